@@ -10,11 +10,14 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/dotcommander/zai/internal/app"
 )
@@ -27,23 +30,42 @@ func closeFile(file *os.File) {
 } //nolint:errcheck // error is already handled in the function
 
 var (
-	audioFile     string
-	audioModel    string
-	audioPrompt   string
-	audioLanguage string
-	audioHotwords string
-	audioStream   bool
-	audioJSON     bool
-	audioUserID   string
+	audioFile         string
+	audioModel        string
+	audioPrompt       string
+	audioLanguage     string
+	audioDetectLang   bool
+	audioHotwords     string
+	audioHotwordsFrom string
+	audioStream       bool
+	audioJSON         bool
+	audioFormat       string
+	audioUserID       string
 	// Preprocessing options
-	audioVAD        bool   // Voice Activity Detection - remove silence
-	audioVideo      string // YouTube video URL to transcribe
-	audioPreprocess bool   // Auto-convert to optimal format (16kHz mono WAV)
+	audioVAD        bool    // Voice Activity Detection - remove silence
+	audioTimestamps bool    // Split at detected silences for real segment times
+	audioVideo      string  // YouTube video URL to transcribe
+	audioPreprocess bool    // Auto-convert to optimal format (16kHz mono WAV)
+	audioNormalize  bool    // Loudness-normalize quiet recordings
+	audioTargetLUFS float64 // Integrated loudness target for --normalize
 	// Cache options
 	audioResume     bool // Resume from previous partial transcription
 	audioClearCache bool // Clear cached transcription and start fresh
+	// Output options
+	audioOutput        string  // Write the transcript to this file instead of stdout
+	audioMergeOutput   string  // Append transcript with a per-file header to this file
+	audioMinConfidence float64 // Mark segments scored below this confidence
+	audioMaxCueChars   int     // Longest subtitle cue before it is split
+	// Concurrency options
+	audioAdaptiveWorkers bool // Adapt chunk workers to observed rate limiting
 )
 
+// audioFormats are the transcript formats accepted by --format.
+var audioFormats = []string{"text", "json", "srt", "vtt"}
+
+// audioChunkSeconds is the length of chunks large files are split into (API limit 30s).
+const audioChunkSeconds = 25
+
 var audioCmd = &cobra.Command{
 	Use:   "audio",
 	Short: "Transcribe audio files to text",
@@ -54,10 +76,24 @@ Examples:
   zai audio -f speech.mp3 --model glm-asr-2512
   zai audio -f interview.wav --prompt "Previous context"
   zai audio -f lecture.wav --hotwords "kubernetes,docker"
+  zai audio -f standup.wav --hotwords-from glossary.md  # Terms pulled from a file
   zai audio --video https://youtu.be/abc123  # YouTube support
   zai audio -f recording.wav --vad  # Remove silence
+  zai audio -f quiet.m4a --normalize --vad  # Boost quiet audio, then remove silence
   zai audio -f recording.wav --resume  # Resume partial transcription
+  zai audio -f talk.mp3 --detect-language  # Probe the first seconds to pick language and model
+  zai audio -f lecture.mp3 --format srt > lecture.srt  # Subtitles, one cue per chunk
+  for f in *.wav; do zai audio -f "$f" --merge-output all.txt; done  # Combined transcript
   cat audio.wav | zai audio  # From stdin
+  zai audio models  # List ASR models
+
+Preprocessing (--vad, --normalize, format conversion) and --detect-language
+require ffmpeg. --detect-language switches to the model configured for the
+detected language under audio.language_models unless --model is given.
+
+--format srt and vtt time cues by chunk position (25-second chunks for
+large files). A file transcribed in one request becomes a single cue spanning
+its duration (estimated from the text when it can't be read from the file).
 
 Supported formats: .wav, .mp3, .mp4, .m4a, .flac, .aac, .ogg
 Maximum file size: 25MB
@@ -66,24 +102,49 @@ Maximum duration: 30 seconds per chunk`,
 	RunE: runAudioTranscription,
 }
 
+var audioModelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List speech recognition (ASR) models",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCapabilityModelList(capabilityAudio, audioCmd.Flags().Lookup("model").DefValue, "transcription, default")
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(audioCmd)
+	audioCmd.AddCommand(audioModelsCmd)
 
 	audioCmd.Flags().StringVarP(&audioFile, "file", "f", "", "Audio file path")
 	audioCmd.Flags().StringVarP(&audioModel, "model", "m", "glm-asr-2512", "ASR model to use")
+	_ = audioCmd.RegisterFlagCompletionFunc("model", completeModelIDs)
 	audioCmd.Flags().StringVarP(&audioPrompt, "prompt", "p", "", "Context from prior transcriptions (max 8000 chars)")
 	audioCmd.Flags().StringVarP(&audioLanguage, "language", "l", "", "Language code (e.g., en, zh, ja)")
+	audioCmd.Flags().BoolVar(&audioDetectLang, "detect-language", false, "Transcribe the first seconds first to detect the language (and pick a model from audio.language_models)")
+	audioCmd.MarkFlagsMutuallyExclusive("language", "detect-language")
 	audioCmd.Flags().StringVar(&audioHotwords, "hotwords", "", "Comma-separated domain vocabulary (max 100 items)")
+	audioCmd.Flags().StringVar(&audioHotwordsFrom, "hotwords-from", "", "Extract hotwords from a glossary or prior transcript (merged with --hotwords)")
 	audioCmd.Flags().BoolVar(&audioStream, "stream", false, "Enable streaming transcription")
-	audioCmd.Flags().BoolVar(&audioJSON, "json", false, "Output in JSON format")
+	audioCmd.Flags().BoolVar(&audioJSON, "json", false, "Output in JSON format (same as --format json)")
+	audioCmd.Flags().StringVar(&audioFormat, "format", "text", "Transcript format: text, json, srt, or vtt")
+	_ = audioCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(audioFormats, cobra.ShellCompDirectiveNoFileComp))
 	audioCmd.Flags().StringVar(&audioUserID, "user-id", "", "User ID for analytics (6-128 characters)")
 	// Preprocessing flags
 	audioCmd.Flags().BoolVar(&audioVAD, "vad", false, "Apply Voice Activity Detection to remove silence (reduces API costs)")
+	audioCmd.Flags().BoolVar(&audioTimestamps, "timestamps", false, "Split audio at detected silences (ffmpeg silencedetect) so segments carry real start/end times")
 	audioCmd.Flags().StringVar(&audioVideo, "video", "", "YouTube video URL to transcribe")
 	audioCmd.Flags().BoolVar(&audioPreprocess, "preprocess", true, "Auto-convert audio to optimal format (16kHz mono WAV)")
+	audioCmd.Flags().BoolVar(&audioNormalize, "normalize", false, "Apply loudness normalization (ffmpeg loudnorm) to improve quiet recordings")
+	audioCmd.Flags().Float64Var(&audioTargetLUFS, "target-lufs", defaultTargetLUFS, "Target integrated loudness for --normalize, in LUFS (-70 to -5)")
 	// Cache flags
 	audioCmd.Flags().BoolVar(&audioResume, "resume", false, "Resume from previous partial transcription")
 	audioCmd.Flags().BoolVar(&audioClearCache, "clear-cache", false, "Clear cached transcription and start fresh")
+	audioCmd.Flags().BoolVar(&audioAdaptiveWorkers, "adaptive-workers", false, fmt.Sprintf("Start chunked transcription with %d workers and adapt (up to %d) to 429/503 responses", minAdaptiveWorkers, maxAdaptiveWorkers))
+	// Output flags
+	audioCmd.Flags().StringVarP(&audioOutput, "output", "o", "", "Write the transcript to this file instead of stdout (format follows the extension unless --format is given)")
+	audioCmd.Flags().IntVar(&audioMaxCueChars, "max-cue-chars", app.DefaultMaxCueChars, "Split SRT/VTT cues longer than this many characters")
+	audioCmd.Flags().Float64Var(&audioMinConfidence, "min-confidence", 0, "Mark transcript segments scored below this confidence (0-1) with [?] for review")
+	audioCmd.Flags().StringVar(&audioMergeOutput, "merge-output", "", "Append the transcript under a '## <filename>' header to this file")
 }
 
 // sanitizePath validates and cleans a file path to prevent command injection.
@@ -118,6 +179,31 @@ func checkFFmpeg() error {
 }
 
 func runAudioTranscription(cmd *cobra.Command, args []string) error { //nolint:gocognit,gocyclo // TODO: decompose into smaller functions
+	if audioJSON {
+		audioFormat = "json"
+	} else {
+		format, err := inferOutputFormat(cmd, "format", audioFormat, audioOutput, audioFormats)
+		if err != nil {
+			return err
+		}
+		audioFormat = format
+	}
+	if !slices.Contains(audioFormats, audioFormat) {
+		return fmt.Errorf("invalid --format %q (must be %s)", audioFormat, strings.Join(audioFormats, ", "))
+	}
+	if audioVAD && audioTimestamps {
+		return fmt.Errorf("--timestamps cannot be combined with --vad: trimmed silence would shift segment times away from the source")
+	}
+	if audioVAD && (audioFormat == "srt" || audioFormat == "vtt") {
+		fmt.Fprintln(os.Stderr, "Warning: --vad trims leading silence, so subtitle times may run ahead of the source media")
+	}
+	if audioMaxCueChars <= 0 {
+		return fmt.Errorf("invalid --max-cue-chars %d (must be positive)", audioMaxCueChars)
+	}
+	if audioMinConfidence < 0 || audioMinConfidence > 1 {
+		return fmt.Errorf("invalid --min-confidence %g (must be between 0 and 1)", audioMinConfidence)
+	}
+
 	// Use extended timeout for large audio files (10 min for long recordings)
 	ctx, cancel := createContext(10 * time.Minute)
 	defer cancel()
@@ -126,12 +212,11 @@ func runAudioTranscription(cmd *cobra.Command, args []string) error { //nolint:g
 	tempMgr := &TempFileManager{}
 	defer tempMgr.Cleanup()
 
-	// Determine audio source and get audio path
-	audioPath, err := determineAudioSource()
+	// Determine audio source and get audio path (temp downloads are registered for cleanup)
+	audioPath, err := determineAudioSource(tempMgr)
 	if err != nil {
 		return err
 	}
-	tempMgr.Add(audioPath)
 
 	// Validate file exists and get original source path
 	originalSource, err := validateAndGetAudioPath(audioPath)
@@ -145,8 +230,14 @@ func runAudioTranscription(cmd *cobra.Command, args []string) error { //nolint:g
 		return err
 	}
 
-	// Handle large files by chunking
-	if shouldChunkFile(audioPath) {
+	if audioDetectLang {
+		if err := detectAudioLanguage(ctx, cmd, audioPath, tempMgr); err != nil {
+			return err
+		}
+	}
+
+	// Handle large files (or --timestamps segmentation) by chunking
+	if audioTimestamps || shouldChunkFile(audioPath) {
 		return handleLargeAudioFile(ctx, audioPath, originalSource, tempMgr)
 	}
 
@@ -155,7 +246,9 @@ func runAudioTranscription(cmd *cobra.Command, args []string) error { //nolint:g
 }
 
 // determineAudioSource determines the audio source (YouTube, file, or stdin) and returns the path.
-func determineAudioSource() (string, error) {
+// Temporary files (YouTube downloads, stdin copies) are registered with tempMgr;
+// user-provided files are never removed.
+func determineAudioSource(tempMgr *TempFileManager) (string, error) {
 	switch {
 	case audioVideo != "":
 		// YouTube source
@@ -163,6 +256,7 @@ func determineAudioSource() (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("YouTube download failed: %w", err)
 		}
+		tempMgr.Add(ytPath)
 		return ytPath, nil
 	case audioFile == "-" || (audioFile == "" && hasStdinData()):
 		// Explicit -f - or auto-detected stdin
@@ -171,7 +265,10 @@ func determineAudioSource() (string, error) {
 		if err != nil {
 			return "", fmt.Errorf("failed to create temp file: %w", err)
 		}
+		tempMgr.Add(stdinPath)
 		return stdinPath, nil
+	case audioFile != "":
+		return audioFile, nil
 	default:
 		return "", fmt.Errorf("audio file required: use -f <file> or --video <youtube_url>, or pipe via stdin")
 	}
@@ -190,8 +287,18 @@ func validateAndGetAudioPath(audioPath string) (string, error) {
 
 // preprocessAudioIfNeeded preprocesses audio if needed and returns the final audio path.
 func preprocessAudioIfNeeded(audioPath string, tempMgr *TempFileManager) (string, error) {
+	filters, err := audioFilterChain()
+	if err != nil {
+		return "", err
+	}
+
+	// A WAV already within API limits can be sent as-is, so ffmpeg is optional
+	if len(filters) == 0 && isDirectWAV(audioPath) {
+		return audioPath, nil
+	}
+
 	// Check ffmpeg before any processing that requires it
-	needsFFmpeg := audioPreprocess || audioVAD
+	needsFFmpeg := audioPreprocess || len(filters) > 0
 	if needsFFmpeg {
 		if err := checkFFmpeg(); err != nil {
 			return "", err
@@ -199,8 +306,8 @@ func preprocessAudioIfNeeded(audioPath string, tempMgr *TempFileManager) (string
 	}
 
 	// Preprocessing: convert to optimal format if needed
-	if audioPreprocess || audioVAD {
-		processedPath, err := preprocessAudio(audioPath, audioVAD)
+	if needsFFmpeg {
+		processedPath, err := preprocessAudio(audioPath, filters)
 		if err != nil {
 			return "", fmt.Errorf("audio preprocessing failed: %w", err)
 		}
@@ -213,6 +320,86 @@ func preprocessAudioIfNeeded(audioPath string, tempMgr *TempFileManager) (string
 	return audioPath, nil
 }
 
+// languageProbeSeconds is how much audio --detect-language transcribes up front.
+const languageProbeSeconds = 8
+
+// detectAudioLanguage transcribes the start of audioPath and asks a chat model
+// for its language, then sets --language and, from audio.language_models,
+// --model unless it was given explicitly.
+func detectAudioLanguage(ctx context.Context, cmd *cobra.Command, audioPath string, tempMgr *TempFileManager) error {
+	if err := checkFFmpeg(); err != nil {
+		return err
+	}
+	headPath, err := extractAudioHead(audioPath, languageProbeSeconds)
+	if err != nil {
+		return fmt.Errorf("language detection failed: %w", err)
+	}
+	tempMgr.Add(headPath)
+
+	client := newClientWithoutHistory()
+	head, err := client.TranscribeAudio(ctx, headPath, app.TranscriptionOptions{Model: audioModel})
+	if err != nil {
+		return fmt.Errorf("language detection failed: %w", err)
+	}
+	if strings.TrimSpace(head.Text) == "" {
+		fmt.Fprintln(os.Stderr, "Could not detect the language (no speech at the start); continuing without it")
+		return nil
+	}
+
+	opts := app.DefaultChatOptions()
+	opts.Model = app.DefaultCompressModel
+	opts.Temperature = app.Float64Ptr(0)
+	opts.MaxTokens = app.IntPtr(10)
+	opts.WebEnabled = app.BoolPtr(false)
+	reply, err := client.Chat(ctx, app.LanguageDetectionPrompt(head.Text), opts)
+	if err != nil {
+		return fmt.Errorf("language detection failed: %w", err)
+	}
+	code, ok := app.ParseLanguageCode(reply)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Could not detect the language (model replied %q); continuing without it\n", strings.TrimSpace(reply))
+		return nil
+	}
+
+	audioLanguage = code
+	if model := viper.GetStringMapString("audio.language_models")[code]; model != "" && !cmd.Flags().Changed("model") {
+		audioModel = model
+	}
+	fmt.Fprintf(os.Stderr, "Detected language: %s (model: %s)\n", code, audioModel)
+	return nil
+}
+
+// extractAudioHead writes the first seconds of inputPath to a temporary 16kHz mono WAV.
+func extractAudioHead(inputPath string, seconds int) (string, error) {
+	sanitizedPath, err := sanitizePath(inputPath)
+	if err != nil {
+		return "", fmt.Errorf("input path validation failed: %w", err)
+	}
+
+	outputPath := filepath.Join(os.TempDir(), fmt.Sprintf("zai-audio-head-%d.wav", time.Now().UnixNano()))
+	args := []string{
+		"-hide_banner",
+		"-loglevel", "error",
+		"-y", "-t", strconv.Itoa(seconds), "-i", sanitizedPath,
+		"-vn", "-acodec", "pcm_s16le", "-ar", "16000", "-ac", "1",
+		outputPath,
+	}
+	cmd := exec.Command("ffmpeg", args...) //nolint:gosec // G204: ffmpeg binary is hardcoded, args are controlled
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("ffmpeg failed: %w (is ffmpeg installed?)", err)
+	}
+	return outputPath, nil
+}
+
+// maxChunkDuration is the longest audio the transcription API accepts per request.
+const maxChunkDuration = 30 * time.Second
+
+// isDirectWAV reports whether a file is a WAV that can be transcribed without
+// ffmpeg: under the size limit and short enough for a single request.
+func isDirectWAV(audioPath string) bool {
+	return !shouldChunkFile(audioPath) && app.IsDirectWAV(audioPath, maxChunkDuration)
+}
+
 // shouldChunkFile checks if the audio file should be chunked based on size.
 func shouldChunkFile(audioPath string) bool {
 	info, err := os.Stat(audioPath)
@@ -235,18 +422,28 @@ func handleLargeAudioFile(ctx context.Context, audioPath, originalSource string,
 	if err != nil {
 		return fmt.Errorf("failed to access audio file: %w", err)
 	}
-	fmt.Fprintf(os.Stderr, "File too large (%d MB), splitting into chunks...\n", info.Size()/1024/1024)
-	chunks, err := splitAudio(audioPath, 25) // 25-second chunks (API limit 30s)
+
+	var chunks []string
+	var starts []time.Duration
+	if audioTimestamps {
+		fmt.Fprintf(os.Stderr, "Detecting silences to split into timed segments...\n")
+		chunks, starts, err = splitAudioAtSilences(audioPath, audioChunkSeconds*time.Second)
+	} else {
+		fmt.Fprintf(os.Stderr, "File too large (%d MB), splitting into chunks...\n", info.Size()/1024/1024)
+		chunks, err = splitAudio(audioPath, audioChunkSeconds)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to chunk audio: %w", err)
 	}
-	tempMgr.AddAll(chunks)
+	if len(chunks) > 1 || chunks[0] != audioPath {
+		tempMgr.AddAll(chunks)
+	}
 
 	// Create client once for all chunk processing
 	client := newClientWithoutHistory()
 
 	// Transcribe each chunk and combine
-	return transcribeChunks(ctx, client, chunks, originalSource, audioPath)
+	return transcribeChunks(ctx, client, chunks, starts, originalSource, audioPath)
 }
 
 // performRegularTranscription performs transcription for normal-sized audio files.
@@ -255,7 +452,10 @@ func performRegularTranscription(ctx context.Context, audioPath, originalSource
 	client := newClientWithoutHistory()
 
 	// Build transcription options
-	opts := buildTranscriptionOptions()
+	opts, err := buildTranscriptionOptions()
+	if err != nil {
+		return err
+	}
 
 	// Perform transcription
 	resp, err := client.TranscribeAudio(ctx, audioPath, opts)
@@ -264,7 +464,16 @@ func performRegularTranscription(ctx context.Context, audioPath, originalSource
 	}
 
 	// Output results
-	outputTranscriptionResult(resp)
+	output, err := formatTranscriptionResult(resp, audioPath, audioMaxCueChars)
+	if err != nil {
+		return err
+	}
+	if err := writeTranscript(output); err != nil {
+		return err
+	}
+	if err := appendMergedTranscript(audioMergeOutput, audioSourceName(), resp.Text); err != nil {
+		return err
+	}
 
 	// Save to history (non-blocking)
 	saveAudioToHistory(resp)
@@ -272,14 +481,61 @@ func performRegularTranscription(ctx context.Context, audioPath, originalSource
 	return nil
 }
 
+// audioSourceName returns a display name for the transcribed input.
+func audioSourceName() string {
+	switch {
+	case audioVideo != "":
+		return audioVideo
+	case audioFile != "" && audioFile != "-":
+		return filepath.Base(audioFile)
+	default:
+		return "stdin"
+	}
+}
+
+// appendMergedTranscript appends text under a "## <name>" header to path,
+// separating it from any earlier transcripts. Does nothing if path is empty.
+func appendMergedTranscript(path, name, text string) error {
+	if path == "" {
+		return nil
+	}
+
+	file, err := os.OpenFile(filepath.Clean(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, outputFileMode(0600))
+	if err != nil {
+		return fmt.Errorf("failed to open merge output: %w", err)
+	}
+	defer closeFile(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to stat merge output: %w", err)
+	}
+
+	var b strings.Builder
+	if info.Size() > 0 {
+		b.WriteString("\n---\n\n")
+	}
+	fmt.Fprintf(&b, "## %s\n\n%s\n", name, strings.TrimSpace(text))
+
+	if _, err := file.WriteString(b.String()); err != nil {
+		return fmt.Errorf("failed to write merge output: %w", err)
+	}
+	return nil
+}
+
 // buildTranscriptionOptions builds the transcription options from command flags.
-func buildTranscriptionOptions() app.TranscriptionOptions {
+func buildTranscriptionOptions() (app.TranscriptionOptions, error) {
+	hotwords, err := resolveHotwords()
+	if err != nil {
+		return app.TranscriptionOptions{}, err
+	}
+
 	opts := app.TranscriptionOptions{
 		Model:    audioModel,
 		Prompt:   audioPrompt,
 		Stream:   audioStream,
 		UserID:   audioUserID,
-		Hotwords: parseHotwords(audioHotwords),
+		Hotwords: hotwords,
 	}
 
 	// Handle language via prompt if provided
@@ -291,32 +547,105 @@ func buildTranscriptionOptions() app.TranscriptionOptions {
 		}
 	}
 
-	return opts
+	return opts, nil
 }
 
-// outputTranscriptionResult outputs the transcription result in the requested format.
-func outputTranscriptionResult(resp *app.TranscriptionResponse) {
-	if audioJSON {
+// resolveHotwords merges --hotwords with terms extracted from --hotwords-from.
+// Explicit hotwords come first so they survive the 100-item cap.
+func resolveHotwords() ([]string, error) {
+	explicit := parseHotwords(audioHotwords)
+	if audioHotwordsFrom == "" {
+		return explicit, nil
+	}
+
+	data, err := os.ReadFile(filepath.Clean(audioHotwordsFrom))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read hotwords file: %w", err)
+	}
+	extracted := app.ExtractHotwords(string(data), app.MaxHotwords)
+	merged := app.MergeHotwords(explicit, extracted)
+	fmt.Fprintf(os.Stderr, "Using %d hotwords (%d extracted from %s)\n", len(merged), len(extracted), audioHotwordsFrom)
+	return merged, nil
+}
+
+// formatTranscriptionResult renders the transcription result in the requested format.
+// Subtitles follow the response segments when present, otherwise one cue
+// spans audioPath's duration; cues longer than maxCueChars are split. With
+// --min-confidence, low-scored segments are marked with [?] and listed under
+// low_confidence_segments in JSON.
+func formatTranscriptionResult(resp *app.TranscriptionResponse, audioPath string, maxCueChars int) (string, error) {
+	lowConfidence, scored := app.LowConfidenceSegments(resp.Segments, audioMinConfidence)
+	minConfidence := 0.0
+	if audioMinConfidence > 0 {
+		if scored {
+			minConfidence = audioMinConfidence
+		} else {
+			fmt.Fprintln(os.Stderr, "Note: the model returned no confidence scores; --min-confidence has no effect")
+		}
+	}
+
+	switch audioFormat {
+	case "srt", "vtt":
+		cues := app.SegmentCues(resp.Segments, minConfidence, maxCueChars)
+		if len(cues) == 0 {
+			duration, err := app.WAVDuration(audioPath)
+			if err != nil {
+				duration = app.EstimateSpeechDuration(resp.Text)
+			}
+			cues = app.SplitCue(app.SubtitleCue{End: duration, Text: resp.Text}, maxCueChars)
+		}
+		return formatSubtitles(cues, audioFormat), nil
+	case "json":
 		output := map[string]interface{}{
 			"id":      resp.ID,
 			"model":   resp.Model,
 			"text":    resp.Text,
 			"created": resp.Created,
 		}
-		data, err := json.MarshalIndent(output, "", "  ")
+		if len(resp.Segments) > 0 {
+			output["segments"] = resp.Segments
+		}
+		if minConfidence > 0 {
+			output["low_confidence_segments"] = lowConfidence
+		}
+		data, err := marshalJSON(output)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to marshal JSON: %v\n", err)
-			return
+			return "", fmt.Errorf("failed to marshal JSON: %w", err)
 		}
-		fmt.Println(string(data))
-	} else {
-		fmt.Println(resp.Text)
+		return string(data) + "\n", nil
+	default:
+		if minConfidence > 0 {
+			return app.AnnotateLowConfidence(resp.Segments, minConfidence) + "\n", nil
+		}
+		return resp.Text + "\n", nil
+	}
+}
+
+// writeTranscript writes the formatted transcript to --output, or stdout when unset.
+// Progress and status messages always go to stderr, so the file holds only the transcript.
+func writeTranscript(output string) error {
+	if audioOutput == "" {
+		fmt.Print(output)
+		return nil
 	}
+	if err := app.WriteFileAtomic(audioOutput, []byte(output), outputFileMode(0644)); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Transcript written to %s\n", audioOutput)
+	return nil
+}
+
+// formatSubtitles renders cues as SRT or WebVTT.
+func formatSubtitles(cues []app.SubtitleCue, format string) string {
+	if format == "vtt" {
+		return app.FormatVTT(cues)
+	}
+	return app.FormatSRT(cues)
 }
 
 // saveAudioToHistory saves the transcription result to history.
 func saveAudioToHistory(resp *app.TranscriptionResponse) {
-	history := app.NewFileHistoryStore("")
+	history := newHistoryStore()
 	entry := app.NewAudioHistoryEntry(resp.Text, resp.Model)
 	if err := history.Save(entry); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Failed to save to history: %v\n", err)
@@ -399,12 +728,18 @@ type chunkResult struct {
 }
 
 // transcribeChunks transcribes multiple audio chunks with caching, resume, and parallel processing.
-func transcribeChunks(ctx context.Context, client *app.Client, chunks []string, cacheSourcePath, audioPath string) error { //nolint:gocognit,gocyclo // TODO: decompose into smaller functions
+// starts holds each chunk's offset in the source when chunks were split at
+// silences; nil means fixed audioChunkSeconds windows.
+func transcribeChunks(ctx context.Context, client *app.Client, chunks []string, starts []time.Duration, cacheSourcePath, audioPath string) error { //nolint:gocognit,gocyclo // TODO: decompose into smaller functions
 	// Get cache path using original source file for consistent cache keys
 	cachePath, err := getCachePath(cacheSourcePath)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: Could not determine cache path: %v\n", err)
 	}
+	if cachePath != "" && starts != nil {
+		// Silence-split chunk indices don't line up with fixed windows
+		cachePath = strings.TrimSuffix(cachePath, ".json") + "-timestamps.json"
+	}
 
 	var cache *AudioCache
 	if cachePath != "" && !audioClearCache {
@@ -434,6 +769,11 @@ func transcribeChunks(ctx context.Context, client *app.Client, chunks []string,
 		}
 	}
 
+	hotwords, err := resolveHotwords()
+	if err != nil {
+		return err
+	}
+
 	allDone := len(pending) == 0
 	if allDone {
 		fmt.Fprintf(os.Stderr, "All %d chunks already transcribed (from cache)\n", len(chunks))
@@ -443,7 +783,7 @@ func transcribeChunks(ctx context.Context, client *app.Client, chunks []string,
 
 	// Process pending chunks in parallel
 	if !allDone { //nolint:nestif // TODO: reduce nesting
-		results := transcribeParallel(ctx, client, chunks, pending)
+		results := transcribeParallel(ctx, client, chunks, pending, hotwords)
 		for res := range results {
 			if res.err != nil {
 				if cachePath != "" {
@@ -460,10 +800,27 @@ func transcribeChunks(ctx context.Context, client *app.Client, chunks []string,
 		}
 	}
 
+	// Chunks never sent (the context ran out while queued) leave a gap; the
+	// cache keeps the rest for a resumed run
+	for i := range chunks {
+		if _, ok := cache.Chunks[i]; !ok {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("chunk %d was not transcribed: %w", i+1, err)
+			}
+			return fmt.Errorf("chunk %d was not transcribed", i+1)
+		}
+	}
+
+	if audioMinConfidence > 0 {
+		fmt.Fprintln(os.Stderr, "Note: --min-confidence is not supported for chunked transcriptions; segments are not marked")
+	}
+
 	// Assemble final text in order
 	var fullText string
+	texts := make([]string, len(chunks))
 	for i := range chunks {
 		if text, ok := cache.Chunks[i]; ok {
+			texts[i] = text
 			if fullText != "" {
 				fullText += "\n"
 			}
@@ -472,24 +829,83 @@ func transcribeChunks(ctx context.Context, client *app.Client, chunks []string,
 	}
 
 	// Output results
-	if audioJSON {
-		output := map[string]interface{}{
+	var output string
+	switch audioFormat {
+	case "srt", "vtt":
+		output = formatSubtitles(chunkCues(chunks, texts, starts, audioMaxCueChars), audioFormat)
+	case "json":
+		result := map[string]interface{}{
 			"model": audioModel,
 			"text":  fullText,
 		}
-		data, _ := json.MarshalIndent(output, "", "  ")
-		fmt.Println(string(data))
-	} else {
-		fmt.Println(fullText)
+		if starts != nil {
+			result["segments"] = chunkSegments(chunkCues(chunks, texts, starts, audioMaxCueChars))
+		}
+		data, err := marshalJSON(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		output = string(data) + "\n"
+	default:
+		output = fullText + "\n"
+	}
+	if err := writeTranscript(output); err != nil {
+		return err
 	}
 
-	return nil
+	return appendMergedTranscript(audioMergeOutput, audioSourceName(), fullText)
 }
 
+// chunkCues builds subtitle cues for transcribed chunks, timed by starts when
+// chunks were split at silences and by fixed windows otherwise. Cues longer
+// than maxCueChars are split.
+func chunkCues(chunks, texts []string, starts []time.Duration, maxCueChars int) []app.SubtitleCue {
+	last, err := app.WAVDuration(chunks[len(chunks)-1])
+	if err != nil {
+		last = 0
+	}
+	if starts == nil {
+		chunkDuration := audioChunkSeconds * time.Second
+		var lastEnd time.Duration
+		if last > 0 {
+			lastEnd = time.Duration(len(chunks)-1)*chunkDuration + last
+		}
+		return app.ChunkCues(texts, chunkDuration, lastEnd, maxCueChars)
+	}
+	return app.SpanCues(texts, starts, starts[len(starts)-1]+last, maxCueChars)
+}
+
+// chunkSegments converts cues into timed transcript segments for JSON output.
+func chunkSegments(cues []app.SubtitleCue) []app.TranscriptionSegment {
+	segments := make([]app.TranscriptionSegment, 0, len(cues))
+	for _, cue := range cues {
+		segments = append(segments, app.TranscriptionSegment{
+			Start: cue.Start.Seconds(),
+			End:   cue.End.Seconds(),
+			Text:  cue.Text,
+		})
+	}
+	return segments
+}
+
+// Chunk worker counts: fixed by default, or bounds for --adaptive-workers.
+const (
+	chunkWorkers       = 5
+	minAdaptiveWorkers = 2
+	maxAdaptiveWorkers = 10
+)
+
 // transcribeParallel processes chunks concurrently using a worker pool.
-// Client is shared across workers for connection pooling.
-func transcribeParallel(ctx context.Context, client *app.Client, chunks []string, pendingIndices []int) <-chan chunkResult { //nolint:gocognit // TODO: decompose into smaller functions
-	numWorkers := 5
+// Client is shared across workers for connection pooling. With
+// --adaptive-workers, an AIMD limiter decides how many workers may call the
+// API at once: it grows on success and halves on 429/503 responses.
+func transcribeParallel(ctx context.Context, client *app.Client, chunks []string, pendingIndices []int, hotwords []string) <-chan chunkResult { //nolint:gocognit // TODO: decompose into smaller functions
+	numWorkers := chunkWorkers
+	var limiter *app.AdaptiveLimiter
+	if audioAdaptiveWorkers {
+		numWorkers = maxAdaptiveWorkers
+		limiter = app.NewAdaptiveLimiter(minAdaptiveWorkers, maxAdaptiveWorkers)
+	}
 	results := make(chan chunkResult, len(pendingIndices))
 	jobs := make(chan int, len(pendingIndices))
 
@@ -498,7 +914,7 @@ func transcribeParallel(ctx context.Context, client *app.Client, chunks []string
 		wg.Add(1)
 		go func(workerID int) {
 			defer wg.Done()
-			opts := app.TranscriptionOptions{Model: audioModel, Prompt: audioPrompt}
+			opts := app.TranscriptionOptions{Model: audioModel, Prompt: audioPrompt, Hotwords: hotwords}
 
 			for idx := range jobs {
 				var resp *app.TranscriptionResponse
@@ -506,7 +922,7 @@ func transcribeParallel(ctx context.Context, client *app.Client, chunks []string
 
 				// Retry with exponential backoff + jitter (matches Chat pattern)
 				for attempt := 1; attempt <= 3; attempt++ {
-					resp, err = client.TranscribeAudio(ctx, chunks[idx], opts)
+					resp, err = transcribeChunk(ctx, client, limiter, chunks[idx], opts)
 					if err == nil {
 						break
 					}
@@ -528,12 +944,7 @@ func transcribeParallel(ctx context.Context, client *app.Client, chunks []string
 		}(w)
 	}
 
-	go func() {
-		for _, idx := range pendingIndices {
-			jobs <- idx
-		}
-		close(jobs)
-	}()
+	go feedJobs(ctx, jobs, pendingIndices, viper.GetDuration("throttle"))
 
 	go func() {
 		wg.Wait()
@@ -543,8 +954,47 @@ func transcribeParallel(ctx context.Context, client *app.Client, chunks []string
 	return results
 }
 
-// preprocessAudio converts audio to optimal format and optionally applies VAD.
-func preprocessAudio(inputPath string, applyVAD bool) (string, error) {
+// transcribeChunk transcribes one chunk, holding a limiter slot when adaptive.
+func transcribeChunk(ctx context.Context, client *app.Client, limiter *app.AdaptiveLimiter, path string, opts app.TranscriptionOptions) (*app.TranscriptionResponse, error) {
+	if limiter == nil {
+		return client.TranscribeAudio(ctx, path, opts)
+	}
+	if err := limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	resp, err := client.TranscribeAudio(ctx, path, opts)
+	throttled := app.IsThrottleError(err)
+	limiter.Release(throttled)
+	if throttled {
+		fmt.Fprintf(os.Stderr, "Rate limited; reducing to %d concurrent chunk(s)\n", limiter.Limit())
+	}
+	return resp, err
+}
+
+// defaultTargetLUFS is the --normalize loudness target, typical for speech.
+const defaultTargetLUFS = -16.0
+
+// vadFilter trims silence from both ends of the audio.
+const vadFilter = "silenceremove=start_periods=1:start_duration=1:start_threshold=-50dB:detection=peak,aformat=dblp,areverse,silenceremove=start_periods=1:start_duration=1:start_threshold=-50dB:detection=peak,aformat=dblp,areverse"
+
+// audioFilterChain returns the ffmpeg audio filters selected by flags.
+// Normalization runs first so quiet speech isn't mistaken for silence by VAD.
+func audioFilterChain() ([]string, error) {
+	var filters []string
+	if audioNormalize {
+		if audioTargetLUFS < -70 || audioTargetLUFS > -5 {
+			return nil, fmt.Errorf("--target-lufs must be between -70 and -5, got %g", audioTargetLUFS)
+		}
+		filters = append(filters, fmt.Sprintf("loudnorm=I=%g:TP=-1.5:LRA=11", audioTargetLUFS))
+	}
+	if audioVAD {
+		filters = append(filters, vadFilter)
+	}
+	return filters, nil
+}
+
+// preprocessAudio converts audio to optimal format and applies the given ffmpeg filters.
+func preprocessAudio(inputPath string, filters []string) (string, error) {
 	// Sanitize input path to prevent command injection
 	sanitizedPath, err := sanitizePath(inputPath)
 	if err != nil {
@@ -553,7 +1003,7 @@ func preprocessAudio(inputPath string, applyVAD bool) (string, error) {
 
 	// Check if already optimal WAV
 	ext := strings.ToLower(filepath.Ext(sanitizedPath))
-	if ext == ".wav" && !applyVAD {
+	if ext == ".wav" && len(filters) == 0 {
 		return sanitizedPath, nil
 	}
 
@@ -570,9 +1020,9 @@ func preprocessAudio(inputPath string, applyVAD bool) (string, error) {
 		"-ac", "1", // Mono
 	}
 
-	// Apply VAD filter if requested
-	if applyVAD {
-		args = append(args, "-af", "silenceremove=start_periods=1:start_duration=1:start_threshold=-50dB:detection=peak,aformat=dblp,areverse,silenceremove=start_periods=1:start_duration=1:start_threshold=-50dB:detection=peak,aformat=dblp,areverse")
+	// Apply normalization and VAD filters as one chain
+	if len(filters) > 0 {
+		args = append(args, "-af", strings.Join(filters, ","))
 	}
 
 	args = append(args, outputPath)
@@ -585,8 +1035,70 @@ func preprocessAudio(inputPath string, applyVAD bool) (string, error) {
 	return outputPath, nil
 }
 
-// splitAudio splits an audio file into chunks using ffmpeg.
+// splitAudio splits an audio file into chunks using ffmpeg, returned in
+// playback order (chunk i starts at i*chunkDuration seconds).
 func splitAudio(inputPath string, chunkDuration int) ([]string, error) {
+	return segmentAudio(inputPath, "-segment_time", fmt.Sprintf("%d", chunkDuration))
+}
+
+// silenceDetectFilter finds pauses of at least 0.4s quieter than -35dB.
+const silenceDetectFilter = "silencedetect=noise=-35dB:d=0.4"
+
+// splitAudioAtSilences splits audio at detected pauses into segments of at
+// most maxSegment and returns them with their start offsets. Falls back to
+// fixed audioChunkSeconds windows (nil starts) when no silence is detected
+// or the duration is unknown, since the final segment could not be capped.
+func splitAudioAtSilences(inputPath string, maxSegment time.Duration) ([]string, []time.Duration, error) {
+	sanitizedPath, err := sanitizePath(inputPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("input path validation failed: %w", err)
+	}
+
+	cmd := exec.Command("ffmpeg", "-hide_banner", "-nostats", "-i", sanitizedPath, "-af", silenceDetectFilter, "-f", "null", "-") //nolint:gosec // G204: ffmpeg binary is hardcoded, args are controlled
+	log, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, nil, fmt.Errorf("silence detection failed: %w", err)
+	}
+
+	silences := app.ParseSilenceDetect(string(log))
+	if len(silences) == 0 {
+		fmt.Fprintf(os.Stderr, "No silences detected; splitting into %ds chunks\n", audioChunkSeconds)
+		chunks, err := splitAudio(inputPath, audioChunkSeconds)
+		return chunks, nil, err
+	}
+
+	total, ok := app.ParseFFmpegDuration(string(log))
+	if !ok {
+		// Without the duration the span after the last silence can't be capped
+		fmt.Fprintf(os.Stderr, "Could not read the audio duration; splitting into %ds chunks\n", audioChunkSeconds)
+		chunks, err := splitAudio(inputPath, audioChunkSeconds)
+		return chunks, nil, err
+	}
+	points := app.SilenceSplitPoints(silences, total, maxSegment)
+	starts := append([]time.Duration{0}, points...)
+	if len(points) == 0 {
+		// Short enough for one request: the input itself is the only segment
+		return []string{inputPath}, starts, nil
+	}
+
+	times := make([]string, len(points))
+	for i, p := range points {
+		times[i] = fmt.Sprintf("%.3f", p.Seconds())
+	}
+	chunks, err := segmentAudio(inputPath, "-segment_times", strings.Join(times, ","))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chunks) != len(starts) {
+		return nil, nil, fmt.Errorf("expected %d segments, ffmpeg produced %d", len(starts), len(chunks))
+	}
+	fmt.Fprintf(os.Stderr, "Split at %d silences into %d segments\n", len(points), len(chunks))
+	return chunks, starts, nil
+}
+
+// segmentAudio runs ffmpeg's segment muxer with the given split arguments
+// (-segment_time or -segment_times) and returns the segments in playback order.
+func segmentAudio(inputPath string, splitArgs ...string) ([]string, error) {
 	// Sanitize input path to prevent command injection
 	sanitizedPath, err := sanitizePath(inputPath)
 	if err != nil {
@@ -601,10 +1113,9 @@ func splitAudio(inputPath string, chunkDuration int) ([]string, error) {
 		"-loglevel", "error",
 		"-i", sanitizedPath,
 		"-f", "segment",
-		"-segment_time", fmt.Sprintf("%d", chunkDuration),
-		"-c", "copy",
-		chunkPattern,
 	}
+	args = append(args, splitArgs...)
+	args = append(args, "-c", "copy", chunkPattern)
 
 	cmd := exec.Command("ffmpeg", args...) //nolint:gosec // G204: ffmpeg binary is hardcoded, args are controlled
 	if err := cmd.Run(); err != nil {
@@ -622,7 +1133,8 @@ func splitAudio(inputPath string, chunkDuration int) ([]string, error) {
 		return nil, fmt.Errorf("no chunks generated")
 	}
 
-	return chunks, nil
+	// Glob order isn't guaranteed; the segment index is the playback order
+	return app.SortSegmentPaths(chunks), nil
 }
 
 // downloadYouTubeAudio downloads audio from a YouTube video using yt-dlp.
@@ -726,8 +1238,8 @@ func parseHotwords(s string) []string {
 		}
 	}
 	// Limit to 100 items
-	if len(hotwords) > 100 {
-		hotwords = hotwords[:100]
+	if len(hotwords) > app.MaxHotwords {
+		hotwords = hotwords[:app.MaxHotwords]
 	}
 	return hotwords
 }
@@ -0,0 +1,251 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/app"
+)
+
+var (
+	batchPrompts  string
+	batchOutDir   string
+	batchParallel int
+)
+
+var batchCmd = &cobra.Command{
+	Use:   "batch",
+	Short: "Run many independent prompts concurrently",
+	Long: `Send each line of a prompts file as its own one-shot chat request.
+
+Prompts run concurrently (bounded by --parallel) through the same
+rate-limited client as one-shot mode. Each prompt is independent; no
+conversation context is shared. Blank lines are skipped.
+
+Responses are printed in input order with separators, or written to
+<out-dir>/<index>.txt when --out-dir is set. Timing and success/failure
+are reported per prompt.
+
+Examples:
+  zai batch --prompts prompts.txt
+  zai batch --prompts prompts.txt --out-dir results/ --parallel 3
+  zai batch --prompts prompts.txt --json
+  zai batch --prompts prompts.txt --throttle 2s  # Pause between requests`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatch()
+	},
+}
+
+func init() {
+	batchCmd.Flags().StringVar(&batchPrompts, "prompts", "", "File with one prompt per line (required)")
+	batchCmd.Flags().StringVar(&batchOutDir, "out-dir", "", "Write each response to <out-dir>/<index>.txt")
+	batchCmd.Flags().IntVar(&batchParallel, "parallel", 5, "Maximum concurrent requests")
+	_ = batchCmd.MarkFlagRequired("prompts")
+
+	rootCmd.AddCommand(batchCmd)
+}
+
+// batchResult holds the outcome of one prompt.
+type batchResult struct {
+	Index      int           `json:"index"`
+	Prompt     string        `json:"prompt"`
+	Response   string        `json:"response,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	DurationMS int64         `json:"duration_ms"`
+	Duration   time.Duration `json:"-"`
+	err        error
+}
+
+func runBatch() error {
+	if batchParallel < 1 {
+		return fmt.Errorf("--parallel must be at least 1")
+	}
+
+	prompts, err := readBatchPrompts(batchPrompts)
+	if err != nil {
+		return err
+	}
+	if len(prompts) == 0 {
+		return fmt.Errorf("no prompts found in %s", batchPrompts)
+	}
+
+	if batchOutDir != "" {
+		if err := os.MkdirAll(batchOutDir, 0755); err != nil {
+			return fmt.Errorf("failed to create output directory: %w", err)
+		}
+	}
+
+	client := newClient()
+	opts := app.DefaultChatOptions()
+	opts.Think = viper.GetBool("think")
+	opts.SystemPrompt = viper.GetString("system")
+	applySamplingFlags(&opts)
+
+	ctx, cancel := createContext(30 * time.Minute)
+	defer cancel()
+
+	start := time.Now()
+	results := make([]batchResult, len(prompts))
+	for r := range chatParallel(ctx, client, prompts, opts, batchParallel) {
+		if r.err == nil && batchOutDir != "" {
+			path := filepath.Join(batchOutDir, fmt.Sprintf("%d.txt", r.Index))
+			if err := os.WriteFile(path, []byte(r.Response+"\n"), outputFileMode(0600)); err != nil {
+				r.err = fmt.Errorf("failed to write %s: %w", path, err)
+			}
+		}
+		if r.err != nil {
+			r.Error = r.err.Error()
+		}
+		r.DurationMS = r.Duration.Milliseconds()
+		results[r.Index-1] = r
+		printBatchStatus(r)
+	}
+	for i := range results {
+		if results[i].Index == 0 {
+			// Never sent: the context ran out while prompts were queued
+			err := fmt.Errorf("not run: %w", ctx.Err())
+			results[i] = batchResult{Index: i + 1, Prompt: prompts[i], Error: err.Error(), err: err}
+		}
+	}
+
+	failed := printBatchResults(results)
+	fmt.Fprintf(os.Stderr, "\n%d/%d succeeded in %v\n",
+		len(results)-failed, len(results), time.Since(start).Round(time.Millisecond))
+
+	return batchError(len(results)-failed, failed)
+}
+
+// readBatchPrompts reads non-blank lines from path.
+func readBatchPrompts(path string) ([]string, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prompts file: %w", err)
+	}
+	defer file.Close() //nolint:errcheck // read-only file
+
+	var prompts []string
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxStdinSize)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read prompts file: %w", err)
+	}
+	return prompts, nil
+}
+
+// chatParallel sends prompts concurrently using a worker pool.
+// Results arrive in completion order; Index is 1-based input order.
+func chatParallel(ctx context.Context, client *app.Client, prompts []string, opts app.ChatOptions, numWorkers int) <-chan batchResult {
+	results := make(chan batchResult, len(prompts))
+	jobs := make(chan int, len(prompts))
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				started := time.Now()
+				response, err := client.Chat(ctx, prompts[idx], opts)
+				results <- batchResult{
+					Index:    idx + 1,
+					Prompt:   prompts[idx],
+					Response: response,
+					Duration: time.Since(started),
+					err:      err,
+				}
+			}
+		}()
+	}
+
+	indices := make([]int, len(prompts))
+	for i := range indices {
+		indices[i] = i
+	}
+	go feedJobs(ctx, jobs, indices, viper.GetDuration("throttle"))
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// feedJobs sends indices to jobs, pausing throttle between items, then closes jobs.
+// The pause is in addition to the client rate limiter and stops early on cancel.
+func feedJobs(ctx context.Context, jobs chan<- int, indices []int, throttle time.Duration) {
+	defer close(jobs)
+	for i, idx := range indices {
+		if i > 0 && throttle > 0 {
+			select {
+			case <-time.After(throttle):
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case jobs <- idx:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// printBatchStatus reports a finished prompt on stderr.
+func printBatchStatus(r batchResult) {
+	if r.err != nil {
+		fmt.Fprintf(os.Stderr, "✗ [%d] %v: %v\n", r.Index, r.Duration.Round(time.Millisecond), r.err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "✓ [%d] %v\n", r.Index, r.Duration.Round(time.Millisecond))
+}
+
+// printBatchResults prints responses in input order and returns the failure count.
+// With --out-dir, responses are already on disk and only JSON output is printed.
+func printBatchResults(results []batchResult) int {
+	failed := 0
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+		}
+	}
+
+	if viper.GetBool("json") {
+		data, err := marshalJSON(results)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to marshal JSON: %v\n", err)
+			return failed
+		}
+		fmt.Println(string(data))
+		return failed
+	}
+
+	if batchOutDir != "" {
+		return failed
+	}
+
+	for _, r := range results {
+		fmt.Printf("\n── [%d] %s\n", r.Index, truncate(r.Prompt, 60))
+		if r.err != nil {
+			fmt.Printf("(failed: %v)\n", r.err)
+			continue
+		}
+		fmt.Println(r.Response)
+	}
+	return failed
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/app"
+)
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect and clear the search cache",
+	Long: `Inspect and clear the web search cache.
+
+The cache lives in web_search.cache_dir (default ~/.cache/zai/search).
+
+Examples:
+  zai cache stats
+  zai cache stats --json
+  zai cache cleanup   # Remove expired entries
+  zai cache clear     # Remove every entry`,
+}
+
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show search cache size and entry counts",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheStats()
+	},
+}
+
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove all search cache entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheRemove(false)
+	},
+}
+
+var cacheCleanupCmd = &cobra.Command{
+	Use:   "cleanup",
+	Short: "Remove expired search cache entries",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheRemove(true)
+	},
+}
+
+func init() {
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheCleanupCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// newFileSearchCache returns the search cache at web_search.cache_dir.
+func newFileSearchCache() *app.FileSearchCache {
+	return app.NewFileSearchCache(viper.GetString("web_search.cache_dir"))
+}
+
+func runCacheStats() error {
+	stats, err := newFileSearchCache().Stats()
+	if err != nil {
+		return err
+	}
+
+	if viper.GetBool("json") {
+		data, err := marshalJSON(stats)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cache stats: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("  %s %d\n", theme.Dim.Render("Entries:"), stats.TotalEntries)
+	fmt.Printf("  %s %d\n", theme.Dim.Render("Expired:"), stats.ExpiredEntries)
+	fmt.Printf("  %s %.2f MB\n", theme.Dim.Render("Size:   "), float64(stats.SizeBytes)/(1024*1024))
+	fmt.Printf("  %s %s\n", theme.Dim.Render("Dir:    "), stats.CacheDir)
+	return nil
+}
+
+// runCacheRemove clears the cache, or only its expired entries when
+// expiredOnly is set, and reports how many entries were removed.
+func runCacheRemove(expiredOnly bool) error {
+	cache := newFileSearchCache()
+	before, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+
+	remove := cache.Clear
+	if expiredOnly {
+		remove = cache.Cleanup
+	}
+	if err := remove(); err != nil {
+		return err
+	}
+
+	after, err := cache.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Removed %d of %d cache entries from %s\n", before.TotalEntries-after.TotalEntries, before.TotalEntries, before.CacheDir)
+	return nil
+}
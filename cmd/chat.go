@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -31,14 +32,62 @@ The -f flag loads a file into context for the entire session.
 
 Examples:
   zai chat                    # Start REPL
-  zai chat -f main.go         # Start REPL with file in context`,
+  zai chat -f main.go         # Start REPL with file in context
+  zai chat --warm=false       # Skip connection pre-warming
+  zai chat --dedupe-urls=false  # Re-fetch URLs on every mention
+  zai chat --export-on-exit notes/session.md  # Save a transcript when done
+  zai chat --context-file scenario.json       # Seed with prior messages (read-only)
+  zai chat --save-session-on-error            # Keep the conversation if the REPL crashes
+
+--context-file takes a JSON array of {"role", "content"} messages, such as a
+transcript saved with --export-format json. The file is never written to.
+
+--save-session-on-error writes the conversation to
+~/.config/zai/sessions/crash-<timestamp>.json if the REPL panics or fails,
+or a message fails in a way retrying won't fix (rejected API key, open
+circuit breaker, exhausted retry budget), so it can be picked up again with
+--context-file.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := inferOutputFormat(cmd, "export-format", chatExportFormat, chatExportOnExit, chatExportFormats)
+		if err != nil {
+			return err
+		}
+		chatExportFormat = format
 		return runChatREPL()
 	},
 }
 
+var (
+	chatWarm         bool
+	chatDedupeURLs   bool
+	chatExportOnExit string
+	chatExportFormat string
+	chatContextFile  string
+	chatSaveOnError  bool
+)
+
+// chatExportFormats are the transcript formats accepted by --export-format.
+var chatExportFormats = []string{"markdown", "json"}
+
 func init() {
 	rootCmd.AddCommand(chatCmd)
+	chatCmd.Flags().BoolVar(&chatWarm, "warm", true, "pre-warm the API connection so the first message skips the TLS handshake")
+	chatCmd.Flags().StringVar(&chatExportOnExit, "export-on-exit", "", "save the conversation to this file when the session ends (exit, quit, or EOF)")
+	chatCmd.Flags().StringVar(&chatExportFormat, "export-format", "markdown", "transcript format for --export-on-exit: markdown or json (default: from the file extension, else markdown)")
+	_ = chatCmd.RegisterFlagCompletionFunc("export-format", cobra.FixedCompletions(chatExportFormats, cobra.ShellCompDirectiveNoFileComp))
+	chatCmd.Flags().StringVar(&chatContextFile, "context-file", "", "seed the conversation with messages from a JSON file (e.g. a --export-format json transcript)")
+	chatCmd.Flags().BoolVar(&chatSaveOnError, "save-session-on-error", false, "if the REPL panics or fails, save the conversation to a crash-<timestamp>.json file for --context-file")
+	chatCmd.Flags().BoolVar(&chatDedupeURLs, "dedupe-urls", true, "fetch each URL once per session and reuse it (use /refetch <url> to refresh)")
+}
+
+// warmConnection opens a pooled connection to the API in the background with a
+// lightweight models request. Failures are ignored: the first message simply pays the cost.
+func warmConnection(ctx context.Context, client *app.Client) {
+	go func() {
+		warmCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		_, _ = client.ListModels(warmCtx)
+	}()
 }
 
 // animateThinking displays an animated spinner while waiting for API response.
@@ -47,10 +96,21 @@ func animateThinking(w io.Writer, stop *atomic.Bool) {
 	if w == nil {
 		w = os.Stdout
 	}
+	frames := spinnerFrames()
+	if len(frames) == 0 {
+		// Static message for terminals that can't animate
+		fmt.Fprintf(w, "\r%s", theme.Dim.Render("Thinking...")) //nolint:errcheck // terminal output
+		for !stop.Load() {
+			time.Sleep(80 * time.Millisecond)
+		}
+		fmt.Fprint(w, "\r\033[K") //nolint:errcheck // terminal output
+		return
+	}
+
 	spinnerStyle := theme.SpinnerStyle()
 	i := 0
 	for !stop.Load() {
-		fmt.Fprintf(w, "\r%s %s", spinnerStyle.Render(SpinnerFrames[i%len(SpinnerFrames)]), theme.Dim.Render("Thinking...")) //nolint:errcheck // terminal output
+		fmt.Fprintf(w, "\r%s %s", spinnerStyle.Render(frames[i%len(frames)]), theme.Dim.Render("Thinking...")) //nolint:errcheck // terminal output
 		time.Sleep(80 * time.Millisecond)
 		i++
 	}
@@ -58,7 +118,8 @@ func animateThinking(w io.Writer, stop *atomic.Bool) {
 }
 
 // printWelcomeBanner displays the styled welcome message.
-func printWelcomeBanner(filePath string, searchEnabled bool) {
+// seeded is the number of messages loaded with --context-file.
+func printWelcomeBanner(filePath string, searchEnabled bool, seeded int) {
 	fmt.Println()
 	fmt.Println(theme.Title.Render(" Z.AI Chat "))
 	fmt.Println()
@@ -66,6 +127,9 @@ func printWelcomeBanner(filePath string, searchEnabled bool) {
 	if filePath != "" {
 		fmt.Println(theme.Info.Render("  File: ") + theme.Dim.Render(filePath))
 	}
+	if seeded > 0 {
+		fmt.Println(theme.Info.Render("  Context: ") + theme.Dim.Render(fmt.Sprintf("%d messages from %s", seeded, chatContextFile)))
+	}
 	if searchEnabled {
 		fmt.Println(theme.Info.Render("  Search: ") + theme.Dim.Render("enabled (answers include web search)"))
 	}
@@ -90,8 +154,10 @@ func printStyledHelp() {
 		{"history", "Show session history"},
 		{"context", "Show conversation context"},
 		{"clear", "Clear conversation and screen"},
+		{"system <text>", "Replace the system prompt"},
 		{"search <query>", "Search the web"},
 		{"web <url>", "Fetch and display web page"},
+		{"refetch <url>", "Refresh a page fetched earlier"},
 		{"exit, quit", "Exit chat"},
 	}
 
@@ -112,7 +178,7 @@ func printStyledHelp() {
 	fmt.Println(theme.Divider.Render(strings.Repeat("─", 40)))
 	tips := []string{
 		"Previous messages are used as context",
-		"URLs in messages are auto-fetched",
+		"URLs in messages are auto-fetched (once per session)",
 		"Web/search results are added to context",
 		"Use --search flag to auto-search every message",
 	}
@@ -123,7 +189,7 @@ func printStyledHelp() {
 }
 
 // runChatREPL starts the interactive chat session.
-func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL into smaller functions
+func runChatREPL() (err error) { //nolint:gocognit,gocyclo // TODO: decompose REPL into smaller functions
 	// Set up signal handling for graceful shutdown
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
@@ -131,12 +197,48 @@ func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL int
 	// Initialize client and options
 	client, baseOpts, searchEnabled := initializeChatOptions()
 
+	instructions, err := loadInstructions(viper.GetString("chat.instructions_file"))
+	if err != nil {
+		return err
+	}
+	baseOpts.Instructions = instructions
+
+	if chatDedupeURLs {
+		baseOpts.WebCache = app.NewWebContentCache()
+	}
+
+	if chatWarm {
+		warmConnection(ctx, client)
+	}
+
 	// Track conversation context and history
-	var conversationContext []app.Message
+	conversationContext, err := loadContextFile(chatContextFile)
+	if err != nil {
+		return err
+	}
 	var sessionHistory []string
+	firstTurn := true
+
+	// Resolve --cache-prefix once so every turn, not just the first, sends the file
+	baseOpts.Context = conversationContext
+	if baseOpts, err = client.ApplyCachePrefix(ctx, baseOpts); err != nil {
+		return err
+	}
+
+	if chatSaveOnError {
+		defer func() {
+			r := recover()
+			if r != nil || err != nil {
+				saveCrashSession(conversationContext)
+			}
+			if r != nil {
+				panic(r)
+			}
+		}()
+	}
 
 	// Show welcome
-	printWelcomeBanner(baseOpts.FilePath, searchEnabled)
+	printWelcomeBanner(baseOpts.FilePath, searchEnabled, len(conversationContext))
 
 	// Main REPL loop
 	scanner := bufio.NewScanner(os.Stdin)
@@ -145,13 +247,22 @@ func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL int
 			break
 		}
 
-		input := readUserInput(scanner)
+		input, ok := readUserInput(scanner)
+		if !ok || isExitCommand(input) {
+			fmt.Println()
+			fmt.Println(theme.Dim.Render("Goodbye!"))
+			fmt.Println()
+			break
+		}
 		if input == "" {
 			continue
 		}
 
 		// Handle special commands
 		if handled, err := handleSpecialCommands(input, &conversationContext, &sessionHistory); handled {
+			if len(conversationContext) == 0 {
+				firstTurn = true // Cleared: start over with the file and instructions
+			}
 			if err != nil {
 				fmt.Println(theme.ErrorText.Render("Error: ") + theme.Dim.Render(err.Error()))
 				fmt.Println()
@@ -159,6 +270,12 @@ func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL int
 			continue
 		}
 
+		// Handle system command
+		if isSystemCommand(input) {
+			handleSystemCommand(input, &baseOpts)
+			continue
+		}
+
 		// Handle search command
 		if isSearchCommand(input) {
 			if err := handleSearchCommand(ctx, client, input, &conversationContext, &sessionHistory); err != nil {
@@ -168,9 +285,18 @@ func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL int
 			continue
 		}
 
+		// Handle refetch command
+		if isRefetchCommand(input) {
+			if err := handleRefetchCommand(ctx, client, input, baseOpts.WebCache); err != nil {
+				fmt.Println(theme.ErrorText.Render("Error: ") + theme.Dim.Render(err.Error()))
+				fmt.Println()
+			}
+			continue
+		}
+
 		// Handle web command
 		if isWebCommand(input) {
-			if err := handleWebCommand(ctx, client, input, &conversationContext, &sessionHistory); err != nil {
+			if err := handleWebCommand(ctx, client, input, baseOpts.WebCache, &conversationContext, &sessionHistory); err != nil {
 				fmt.Println(theme.ErrorText.Render("Error: ") + theme.Dim.Render(err.Error()))
 				fmt.Println()
 			}
@@ -178,16 +304,111 @@ func runChatREPL() error { //nolint:gocognit,gocyclo // TODO: decompose REPL int
 		}
 
 		// Handle regular chat message
-		if err := handleRegularChat(ctx, client, baseOpts, input, searchEnabled, &conversationContext, &sessionHistory); err != nil {
+		if err := handleRegularChat(ctx, client, baseOpts, input, searchEnabled, firstTurn, &conversationContext, &sessionHistory); err != nil {
 			fmt.Println(theme.ErrorText.Render("Error: ") + theme.Dim.Render(err.Error()))
 			fmt.Println()
+			// Later messages would fail the same way, so keep the work safe now
+			if chatSaveOnError && app.IsUnrecoverableError(err) {
+				saveCrashSession(conversationContext)
+			}
 			continue
 		}
+		firstTurn = false
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	if chatExportOnExit != "" {
+		return exportTranscript(chatExportOnExit, chatExportFormat, conversationContext)
+	}
+	return nil
+}
+
+// saveCrashSession writes the conversation to a timestamped JSON file under
+// ~/.config/zai/sessions after a panic, a fatal error, or an API error that
+// later messages can't recover from. Best effort: a failure here is reported
+// but doesn't mask the original error.
+func saveCrashSession(conversation []app.Message) {
+	if len(conversation) == 0 {
+		return
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not save session: %v\n", err)
+		return
+	}
+	path := filepath.Join(home, ".config", "zai", "sessions", "crash-"+time.Now().Format("20060102-150405")+".json")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not save session: %v\n", err)
+		return
+	}
+	if err := exportTranscript(path, "json", conversation); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not save session: %v\n", err)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Resume with: zai chat --context-file %s\n", path)
+}
+
+// loadContextFile reads the messages that seed the conversation, if a file is set.
+func loadContextFile(path string) ([]app.Message, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read context file: %w", err)
+	}
+	messages, err := app.ParseMessages(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid context file %s: %w", path, err)
+	}
+	return messages, nil
+}
+
+// exportTranscript writes the conversation to path as Markdown or JSON.
+func exportTranscript(path, format string, conversation []app.Message) error {
+	var data []byte
+	switch format {
+	case "json":
+		if conversation == nil {
+			conversation = []app.Message{}
+		}
+		var err error
+		data, err = marshalJSON(conversation)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transcript: %w", err)
+		}
+		data = append(data, '\n')
+	case "markdown", "md":
+		data = []byte(formatTranscriptMarkdown(conversation, time.Now()))
+	default:
+		return fmt.Errorf("invalid --export-format %q (must be markdown or json)", format)
 	}
 
+	if err := app.WriteFileAtomic(path, data, outputFileMode(0600)); err != nil {
+		return fmt.Errorf("failed to export transcript: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Transcript saved to %s (%d messages)\n", path, len(conversation))
 	return nil
 }
 
+// formatTranscriptMarkdown renders messages as a readable Markdown transcript.
+func formatTranscriptMarkdown(conversation []app.Message, exported time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("# Chat transcript\n\n")
+	sb.WriteString(fmt.Sprintf("_Exported %s_\n", exported.Format("2006-01-02 15:04")))
+	for _, msg := range conversation {
+		role := "AI"
+		if msg.Role == "user" {
+			role = "You"
+		}
+		sb.WriteString(fmt.Sprintf("\n## %s\n\n%s\n", role, strings.TrimSpace(msg.Content)))
+	}
+	return sb.String()
+}
+
 // initializeChatOptions sets up the client and base options for the chat session.
 func initializeChatOptions() (*app.Client, app.ChatOptions, bool) {
 	client := newClient()
@@ -195,6 +416,13 @@ func initializeChatOptions() (*app.Client, app.ChatOptions, bool) {
 	baseOpts.FilePath = viper.GetString("file")
 	baseOpts.Think = viper.GetBool("think")
 	baseOpts.SystemPrompt = viper.GetString("system")
+	baseOpts.CompressFile = viper.GetBool("compress")
+	baseOpts.CompressModel = viper.GetString("chat.compress_model")
+	baseOpts.CachePrefix = viper.GetBool("cache_prefix")
+	baseOpts.RetryOnEmpty = viper.GetBool("api.retry.on_empty")
+	baseOpts.WebFormat = viper.GetString("web_reader.chat_format")
+	baseOpts.StripURLs = viper.GetBool("strip_urls")
+	applySamplingFlags(&baseOpts)
 	searchEnabled := viper.GetBool("search")
 	return client, baseOpts, searchEnabled
 }
@@ -213,23 +441,39 @@ func shouldExitREPL(ctx context.Context) bool {
 }
 
 // readUserInput reads user input from the scanner.
-func readUserInput(scanner *bufio.Scanner) string {
+// Returns false at end of input (EOF or read error).
+func readUserInput(scanner *bufio.Scanner) (string, bool) {
 	fmt.Print(theme.Prompt.Render("you> "))
 	if !scanner.Scan() {
-		return ""
+		return "", false
 	}
-	return strings.TrimSpace(scanner.Text())
+	return strings.TrimSpace(scanner.Text()), true
 }
 
-// handleSpecialCommands handles built-in commands like exit, help, clear, etc.
-func handleSpecialCommands(input string, conversationContext *[]app.Message, sessionHistory *[]string) (bool, error) {
+// isExitCommand checks if the input ends the session.
+func isExitCommand(input string) bool {
 	switch strings.ToLower(input) {
 	case "exit", "quit", "/exit", "/quit":
-		fmt.Println()
-		fmt.Println(theme.Dim.Render("Goodbye!"))
-		fmt.Println()
-		return true, nil
+		return true
+	}
+	return false
+}
+
+// maxContextMessages is how many recent messages are sent as context (10 exchanges).
+const maxContextMessages = 20
+
+// recentContext returns the tail of the conversation that is sent to the API.
+// The full conversation is kept for --export-on-exit.
+func recentContext(conversation []app.Message) []app.Message {
+	if len(conversation) > maxContextMessages {
+		return conversation[len(conversation)-maxContextMessages:]
+	}
+	return conversation
+}
 
+// handleSpecialCommands handles built-in commands like exit, help, clear, etc.
+func handleSpecialCommands(input string, conversationContext *[]app.Message, sessionHistory *[]string) (bool, error) {
+	switch strings.ToLower(input) {
 	case "help", "/help", "?":
 		printStyledHelp()
 		return true, nil
@@ -242,16 +486,41 @@ func handleSpecialCommands(input string, conversationContext *[]app.Message, ses
 		*conversationContext = nil
 		*sessionHistory = nil
 		fmt.Print("\033[2J\033[H") // Clear screen
-		printWelcomeBanner("", false)
+		printWelcomeBanner("", false, 0)
 		return true, nil
 
 	case "context", "/context":
-		printContextStyled(*conversationContext)
+		printContextStyled(recentContext(*conversationContext))
 		return true, nil
 	}
 	return false, nil
 }
 
+// isSystemCommand checks if the input is a system command.
+func isSystemCommand(input string) bool {
+	return input == "system" || input == "/system" ||
+		strings.HasPrefix(input, "/system ") || strings.HasPrefix(input, "system ")
+}
+
+// handleSystemCommand replaces the system prompt for the rest of the session.
+// Without text it shows the prompt set by --system or earlier commands.
+func handleSystemCommand(input string, opts *app.ChatOptions) {
+	_, text, _ := strings.Cut(input, " ")
+	text = strings.TrimSpace(text)
+
+	fmt.Println()
+	switch {
+	case text != "":
+		opts.SystemPrompt = text
+		fmt.Println(theme.Info.Render("  System prompt set: ") + theme.Dim.Render(text))
+	case opts.SystemPrompt != "":
+		fmt.Println(theme.Info.Render("  System prompt: ") + theme.Dim.Render(opts.SystemPrompt))
+	default:
+		fmt.Println(theme.Dim.Render("  Using the configured or default system prompt"))
+	}
+	fmt.Println()
+}
+
 // isSearchCommand checks if the input is a search command.
 func isSearchCommand(input string) bool {
 	return strings.HasPrefix(input, "/search ") || strings.HasPrefix(input, "search ")
@@ -262,6 +531,41 @@ func isWebCommand(input string) bool {
 	return strings.HasPrefix(input, "/web ") || strings.HasPrefix(input, "web ")
 }
 
+// isRefetchCommand checks if the input is a refetch command.
+func isRefetchCommand(input string) bool {
+	return strings.HasPrefix(input, "/refetch ") || strings.HasPrefix(input, "refetch ")
+}
+
+// handleRefetchCommand drops a URL from the session cache and fetches it again,
+// so the next message mentioning it sees the fresh page.
+func handleRefetchCommand(ctx context.Context, client *app.Client, input string, cache *app.WebContentCache) error {
+	url := strings.TrimSpace(input[strings.Index(input, " ")+1:])
+	if url == "" {
+		return fmt.Errorf("usage: /refetch <url>")
+	}
+	if cache == nil {
+		return fmt.Errorf("URL caching is off (--dedupe-urls=false); URLs are always fetched fresh")
+	}
+
+	cache.Delete(url)
+
+	var stop atomic.Bool
+	go animateThinking(nil, &stop)
+	resp, err := client.FetchWebContent(ctx, url, &app.WebReaderOptions{ReturnFormat: "markdown"})
+	stop.Store(true)
+	time.Sleep(100 * time.Millisecond) // Let spinner clear
+
+	if err != nil {
+		return err
+	}
+	cache.Set(url, resp.ReaderResult)
+
+	fmt.Println()
+	fmt.Println(theme.Info.Render("  Refreshed: ") + theme.ResultLink.Render(url) + " " + theme.Dim.Render(resp.ReaderResult.Title))
+	fmt.Println()
+	return nil
+}
+
 // handleSearchCommand processes search commands and displays results.
 func handleSearchCommand(ctx context.Context, client *app.Client, input string, conversationContext *[]app.Message, sessionHistory *[]string) error {
 	query := strings.TrimSpace(input[len("/search "):])
@@ -317,16 +621,13 @@ func handleSearchCommand(ctx context.Context, client *app.Client, input string,
 		app.Message{Role: "user", Content: fmt.Sprintf("Search: %s", query)},
 		app.Message{Role: "assistant", Content: searchFormatted},
 	)
-	if len(*conversationContext) > 20 {
-		*conversationContext = (*conversationContext)[2:]
-	}
 
 	*sessionHistory = append(*sessionHistory, input)
 	return nil
 }
 
 // handleWebCommand processes web commands and displays fetched content.
-func handleWebCommand(ctx context.Context, client *app.Client, input string, conversationContext *[]app.Message, sessionHistory *[]string) error {
+func handleWebCommand(ctx context.Context, client *app.Client, input string, cache *app.WebContentCache, conversationContext *[]app.Message, sessionHistory *[]string) error {
 	url := strings.TrimSpace(input[len("/web "):])
 	if strings.HasPrefix(input, "web ") {
 		url = strings.TrimSpace(input[len("web "):])
@@ -355,6 +656,9 @@ func handleWebCommand(ctx context.Context, client *app.Client, input string, con
 	if err != nil {
 		return err
 	}
+	if cache != nil {
+		cache.Set(url, resp.ReaderResult)
+	}
 
 	// Display content
 	fmt.Println()
@@ -377,26 +681,25 @@ func handleWebCommand(ctx context.Context, client *app.Client, input string, con
 		app.Message{Role: "user", Content: userMsg},
 		app.Message{Role: "assistant", Content: formattedContent},
 	)
-	if len(*conversationContext) > 20 {
-		*conversationContext = (*conversationContext)[2:]
-	}
 
 	*sessionHistory = append(*sessionHistory, input)
 	return nil
 }
 
 // handleRegularChat processes regular chat messages.
-func handleRegularChat(ctx context.Context, client *app.Client, baseOpts app.ChatOptions, input string, searchEnabled bool, conversationContext *[]app.Message, sessionHistory *[]string) error {
+// The file and instructions are sent on the first turn only; a context seeded
+// with --context-file doesn't count as a turn.
+func handleRegularChat(ctx context.Context, client *app.Client, baseOpts app.ChatOptions, input string, searchEnabled, firstTurn bool, conversationContext *[]app.Message, sessionHistory *[]string) error {
 	// Add to session history
 	*sessionHistory = append(*sessionHistory, input)
 
 	// Build options with current context
 	opts := baseOpts
-	opts.Context = *conversationContext
+	opts.Context = recentContext(*conversationContext)
 
-	// Only include file on first message or if explicitly requested
-	if len(*conversationContext) > 0 {
+	if !firstTurn {
 		opts.FilePath = ""
+		opts.Instructions = ""
 	}
 
 	// If search is not enabled, proceed with regular chat
@@ -433,7 +736,7 @@ func handleRegularChat(ctx context.Context, client *app.Client, baseOpts app.Cha
 	case result := <-searchChan:
 		searchErr = result.err
 		if result.err == nil && result.results != nil && len(result.results.SearchResult) > 0 {
-			searchContext = app.FormatSearchForContext(result.results.SearchResult)
+			searchContext = app.FormatSearchForContextWithBudget(result.results.SearchResult, viper.GetInt("web_search.context_budget"))
 		}
 	case <-ctx.Done():
 		return fmt.Errorf("search cancelled: %w", ctx.Err())
@@ -449,33 +752,49 @@ func handleRegularChat(ctx context.Context, client *app.Client, baseOpts app.Cha
 	return sendChatMessage(ctx, client, messageToSend, opts, conversationContext)
 }
 
-// sendChatMessage handles the actual chat API call with spinner animation
+// sendChatMessage streams the reply, showing the spinner until the first text arrives.
 func sendChatMessage(ctx context.Context, client *app.Client, messageToSend string, opts app.ChatOptions, conversationContext *[]app.Message) error {
-	// Send to API with spinner
 	var stop atomic.Bool
 	go animateThinking(nil, &stop)
 
-	response, err := client.Chat(ctx, messageToSend, opts)
-	stop.Store(true)
-	time.Sleep(100 * time.Millisecond) // Let spinner clear
+	var usage app.Usage
+	opts.OnUsage = func(u app.Usage) { usage = u }
+
+	started := false
+	startReply := func() {
+		started = true
+		stop.Store(true)
+		time.Sleep(100 * time.Millisecond) // Let spinner clear
+		fmt.Println()
+		fmt.Printf("%s ", theme.AILabel.Render("AI>"))
+	}
+
+	response, err := client.ChatStream(ctx, messageToSend, opts, func(delta string) {
+		if !started {
+			startReply()
+		}
+		fmt.Print(delta)
+	})
+	if started {
+		fmt.Println()
+		if err == nil && viper.GetBool("show_usage") {
+			fmt.Fprintln(os.Stderr, theme.Dim.Render(newUsageReport(usage).String()))
+		}
+		fmt.Println()
+	} else {
+		stop.Store(true)
+		time.Sleep(100 * time.Millisecond) // Let spinner clear
+	}
 
 	if err != nil {
 		return err
 	}
 
-	// Update conversation context (keep last 10 exchanges = 20 messages)
+	// Update conversation context (only the last maxContextMessages are sent)
 	*conversationContext = append(*conversationContext,
 		app.Message{Role: "user", Content: messageToSend},
 		app.Message{Role: "assistant", Content: response},
 	)
-	if len(*conversationContext) > 20 {
-		*conversationContext = (*conversationContext)[2:]
-	}
-
-	// Display response with styling
-	fmt.Println()
-	fmt.Printf("%s %s\n", theme.AILabel.Render("AI>"), response)
-	fmt.Println()
 
 	return nil
 }
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/dotcommander/zai/internal/app"
+)
+
+// copyToClipboard copies URL to clipboard (macOS, Linux, Windows)
+func copyToClipboard(url string) error {
+	var cmd *exec.Cmd
+	var err error
+
+	// Platform-specific commands
+	if _, err = exec.LookPath("pbcopy"); err == nil { //nolint:nestif // platform detection requires chained lookups
+		cmd = exec.Command("pbcopy")
+	} else if _, err = exec.LookPath("xclip"); err == nil { // Linux
+		cmd = exec.Command("xclip", "-selection", "clipboard")
+	} else if _, err = exec.LookPath("xsel"); err == nil { // Linux (alternative)
+		cmd = exec.Command("xsel", "--clipboard", "--input")
+	} else if _, err = exec.LookPath("clip"); err == nil { // Windows
+		cmd = exec.Command("clip")
+	} else {
+		return fmt.Errorf("no suitable clipboard tool found (requires: pbcopy/macOS, xclip/xsel/Linux, or clip/Windows)")
+	}
+
+	cmd.Stdin = strings.NewReader(url)
+	return cmd.Run()
+}
+
+// readClipboard returns the clipboard text (macOS, Linux, Windows)
+func readClipboard() (string, error) {
+	var cmd *exec.Cmd
+	var err error
+
+	// Platform-specific commands
+	if _, err = exec.LookPath("pbpaste"); err == nil { //nolint:nestif // platform detection requires chained lookups
+		cmd = exec.Command("pbpaste")
+	} else if _, err = exec.LookPath("wl-paste"); err == nil { // Linux (Wayland)
+		cmd = exec.Command("wl-paste", "--no-newline")
+	} else if _, err = exec.LookPath("xclip"); err == nil { // Linux
+		cmd = exec.Command("xclip", "-selection", "clipboard", "-o")
+	} else if _, err = exec.LookPath("xsel"); err == nil { // Linux (alternative)
+		cmd = exec.Command("xsel", "--clipboard", "--output")
+	} else if _, err = exec.LookPath("powershell"); err == nil { // Windows
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", "Get-Clipboard")
+	} else {
+		return "", fmt.Errorf("no suitable clipboard tool found (requires: pbpaste/macOS, wl-paste/xclip/xsel/Linux, or powershell/Windows)")
+	}
+
+	data, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to read clipboard: %w", err)
+	}
+	if len(data) > MaxStdinSize {
+		return "", fmt.Errorf("clipboard exceeds maximum size of %d bytes", MaxStdinSize)
+	}
+	if !app.IsProbablyText(data) {
+		return "", fmt.Errorf("clipboard does not contain text")
+	}
+	return strings.TrimSpace(string(data)), nil
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/app"
+)
+
+// modelCacheTTL is how long the cached models list serves shell completion.
+const modelCacheTTL = time.Hour
+
+// modelCompletionTimeout bounds the API call a completion makes on a cache miss.
+const modelCompletionTimeout = 3 * time.Second
+
+// newModelListCache returns the models cache at ~/.cache/zai/models.json.
+func newModelListCache() *app.ModelListCache {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "/tmp"
+	}
+	return app.NewModelListCache(filepath.Join(home, ".cache", "zai", "models.json"))
+}
+
+// completeModelIDs completes model IDs from the cached models list, fetching
+// it once when the cache is missing or stale.
+func completeModelIDs(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	cache := newModelListCache()
+	models, ok := cache.Get(modelCacheTTL)
+	if !ok {
+		// Completion skips PersistentPreRunE, so load the config here
+		if readConfigFile() != nil || applyRegion() != nil || viper.GetString("api.key") == "" {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), modelCompletionTimeout)
+		defer cancel()
+		var err error
+		if models, err = newClientWithoutHistory().ListModels(ctx); err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		_ = cache.Set(models)
+	}
+
+	ids := make([]string, 0, len(models))
+	for _, m := range models {
+		ids = append(ids, m.ID)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completePresetNames completes --preset with the presets in the config file.
+func completePresetNames(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if readConfigFile() != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names := make([]string, 0)
+	for name := range viper.GetStringMap("presets") {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeProfileNames completes --profile from the profiles directory.
+func completeProfileNames(_ *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	names, err := listProfiles()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/config"
+)
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect configuration",
+	Long:  `Inspect the configuration zai resolves from flags, environment, and config file.`,
+}
+
+var configDumpCmd = &cobra.Command{
+	Use:   "dump",
+	Short: "Print the effective merged configuration",
+	Long: `Print every configuration value as zai sees it after merging
+flags, ZAI_* environment variables, the config file, and defaults.
+
+Each value shows where it came from. Precedence is flag > env > file > default.
+The API key is masked.
+
+Examples:
+  zai config dump
+  zai config dump --json
+  zai config dump --config ./other.yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigDump(cmd)
+	},
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Check the config file for unknown keys",
+	Long: `Check every key in the config file against the settings zai knows,
+and list any it would silently ignore (typos like api.modle).
+
+Pass --strict-config to any command (or set strict_config: true) to run
+this check on every invocation.
+
+Examples:
+  zai config validate
+  zai config validate --config ./other.yaml`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if err := readConfigFile(); err != nil {
+			return fmt.Errorf("failed to read config: %w", err)
+		}
+		if err := validateConfigKeys(); err != nil {
+			return err
+		}
+		if viper.ConfigFileUsed() == "" {
+			fmt.Println("No config file found.")
+			return nil
+		}
+		fmt.Printf("%s: all keys recognized\n", viper.ConfigFileUsed())
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configDumpCmd)
+	configCmd.AddCommand(configValidateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+// ConfigValue is a resolved setting and the layer that supplied it.
+type ConfigValue struct {
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"` // "flag", "env", "file", "region", or "default"
+}
+
+func runConfigDump(cmd *cobra.Command) error {
+	if err := readConfigFile(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := applyRegion(); err != nil {
+		return err
+	}
+
+	keys := viper.AllKeys()
+	// Env-only keys have no default, so AllKeys misses them
+	if viper.IsSet("api.key") && !viper.InConfig("api.key") {
+		keys = append(keys, "api.key")
+	}
+	sort.Strings(keys)
+
+	values := make(map[string]ConfigValue, len(keys))
+	for _, key := range keys {
+		value := viper.Get(key)
+		if key == "api.key" {
+			value = maskSecret(viper.GetString(key))
+		}
+		values[key] = ConfigValue{Value: value, Source: configSource(cmd, key)}
+	}
+
+	if viper.GetBool("json") {
+		output := map[string]interface{}{
+			"config_file": viper.ConfigFileUsed(),
+			"values":      values,
+		}
+		data, err := marshalJSON(output)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	configFile := viper.ConfigFileUsed()
+	if configFile == "" {
+		configFile = "(none found)"
+	}
+	fmt.Printf("Config file: %s\n\n", configFile)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tVALUE\tSOURCE") //nolint:errcheck // terminal output
+	fmt.Fprintln(w, "───\t─────\t──────") //nolint:errcheck // terminal output
+	for _, key := range keys {
+		v := values[key]
+		fmt.Fprintf(w, "%s\t%v\t%s\n", key, v.Value, v.Source) //nolint:errcheck // terminal output
+	}
+	w.Flush() //nolint:errcheck // tabwriter flush
+
+	return nil
+}
+
+// configSource reports which layer supplies key, following viper's precedence.
+func configSource(cmd *cobra.Command, key string) string {
+	if name, ok := persistentFlagBindings[key]; ok {
+		if flag := cmd.Flags().Lookup(name); flag != nil && flag.Changed {
+			return "flag"
+		}
+	}
+	if _, ok := os.LookupEnv(configEnvVar(key)); ok {
+		return "env"
+	}
+	if viper.InConfig(key) {
+		return "file"
+	}
+	if (key == "api.base_url" || key == "api.coding_base_url") && viper.GetString("api.region") != "" {
+		return "region"
+	}
+	return "default"
+}
+
+// configEnvVar returns the environment variable viper checks for key.
+func configEnvVar(key string) string {
+	return "ZAI_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// maskSecret hides all but the last four characters of a secret.
+func maskSecret(secret string) string {
+	if len(secret) <= 4 {
+		return strings.Repeat("*", len(secret))
+	}
+	return strings.Repeat("*", len(secret)-4) + secret[len(secret)-4:]
+}
+
+// knownConfigKeys returns the config schema keys plus the keys root flags bind,
+// which can also be set in the config file.
+func knownConfigKeys() []string {
+	keys := config.KnownKeys()
+	for key := range persistentFlagBindings {
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// checkStrictConfig validates config keys when --strict-config (or strict_config) is set.
+func checkStrictConfig() error {
+	if !viper.GetBool("strict_config") {
+		return nil
+	}
+	return validateConfigKeys()
+}
+
+// underMapKey reports whether key is an entry of a map-valued known key,
+// such as presets.draft.model under presets.
+func underMapKey(key string, known []string) bool {
+	for _, k := range known {
+		if strings.HasPrefix(key, k+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// validateConfigKeys reports keys in the config file that zai doesn't use.
+// Only the file is checked; defaults and flags can't contain typos.
+func validateConfigKeys() error {
+	path := viper.ConfigFileUsed()
+	if path == "" {
+		return nil
+	}
+	fileConfig := viper.New()
+	fileConfig.SetConfigFile(path)
+	if err := fileConfig.ReadInConfig(); err != nil {
+		return fmt.Errorf("failed to read config: %w", err)
+	}
+
+	known := knownConfigKeys()
+	var unknown []string
+	for _, key := range fileConfig.AllKeys() {
+		if slices.Contains(known, key) || underMapKey(key, known) {
+			continue
+		}
+		if hint := config.ClosestKey(key, known); hint != "" {
+			key += " (did you mean " + hint + "?)"
+		}
+		unknown = append(unknown, key)
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown config keys in %s:\n  %s", path, strings.Join(unknown, "\n  "))
+}
@@ -1,13 +1,25 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"text/tabwriter"
 	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/dotcommander/zai/internal/app"
 )
@@ -15,32 +27,263 @@ import (
 var (
 	historyLimit int
 	historyJSON  bool
+	historySince string
+	historyUntil string
+
+	historyGrepPrompt   string
+	historyGrepResponse string
+	historyType         string
+
+	historyTailLines  int
+	historyTailFollow bool
+	historyTailJSON   bool
+
+	historyShowLast int
+	historyShowJSON bool
+
+	historySearchLimit int
+	historySearchRegex bool
+	historySearchJSON  bool
+
+	historyExportFormat string
+	historyExportOutput string
+	historyExportSince  string
+	historyExportLimit  int
 )
 
+// historyExportFormats are the formats accepted by history export --format.
+var historyExportFormats = []string{"markdown", "json"}
+
 var historyCmd = &cobra.Command{
 	Use:   "history",
 	Short: "Show chat history",
-	Long:  `Display your chat history with timestamps and model information.`,
+	Long: `Display your chat history with timestamps and model information.
+
+--since and --until accept YYYY-MM-DD, RFC3339, or an age such as 7d, 2w,
+or 24h. --until is exclusive, and --limit applies after the time filter.
+--grep-prompt and --grep-response keep entries whose prompt or response
+contains the text (case-insensitive); they also apply before --limit.
+--type keeps one kind of entry: chat, audio, image, video, vision, web,
+or web_search (search also works).
+
+Examples:
+  zai history --since 7d                             # Last week
+  zai history --since 2024-01-01 --until 2024-02-01  # January
+  zai history --since 24h -l 0 --json
+  zai history --grep-prompt golang                   # Recent Go questions
+  zai history --type image -l 0                      # Every generated image`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return showHistory()
 	},
 }
 
+var historyTailCmd = &cobra.Command{
+	Use:   "tail",
+	Short: "Follow new history entries as they are appended",
+	Long: `Print the most recent history entries, then keep watching the history
+file and print new entries as they are appended (like tail -f).
+
+Useful for monitoring usage from another terminal or when zai is invoked
+by other processes. Truncated or rotated history files are picked up
+from the beginning.
+
+Examples:
+  zai history tail                  # Last 10 entries, then follow
+  zai history tail -n 0             # Only new entries
+  zai history tail --json           # Stream raw JSONL entries
+  zai history tail --follow=false   # Print and exit`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistoryTail()
+	},
+}
+
+var historyShowCmd = &cobra.Command{
+	Use:   "show [index]",
+	Short: "Show a full history entry",
+	Long: `Print the complete, untruncated prompt and response of one history entry,
+along with its timestamp, model, and token usage.
+
+The index is 1-based and matches the # column of 'zai history'
+(oldest first, most recent last). Without an index, shows the most recent entry.
+
+Examples:
+  zai history show 42        # Entry #42
+  zai history show --last 2  # Second most recent
+  zai history show --json    # Raw JSON of the latest entry`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistoryShow(args)
+	},
+}
+
+var historySearchCmd = &cobra.Command{
+	Use:   "search <term>",
+	Short: "Find history entries whose prompt or response matches a term",
+	Long: `Search every stored prompt and response for a term (case-insensitive)
+and list the matching entries, most recent last, with the match highlighted.
+
+With --regex the term is a Go regular expression; add (?i) to ignore case.
+
+Examples:
+  zai history search kubernetes
+  zai history search "race condition" -l 0
+  zai history search --regex 'go(lang)? 1\.2[0-9]'`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistorySearch(args[0])
+	},
+}
+
+var historyExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write history as a JSON array or a Markdown document",
+	Long: `Export history entries, oldest first, to stdout or a file.
+
+--format json writes a JSON array of entries. --format markdown (or md)
+writes a document with a heading per exchange and the prompt and response
+in code blocks, safe for content that itself contains backticks or pipes.
+With --output, the format is inferred from a .json or .md extension.
+
+--since accepts YYYY-MM-DD, RFC3339, or an age such as 7d or 24h.
+--limit keeps the most recent entries after the --since filter.
+
+Examples:
+  zai history export > history.md
+  zai history export --format json --since 30d -o last-month.json
+  zai history export --limit 20 -o recent.md`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, err := inferOutputFormat(cmd, "format", historyExportFormat, historyExportOutput, historyExportFormats)
+		if err != nil {
+			return err
+		}
+		return runHistoryExport(format)
+	},
+}
+
+var historyImportCmd = &cobra.Command{
+	Use:   "import <file.jsonl>",
+	Short: "Merge history entries from another JSONL file",
+	Long: `Append entries from another zai history file (e.g. from another machine).
+
+Entries already present (same timestamp and prompt) are skipped, and new
+entries are appended in chronological order. Every line must be a valid
+history entry; nothing is imported if any line fails to parse.
+
+Examples:
+  zai history import ~/Downloads/laptop-history.jsonl`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runHistoryImport(args[0])
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(historyCmd)
 	historyCmd.Flags().IntVarP(&historyLimit, "limit", "l", 10, "number of entries (0 for all)")
 	historyCmd.Flags().BoolVar(&historyJSON, "json", false, "Output in JSON format")
+	historyCmd.Flags().StringVar(&historySince, "since", "", "only entries at or after this time (YYYY-MM-DD, RFC3339, 7d, 24h)")
+	historyCmd.Flags().StringVar(&historyUntil, "until", "", "only entries before this time (YYYY-MM-DD, RFC3339, 7d, 24h)")
+	historyCmd.Flags().StringVar(&historyGrepPrompt, "grep-prompt", "", "only entries whose prompt contains this text (case-insensitive)")
+	historyCmd.Flags().StringVar(&historyGrepResponse, "grep-response", "", "only entries whose response contains this text (case-insensitive)")
+	historyCmd.Flags().StringVar(&historyType, "type", "", "only entries of this type ("+strings.Join(app.HistoryTypes, ", ")+")")
+
+	historyCmd.AddCommand(historyTailCmd)
+	historyTailCmd.Flags().IntVarP(&historyTailLines, "lines", "n", 10, "number of existing entries to show first")
+	historyTailCmd.Flags().BoolVar(&historyTailFollow, "follow", true, "keep watching for new entries (-f is taken by --file)")
+	historyTailCmd.Flags().BoolVar(&historyTailJSON, "json", false, "Output raw JSON entries (one per line)")
+
+	historyCmd.AddCommand(historyImportCmd)
+
+	historyCmd.AddCommand(historyExportCmd)
+	historyExportCmd.Flags().StringVar(&historyExportFormat, "format", "markdown", "output format: markdown (md) or json")
+	historyExportCmd.Flags().StringVarP(&historyExportOutput, "output", "o", "", "write to this file instead of stdout")
+	historyExportCmd.Flags().StringVar(&historyExportSince, "since", "", "only entries at or after this time (YYYY-MM-DD, RFC3339, 7d, 24h)")
+	historyExportCmd.Flags().IntVarP(&historyExportLimit, "limit", "l", 0, "export only the N most recent entries (0 for all)")
+	_ = historyExportCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(historyExportFormats, cobra.ShellCompDirectiveNoFileComp))
+
+	historyCmd.AddCommand(historySearchCmd)
+	historySearchCmd.Flags().IntVarP(&historySearchLimit, "limit", "l", 20, "number of matches (0 for all)")
+	historySearchCmd.Flags().BoolVar(&historySearchRegex, "regex", false, "treat the term as a regular expression")
+	historySearchCmd.Flags().BoolVar(&historySearchJSON, "json", false, "Output matching entries as JSON")
+
+	historyCmd.AddCommand(historyShowCmd)
+	historyShowCmd.Flags().IntVar(&historyShowLast, "last", 0, "show the Nth most recent entry (1 = latest)")
+	historyShowCmd.Flags().BoolVar(&historyShowJSON, "json", false, "Output the raw history entry as JSON")
 }
 
+// newHistoryStore returns the history store selected by config
+// (daily shards when history.sharded is set, otherwise a single file),
+// capping stored entries at history.max_entry_chars. Encrypted lines are read with the passphrase from historyPassphrase;
+// history.encrypt also seals new entries.
+func newHistoryStore() *app.FileHistoryStore {
+	var store *app.FileHistoryStore
+	if viper.GetBool("history.sharded") {
+		store = app.NewShardedHistoryStore(viper.GetString("history.dir"))
+	} else {
+		store = app.NewFileHistoryStore("")
+	}
+	store.SetEncryption(viper.GetBool("history.encrypt"), historyPassphrase)
+	store.SetMaxEntryChars(viper.GetInt("history.max_entry_chars"))
+	return store
+}
+
+// historyPassphrase returns ZAI_HISTORY_KEY, or prompts for the passphrase
+// when stdin is a terminal. Prompting happens at most once per run.
+var historyPassphrase = sync.OnceValues(func() (string, error) {
+	if key := os.Getenv(app.HistoryKeyEnv); key != "" {
+		return key, nil
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return "", nil
+	}
+	fmt.Fprint(os.Stderr, "History passphrase: ")
+	key, err := term.ReadPassword(os.Stdin.Fd())
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read history passphrase: %w", err)
+	}
+	return string(key), nil
+})
+
 func showHistory() error {
-	store := app.NewFileHistoryStore("")
-	entries, err := store.GetRecent(historyLimit)
+	store := newHistoryStore()
+	all, err := store.GetRecent(0)
 	if err != nil {
 		return fmt.Errorf("failed to get history: %w", err)
 	}
+	since, until, err := historyTimeRange()
+	if err != nil {
+		return err
+	}
+	entryType, err := historyTypeFilter(historyType)
+	if err != nil {
+		return err
+	}
+
+	// Keep each entry's position in the full history so # matches 'history show'
+	var entries []app.HistoryEntry
+	var numbers []int
+	for i, entry := range all {
+		if entry.InTimeRange(since, until) && historyMatchesGrep(entry) && (entryType == "" || historyTypeDisplay(entry) == entryType) {
+			entries = append(entries, entry)
+			numbers = append(numbers, i+1)
+		}
+	}
+	if historyLimit > 0 && len(entries) > historyLimit {
+		entries = entries[len(entries)-historyLimit:]
+		numbers = numbers[len(numbers)-historyLimit:]
+	}
 
 	if len(entries) == 0 {
-		fmt.Println("No chat history found.")
+		if historyGrepPrompt != "" || historyGrepResponse != "" || entryType != "" {
+			fmt.Println("No history entries match.")
+		} else if historySince != "" || historyUntil != "" {
+			fmt.Println("No history entries in that time range.")
+		} else {
+			fmt.Println("No chat history found.")
+		}
 		return nil
 	}
 
@@ -50,10 +293,12 @@ func showHistory() error {
 			"history":   entries,
 			"count":     len(entries),
 			"limit":     historyLimit,
+			"since":     historySince,
+			"until":     historyUntil,
 			"timestamp": time.Now().Format(time.RFC3339),
 		}
 
-		data, err := json.MarshalIndent(output, "", "  ")
+		data, err := marshalJSON(output)
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
@@ -61,38 +306,17 @@ func showHistory() error {
 	} else {
 		// Display human-readable table format
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
-		fmt.Fprintln(w, "TIME\tTYPE\tMODEL\tPROMPT\tRESPONSE") //nolint:errcheck // terminal output
-		fmt.Fprintln(w, "────\t────\t─────\t──────\t────────") //nolint:errcheck // terminal output
-
-		for _, entry := range entries {
-			// Determine type display
-			typeDisplay := entry.Type
-			if typeDisplay == "" {
-				typeDisplay = "chat" // Default for backward compatibility
-			}
-
-			// Special handling for image entries
-			var responseDisplay string
-			switch entry.Type {
-			case "image":
-				responseDisplay = fmt.Sprintf("🖼️ %s", entry.ImageSize)
-			case "web":
-				responseDisplay = "🌐 web content"
-			default:
-				// Handle Response as interface{}
-				if respStr, ok := entry.Response.(string); ok {
-					responseDisplay = truncate(respStr, 30)
-				} else {
-					responseDisplay = "📝 complex response"
-				}
-			}
+		fmt.Fprintln(w, "#\tTIME\tTYPE\tMODEL\tPROMPT\tRESPONSE") //nolint:errcheck // terminal output
+		fmt.Fprintln(w, "─\t────\t────\t─────\t──────\t────────") //nolint:errcheck // terminal output
 
-			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck // terminal output
+		for i, entry := range entries {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck // terminal output
+				numbers[i],
 				entry.Timestamp.Format("01-02 15:04"),
-				typeDisplay,
+				historyTypeDisplay(entry),
 				entry.Model,
 				truncate(entry.Prompt, 30),
-				responseDisplay,
+				historyResponseDisplay(entry),
 			)
 		}
 		w.Flush() //nolint:errcheck // tabwriter flush
@@ -100,7 +324,372 @@ func showHistory() error {
 		if historyLimit > 0 && len(entries) >= historyLimit {
 			fmt.Printf("\nShowing %d most recent. Use -l 0 for all.\n", historyLimit)
 		}
+		fmt.Println("Use 'zai history show <#>' for a full entry.")
+	}
+
+	return nil
+}
+
+// historyTimeRange parses --since/--until; unset bounds are zero (open).
+func historyTimeRange() (since, until time.Time, err error) {
+	now := time.Now()
+	if historySince != "" {
+		if since, err = app.ParseHistoryTime(historySince, now); err != nil {
+			return since, until, fmt.Errorf("--since: %w", err)
+		}
+	}
+	if historyUntil != "" {
+		if until, err = app.ParseHistoryTime(historyUntil, now); err != nil {
+			return since, until, fmt.Errorf("--until: %w", err)
+		}
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return since, until, fmt.Errorf("--since must be before --until")
+	}
+	return since, until, nil
+}
+
+// historyTypeDisplay returns the entry type, defaulting to "chat" for old entries.
+func historyTypeDisplay(entry app.HistoryEntry) string {
+	if entry.Type == "" {
+		return "chat" // Default for backward compatibility
+	}
+	return entry.Type
+}
+
+// historyResponseDisplay returns a short response summary for table output.
+func historyResponseDisplay(entry app.HistoryEntry) string {
+	switch entry.Type {
+	case "image":
+		return fmt.Sprintf("🖼️ %s", entry.ImageSize)
+	case "video":
+		return "🎬 video"
+	case "web":
+		return "🌐 web content"
+	default:
+		// Handle Response as interface{}
+		if respStr, ok := entry.Response.(string); ok {
+			return truncate(respStr, 30)
+		}
+		return "📝 complex response"
+	}
+}
+
+// historyTailPollInterval is how often the history file is checked for growth.
+const historyTailPollInterval = 500 * time.Millisecond
+
+// runHistoryTail prints recent entries and, with --follow, streams new ones until interrupted.
+func runHistoryTail() error {
+	store := newHistoryStore()
+
+	if historyTailLines > 0 {
+		entries, err := store.GetRecent(historyTailLines)
+		if err != nil {
+			return fmt.Errorf("failed to get history: %w", err)
+		}
+		for _, entry := range entries {
+			if err := printHistoryTailEntry(entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !historyTailFollow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	follower := store.Follow(true)
+	ticker := time.NewTicker(historyTailPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			entries, err := follower.Poll()
+			if err != nil {
+				return err
+			}
+			for _, entry := range entries {
+				if err := printHistoryTailEntry(entry); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// printHistoryTailEntry prints a single entry as a styled line or raw JSON.
+func printHistoryTailEntry(entry app.HistoryEntry) error {
+	if historyTailJSON {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("%s  %s  %s  %s  %s\n",
+		theme.Dim.Render(entry.Timestamp.Format("01-02 15:04:05")),
+		theme.Info.Render(fmt.Sprintf("%-10s", historyTypeDisplay(entry))),
+		theme.Dim.Render(entry.Model),
+		truncate(entry.Prompt, 40),
+		theme.Dim.Render(historyResponseDisplay(entry)),
+	)
+	return nil
+}
+
+// runHistoryShow prints one complete entry selected by index or --last.
+func runHistoryShow(args []string) error {
+	entries, err := newHistoryStore().GetRecent(0)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no history entries")
+	}
+
+	index := len(entries) // Default: most recent
+	switch {
+	case len(args) == 1 && historyShowLast > 0:
+		return fmt.Errorf("use either an index or --last, not both")
+	case len(args) == 1:
+		index, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("invalid index %q: must be a number", args[0])
+		}
+	case historyShowLast > 0:
+		index = len(entries) - historyShowLast + 1
+	}
+	if index < 1 || index > len(entries) {
+		return fmt.Errorf("index out of range: history has %d entries", len(entries))
+	}
+	entry := entries[index-1]
+
+	if historyShowJSON {
+		data, err := marshalJSON(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Printf("#%d  %s  %s  %s\n", index,
+		entry.Timestamp.Format("2006-01-02 15:04:05"), historyTypeDisplay(entry), entry.Model)
+	if entry.TokenUsage.TotalTokens > 0 {
+		fmt.Printf("Tokens: %d prompt + %d completion = %d\n",
+			entry.TokenUsage.PromptTokens, entry.TokenUsage.CompletionTokens, entry.TokenUsage.TotalTokens)
+	}
+	if (entry.ImageURL != "" || entry.VideoURL != "") && entry.ExpiresAt != nil {
+		label, status := "Image URL", "expires"
+		if entry.VideoURL != "" {
+			label = "Video URL"
+		}
+		if entry.MediaExpired(time.Now()) {
+			status = "expired (regenerate to get a fresh URL)"
+		}
+		fmt.Printf("%s %s %s\n", label, status, entry.ExpiresAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("\nPrompt:\n%s\n\nResponse:\n%s\n", entry.Prompt, entry.ResponseText())
+	return nil
+}
+
+// historyTypeFilter validates --type, accepting "search" for web_search.
+func historyTypeFilter(value string) (string, error) {
+	if value == "search" {
+		value = "web_search"
+	}
+	if value != "" && !slices.Contains(app.HistoryTypes, value) {
+		return "", fmt.Errorf("invalid --type %q (must be one of: %s)", value, strings.Join(app.HistoryTypes, ", "))
+	}
+	return value, nil
+}
+
+// historyMatchesGrep applies --grep-prompt and --grep-response (case-insensitive substrings).
+func historyMatchesGrep(entry app.HistoryEntry) bool {
+	if historyGrepPrompt != "" && !containsFold(entry.Prompt, historyGrepPrompt) {
+		return false
+	}
+	return historyGrepResponse == "" || containsFold(entry.ResponseText(), historyGrepResponse)
+}
+
+// containsFold reports whether s contains substr, ignoring case.
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+// runHistorySearch lists entries matching term, highlighting the match.
+func runHistorySearch(term string) error {
+	pattern := "(?i)" + regexp.QuoteMeta(term)
+	if historySearchRegex {
+		pattern = term
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid --regex pattern: %w", err)
+	}
+
+	entries, err := newHistoryStore().SearchRegexp(re, historySearchLimit)
+	if err != nil {
+		return fmt.Errorf("failed to search history: %w", err)
+	}
+
+	if historySearchJSON {
+		data, err := marshalJSON(map[string]interface{}{"term": term, "history": entries, "count": len(entries)})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	if len(entries) == 0 {
+		fmt.Println("No history entries match.")
+		return nil
+	}
+
+	// Only the prompt and response cells are highlighted. Widths are measured
+	// on the plain text, since ANSI escapes would throw off a tabwriter.
+	rows := [][]string{
+		{"TIME", "TYPE", "MODEL", "PROMPT", "RESPONSE"},
+		{"────", "────", "─────", "──────", "────────"},
+	}
+	for _, entry := range entries {
+		rows = append(rows, []string{
+			entry.Timestamp.Format("01-02 15:04"),
+			historyTypeDisplay(entry),
+			entry.Model,
+			matchSnippet(entry.Prompt, re, 30),
+			matchSnippet(entry.ResponseText(), re, 40),
+		})
+	}
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	highlight := func(m string) string { return theme.Info.Render(m) }
+	for r, row := range rows {
+		var line strings.Builder
+		for i, cell := range row {
+			padding := widths[i] - utf8.RuneCountInString(cell)
+			if r >= 2 && i >= 3 {
+				cell = re.ReplaceAllStringFunc(cell, highlight)
+			}
+			line.WriteString(cell)
+			if i < len(row)-1 {
+				line.WriteString(strings.Repeat(" ", padding+2))
+			}
+		}
+		fmt.Println(line.String())
+	}
+
+	if historySearchLimit > 0 && len(entries) >= historySearchLimit {
+		fmt.Printf("\nShowing %d most recent matches. Use -l 0 for all.\n", historySearchLimit)
+	}
+	return nil
+}
+
+// matchSnippet returns up to width characters of s on one line, centered on
+// the first match of re so it is visible in the table.
+func matchSnippet(s string, re *regexp.Regexp, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	loc := re.FindStringIndex(s)
+	if loc == nil {
+		return truncate(s, width)
+	}
+
+	// Start a third of the way before the match, but don't run past the end
+	start := max(utf8.RuneCountInString(s[:loc[0]])-width/3, 0)
+	end := min(start+width, len(runes))
+	start = max(end-width, 0)
+
+	snippet := string(runes[start:end])
+	if start > 0 {
+		snippet = "..." + string(runes[start+3:end])
+	}
+	if end < len(runes) {
+		snippet = string([]rune(snippet)[:width-3]) + "..."
+	}
+	return snippet
+}
+
+// runHistoryExport writes the selected entries as JSON or Markdown.
+func runHistoryExport(format string) error {
+	all, err := newHistoryStore().GetRecent(0)
+	if err != nil {
+		return fmt.Errorf("failed to get history: %w", err)
+	}
+	var since time.Time
+	if historyExportSince != "" {
+		if since, err = app.ParseHistoryTime(historyExportSince, time.Now()); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	}
+
+	entries := []app.HistoryEntry{}
+	for _, entry := range all {
+		if entry.InTimeRange(since, time.Time{}) {
+			entries = append(entries, entry)
+		}
+	}
+	if historyExportLimit > 0 && len(entries) > historyExportLimit {
+		entries = entries[len(entries)-historyExportLimit:]
+	}
+
+	var data []byte
+	switch format {
+	case "json":
+		if data, err = marshalJSON(entries); err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		data = append(data, '\n')
+	case "markdown", "md":
+		data = []byte(app.FormatHistoryMarkdown(entries, time.Now()))
+	default:
+		return fmt.Errorf("invalid --format %q (must be markdown or json)", format)
+	}
+
+	if historyExportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := app.WriteFileAtomic(historyExportOutput, data, outputFileMode(0600)); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Exported %d entries to %s\n", len(entries), historyExportOutput)
+	return nil
+}
+
+// runHistoryImport merges a JSONL history file into the local store.
+func runHistoryImport(path string) error {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer closeFile(file)
+
+	entries, err := app.ParseHistoryJSONL(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	imported, skipped, err := newHistoryStore().Import(entries)
+	if err != nil {
+		return fmt.Errorf("import failed after %d entries: %w", imported, err)
 	}
 
+	fmt.Printf("Imported %d entries, skipped %d duplicates.\n", imported, skipped)
 	return nil
 }
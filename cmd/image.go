@@ -1,16 +1,25 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"fmt"
-	"os/exec"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 
 	"github.com/dotcommander/zai/internal/app"
+	"github.com/dotcommander/zai/internal/app/utils"
 )
 
 var (
@@ -23,8 +32,33 @@ var (
 	imageUserID    string
 	imageEnhance   bool
 	imageNoEnhance bool
+	imageReference string
+
+	imageEnhanceTemperature float64
+	imageEnhanceMaxTokens   int
+	imagePromptOnly         bool
+	imageOutputDir          string
+	imageOrganizeByDate     bool
+	imageVariations         int
+	imageGrid               bool
+	imageOutputStdout       bool
+
+	upscaleInput  string
+	upscaleScale  int
+	upscaleOutput string
+	upscaleLocal  bool
 )
 
+// imageStatus receives progress and notices for image generation.
+// --output-stdout discards them so stdout carries only the image bytes.
+var imageStatus io.Writer = os.Stdout
+
+// maxImageVariations caps --variations-count; each variation is a separate API call.
+const maxImageVariations = 10
+
+// imageDownloadWorkers bounds concurrent variation downloads so the image host isn't hammered.
+const imageDownloadWorkers = 3
+
 var imageCmd = &cobra.Command{
 	Use:   "image \"description\"",
 	Short: "Generate images using Z.AI's image generation API",
@@ -35,7 +69,12 @@ Examples:
   zai image "sunset on mars" --quality hd --size 1024x1024
   zai image "abstract art" --output my-art.png
   zai image "logo" --copy --size 512x512
-  zai image "sunset" --no-enhance    # Skip prompt enhancement`,
+  zai image "sunset" --no-enhance    # Skip prompt enhancement
+  zai image -f style.png "a castle"  # Use style.png as a style reference
+  zai image "a castle" --prompt-only # Print the enhanced prompt, don't generate
+  zai image "robot mascot" -n 4 --grid -o mascot.png  # mascot-1..4.png + mascot-grid.png
+  zai image "app icon" --count 3                     # zai-image-<timestamp>-...-1..3.png
+  zai image "cat" --output-stdout > cat.png          # Raw image bytes for pipelines`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runImageGeneration(args[0])
@@ -50,24 +89,63 @@ var imageListCmd = &cobra.Command{
 	},
 }
 
+var imageUpscaleCmd = &cobra.Command{
+	Use:   "upscale",
+	Short: "Enlarge an image with AI upscaling or a local resize",
+	Long: `Enlarge an image by an integer factor.
+
+The image is sent to the API's upscaling endpoint when one is available;
+otherwise it is resized locally with a Lanczos filter. The output says which
+was used.
+
+Examples:
+  zai image upscale -f art.png               # art-upscaled.png, 2x
+  zai image upscale -f art.png --scale 4 -o big.png
+  zai image upscale -f art.png --local       # Skip the API`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runImageUpscale(upscaleInput, upscaleScale, upscaleOutput, upscaleLocal)
+	},
+}
+
 func init() {
 	// Main image command
 	imageCmd.Flags().StringVarP(&imageQuality, "quality", "q", "hd", "Image quality: hd or standard (default: hd)")
 	imageCmd.Flags().StringVarP(&imageSize, "size", "s", "1024x1024", "Image size: 1024x1024, 1024x768, 768x1024, or 512x512 (default: 1024x1024)")
 	imageCmd.Flags().StringVarP(&imageOutput, "output", "o", "", "Save image to file path")
+	imageCmd.Flags().StringVar(&imageOutputDir, "output-dir", "", "Directory for auto-named images (default: media.output_dir or current dir)")
+	imageCmd.Flags().BoolVar(&imageOrganizeByDate, "organize-by-date", false, "Nest auto-named images under YYYY/MM/DD in the output dir")
 	imageCmd.Flags().BoolVarP(&imageShow, "show", "S", false, "Open image with default viewer after generation")
 	imageCmd.Flags().BoolVarP(&imageCopy, "copy", "c", false, "Copy image to clipboard (macOS, Linux, Windows)")
 	imageCmd.Flags().StringVarP(&imageModel, "model", "m", "", "Override default image model")
+	_ = imageCmd.RegisterFlagCompletionFunc("model", completeModelIDs)
 	imageCmd.Flags().StringVar(&imageUserID, "user-id", "", "User ID for analytics")
 	imageCmd.Flags().BoolVarP(&imageEnhance, "enhance", "e", true, "Enhance prompt with AI before generation")
 	imageCmd.Flags().BoolVar(&imageNoEnhance, "no-enhance", false, "Disable prompt enhancement")
+	imageCmd.Flags().StringVarP(&imageReference, "file", "f", "", "Reference image (path or URL) that guides the style")
+	imageCmd.Flags().StringVar(&imageReference, "reference", "", "Alias for --file")
+	imageCmd.Flags().Float64Var(&imageEnhanceTemperature, "enhance-temperature", 0.7, "Temperature for prompt enhancement (lower is more literal)")
+	imageCmd.Flags().IntVar(&imageEnhanceMaxTokens, "enhance-max-tokens", 200, "Max tokens for the enhanced prompt")
+	imageCmd.Flags().BoolVar(&imagePromptOnly, "prompt-only", false, "Print the final prompt and exit without generating")
+	imageCmd.Flags().IntVarP(&imageVariations, "variations-count", "n", 1, "Generate N variations of the prompt (1-10), saved as <name>-1.png, <name>-2.png, ...")
+	imageCmd.Flags().IntVar(&imageVariations, "count", 1, "Alias for --variations-count")
+	imageCmd.Flags().BoolVar(&imageGrid, "grid", false, "Also save a contact sheet of all variations as <name>-grid.png")
+	imageCmd.Flags().BoolVar(&imageOutputStdout, "output-stdout", false, "Write the image bytes to stdout instead of a file, with no other output (stdout must not be a terminal)")
 
 	// Mark mutually exclusive flags
 	imageCmd.MarkFlagsMutuallyExclusive("enhance", "no-enhance")
+	imageCmd.MarkFlagsMutuallyExclusive("variations-count", "count")
 
 	// Add subcommands
 	imageCmd.AddCommand(imageListCmd)
 
+	imageUpscaleCmd.Flags().StringVarP(&upscaleInput, "file", "f", "", "Image to upscale (PNG, JPEG, or GIF)")
+	imageUpscaleCmd.Flags().IntVar(&upscaleScale, "scale", 2, "Upscale factor (2 or more)")
+	imageUpscaleCmd.Flags().StringVarP(&upscaleOutput, "output", "o", "", "Output path (default: <input>-upscaled.png next to the input)")
+	imageUpscaleCmd.Flags().BoolVar(&upscaleLocal, "local", false, "Resize locally without trying the API")
+	_ = imageUpscaleCmd.MarkFlagRequired("file")
+	imageCmd.AddCommand(imageUpscaleCmd)
+
 	// Register with root
 	rootCmd.AddCommand(imageCmd)
 }
@@ -82,7 +160,8 @@ func shouldEnhancePrompt() bool {
 }
 
 // enhanceImagePromptWithCtx is the context-aware version of enhanceImagePrompt.
-func enhanceImagePromptWithCtx(ctx context.Context, client *app.Client, prompt string) (string, error) {
+// temperature and maxTokens control how creative and how long the rewrite is.
+func enhanceImagePromptWithCtx(ctx context.Context, client *app.Client, prompt string, temperature float64, maxTokens int) (string, error) {
 	systemPrompt := `You are an expert at creating detailed, evocative prompts for AI image generation.
 
 ## YOUR TASK
@@ -105,8 +184,8 @@ Output: "A fluffy ginger cat lounging on a weathered wooden bench in a sun-drenc
 - Output ONLY the enhanced prompt - no explanations, no quotes, no prefixes`
 
 	opts := app.ChatOptions{
-		Temperature: app.Float64Ptr(0.8),
-		MaxTokens:   app.IntPtr(250),
+		Temperature: app.Float64Ptr(temperature),
+		MaxTokens:   app.IntPtr(maxTokens),
 		Context: []app.Message{
 			{Role: "system", Content: systemPrompt},
 		},
@@ -127,23 +206,51 @@ Output: "A fluffy ginger cat lounging on a weathered wooden bench in a sun-drenc
 	return result, nil
 }
 
-func enhanceImagePrompt(client *app.Client, prompt string) (string, error) {
+func enhanceImagePrompt(client *app.Client, prompt string, temperature float64, maxTokens int) (string, error) {
 	ctx, cancel := createContext(2 * time.Minute)
 	defer cancel()
-	return enhanceImagePromptWithCtx(ctx, client, prompt)
+	return enhanceImagePromptWithCtx(ctx, client, prompt, temperature, maxTokens)
 }
 
 func runImageGeneration(prompt string) error {
+	if imageVariations < 1 || imageVariations > maxImageVariations {
+		return fmt.Errorf("--count/--variations-count must be between 1 and %d", maxImageVariations)
+	}
+	if imageGrid && imageVariations < 2 {
+		return fmt.Errorf("--grid requires --count/--variations-count of 2 or more")
+	}
+	if imageOutputStdout {
+		if err := validateImageOutputStdout(); err != nil {
+			return err
+		}
+		imageStatus = io.Discard
+	}
+
 	client := newClient()
-	ctx, cancel := createContext(5 * time.Minute)
+	if imagePromptOnly {
+		return runImagePromptOnly(client, prompt)
+	}
+
+	// Variations are generated one after another, so each gets the full budget
+	ctx, cancel := createContext(time.Duration(max(imageVariations, 1)) * 5 * time.Minute)
 	defer cancel()
 
 	// Build options and enhance prompt
 	opts := buildImageOptions()
+	if imageReference != "" {
+		reference, err := resolveReferenceImage(imageReference)
+		if err != nil {
+			return err
+		}
+		opts.ReferenceImage = reference
+	}
 	finalPrompt := buildFinalPrompt(client, prompt)
+	if imageVariations > 1 {
+		return generateImageVariations(ctx, client, prompt, finalPrompt, opts)
+	}
 
 	// Generate image
-	fmt.Printf("\n🖼️  Generating image...\n")
+	fmt.Fprintf(imageStatus, "\n🖼️  Generating image...\n")
 	response, err := client.GenerateImage(ctx, finalPrompt, opts)
 	if err != nil {
 		return fmt.Errorf("failed to generate image: %w", err)
@@ -154,8 +261,202 @@ func runImageGeneration(prompt string) error {
 	// Save to history (non-blocking)
 	saveToHistory(prompt, imageData, opts.Model)
 
+	if imageOutputStdout {
+		if _, err := app.NewMediaDownloader(nil).CopyTo(os.Stdout, imageData.URL); err != nil {
+			return fmt.Errorf("failed to write image to stdout: %w", err)
+		}
+		return nil
+	}
+
 	// Display and handle the result
-	return displayImageResult(imageData, finalPrompt, imageSize)
+	return displayImageResult(imageData, finalPrompt, imageSize, imageOutput)
+}
+
+// validateImageOutputStdout rejects flags that write elsewhere or print to
+// stdout alongside --output-stdout, and refuses to dump binary to a terminal.
+func validateImageOutputStdout() error {
+	switch {
+	case imageOutput != "":
+		return fmt.Errorf("--output-stdout cannot be combined with --output")
+	case imageVariations > 1:
+		return fmt.Errorf("--output-stdout writes a single image; drop --count/--variations-count")
+	case imagePromptOnly || imageCopy || imageShow:
+		return fmt.Errorf("--output-stdout cannot be combined with --prompt-only, --copy, or --show")
+	case term.IsTerminal(os.Stdout.Fd()):
+		return fmt.Errorf("--output-stdout refuses to write binary image data to a terminal; redirect or pipe stdout")
+	}
+	return nil
+}
+
+// generateImageVariations generates imageVariations images from one prompt,
+// saving each under a numbered name and optionally composing a grid.
+// Images are generated one at a time; downloads run on a small worker pool
+// while the next image generates. Each image gets its own history entry.
+// A failed variation is reported and skipped; it fails only if none succeed.
+func generateImageVariations(ctx context.Context, client *app.Client, prompt, finalPrompt string, opts app.ImageOptions) error {
+	base := imageOutput
+	if base == "" {
+		base = autoOutputPath("image", finalPrompt, ".png", imageOutputDir, imageOrganizeByDate)
+	}
+
+	// Workers only download; all output happens below, in generation order
+	type download struct {
+		index int
+		url   string
+	}
+	jobs := make(chan download, imageVariations)
+	generated := make([]*app.ImageData, imageVariations)
+	downloads := make([]*ImageSaveResult, imageVariations)
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(imageDownloadWorkers, imageVariations); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			saver := NewImageSaver(nil)
+			for job := range jobs {
+				downloads[job.index] = saver.Save(job.url, suffixedPath(base, fmt.Sprint(job.index+1)))
+			}
+		}()
+	}
+
+	for i := 1; i <= imageVariations; i++ {
+		fmt.Printf("\n🖼️  Generating image %d/%d...\n", i, imageVariations)
+		response, err := client.GenerateImage(ctx, finalPrompt, opts)
+		if err != nil {
+			fmt.Printf("⚠️  Variation %d failed: %v\n", i, err)
+			continue
+		}
+
+		imageData := response.Data[0]
+		saveToHistory(prompt, imageData, opts.Model)
+		generated[i-1] = &imageData
+		jobs <- download{index: i - 1, url: imageData.URL}
+	}
+	close(jobs)
+	wg.Wait()
+
+	handler := &DefaultImageOutputHandler{}
+	saved := make([]string, imageVariations) // Indexed, so the grid keeps generation order
+	for i, imageData := range generated {
+		if imageData == nil {
+			continue
+		}
+		result := &ImageResult{Data: *imageData, Prompt: finalPrompt, Size: imageSize}
+		handler.PrintSuccess(result)
+		reportImageResult(result, downloads[i], imageOutputConfig(downloads[i].FilePath), handler)
+		if downloads[i].Error == nil {
+			saved[i] = downloads[i].FilePath
+		}
+	}
+
+	saved = slices.DeleteFunc(saved, func(path string) bool { return path == "" })
+	if len(saved) == 0 {
+		return fmt.Errorf("failed to generate any of %d image variations", imageVariations)
+	}
+
+	if imageGrid {
+		gridPath := suffixedPath(base, "grid")
+		if err := writeImageGrid(saved, gridPath); err != nil {
+			return fmt.Errorf("failed to create grid: %w", err)
+		}
+		fmt.Printf("\n🧩 Grid of %d images saved to: %s\n", len(saved), gridPath)
+	}
+	return nil
+}
+
+// suffixedPath inserts -suffix before the extension: art.png -> art-2.png.
+func suffixedPath(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + suffix + ext
+}
+
+// writeImageGrid composes the saved images into a PNG contact sheet.
+func writeImageGrid(paths []string, gridPath string) error {
+	images := make([]image.Image, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return err
+		}
+		img, _, err := image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("cannot decode %s: %w", path, err)
+		}
+		images = append(images, img)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, app.ComposeGrid(images)); err != nil {
+		return err
+	}
+	return app.WriteFileAtomic(gridPath, buf.Bytes(), outputFileMode(0644))
+}
+
+// runImageUpscale enlarges input and saves the result next to it (or to output).
+func runImageUpscale(input string, scale int, output string, localOnly bool) error {
+	data, err := os.ReadFile(filepath.Clean(input))
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	if output == "" {
+		output = strings.TrimSuffix(input, filepath.Ext(input)) + "-upscaled.png"
+	}
+
+	ctx, cancel := createContext(5 * time.Minute)
+	defer cancel()
+
+	result, err := newClientWithoutHistory().UpscaleImage(ctx, data, scale, localOnly)
+	if err != nil {
+		return err
+	}
+	if err := app.WriteFileAtomic(output, result.Data, outputFileMode(0644)); err != nil {
+		return fmt.Errorf("failed to save image: %w", err)
+	}
+
+	method := "AI upscaling (API)"
+	if result.Method == app.UpscaleMethodLocal {
+		method = "local Lanczos resize"
+	}
+	fmt.Printf("✅ Upscaled %dx with %s\n", scale, method)
+	if result.Width > 0 && result.Height > 0 {
+		fmt.Printf("📐 Size: %dx%d\n", result.Width, result.Height)
+	}
+	fmt.Printf("💾 Saved to: %s\n", output)
+	return nil
+}
+
+// ImagePromptResult is the --prompt-only --json output.
+type ImagePromptResult struct {
+	Original string `json:"original"`
+	Enhanced string `json:"enhanced,omitempty"`
+	Final    string `json:"final"`
+}
+
+// runImagePromptOnly prints the prompt that would be sent to the image API.
+// Enhancement failures are returned rather than silently falling back.
+func runImagePromptOnly(client *app.Client, prompt string) error {
+	result := ImagePromptResult{Original: prompt, Final: prompt}
+	if shouldEnhancePrompt() {
+		enhanced, err := enhanceImagePrompt(client, prompt, imageEnhanceTemperature, imageEnhanceMaxTokens)
+		if err != nil {
+			return fmt.Errorf("failed to enhance prompt: %w", err)
+		}
+		result.Enhanced = enhanced
+		result.Final = prompt + ". " + enhanced
+	}
+
+	if viper.GetBool("json") {
+		data, err := marshalJSON(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println(result.Final)
+	return nil
 }
 
 // buildImageOptions creates image options from command line flags and config.
@@ -175,25 +476,41 @@ func buildImageOptions() app.ImageOptions {
 	return opts
 }
 
+// resolveReferenceImage returns a URL as-is or encodes a local image as a data URI.
+func resolveReferenceImage(source string) (string, error) {
+	fmt.Fprintf(imageStatus, "🖌️  Style reference: %s\n", source)
+	if detectImageSource(source) == ImageSourceURL {
+		return source, nil
+	}
+	if err := app.CheckImageFileSize(source, app.MaxReferenceImageBytes); err != nil {
+		return "", fmt.Errorf("reference image: %w", err)
+	}
+	reference, err := encodeLocalImage(source, utils.OSFileReader{})
+	if err != nil {
+		return "", fmt.Errorf("reference image: %w", err)
+	}
+	return reference, nil
+}
+
 // buildFinalPrompt creates the final prompt by optionally enhancing the original.
 func buildFinalPrompt(client *app.Client, originalPrompt string) string {
 	if !shouldEnhancePrompt() {
-		fmt.Printf("🎨 Generating image: %s\n", originalPrompt)
+		fmt.Fprintf(imageStatus, "🎨 Generating image: %s\n", originalPrompt)
 		return originalPrompt
 	}
 
-	fmt.Printf("🎨 Original: %s\n", originalPrompt)
-	fmt.Printf("✨ Enhancing prompt...\n")
+	fmt.Fprintf(imageStatus, "🎨 Original: %s\n", originalPrompt)
+	fmt.Fprintf(imageStatus, "✨ Enhancing prompt...\n")
 
-	enhanced, err := enhanceImagePrompt(client, originalPrompt)
+	enhanced, err := enhanceImagePrompt(client, originalPrompt, imageEnhanceTemperature, imageEnhanceMaxTokens)
 	if err != nil {
-		fmt.Printf("⚠️  Enhancement failed, using original: %v\n", err)
+		fmt.Fprintf(imageStatus, "⚠️  Enhancement failed, using original: %v\n", err)
 		return originalPrompt
 	}
 
 	// Combine original + enhanced for best results
 	finalPrompt := originalPrompt + ". " + enhanced
-	fmt.Printf("✨ Enhanced: %s\n", enhanced)
+	fmt.Fprintf(imageStatus, "✨ Enhanced: %s\n", enhanced)
 	return finalPrompt
 }
 
@@ -252,9 +569,11 @@ func (h *DefaultImageOutputHandler) PrintCopySuccess() {
 
 // ImageOutputConfig holds configuration for image output operations.
 type ImageOutputConfig struct {
-	Copy   bool
-	Show   bool
-	Output string
+	Copy           bool
+	Show           bool
+	Output         string
+	OutputDir      string // Directory for auto-named files
+	OrganizeByDate bool   // Nest auto-named files under YYYY/MM/DD
 }
 
 // ProcessImageResult processes the image result and handles all output operations.
@@ -265,16 +584,20 @@ func ProcessImageResult(result *ImageResult, cfg ImageOutputConfig, handler Imag
 	// Determine output path
 	outputPath := cfg.Output
 	if outputPath == "" {
-		timestamp := time.Now().Format("20060102-150405")
-		outputPath = fmt.Sprintf("zai-image-%s.png", timestamp)
+		outputPath = autoOutputPath("image", result.Prompt, ".png", cfg.OutputDir, cfg.OrganizeByDate)
 	}
 
-	// Save to disk
-	saveResult := saver.Save(result.Data.URL, outputPath)
+	reportImageResult(result, saver.Save(result.Data.URL, outputPath), cfg, handler)
+	return nil
+}
+
+// reportImageResult prints the outcome of saving result and handles --copy
+// and --show. The image has already been downloaded.
+func reportImageResult(result *ImageResult, saveResult *ImageSaveResult, cfg ImageOutputConfig, handler ImageOutputHandler) {
 	if saveResult.Error != nil {
 		handler.PrintSaveError(saveResult.Error)
 	} else {
-		handler.PrintSaveSuccess(outputPath)
+		handler.PrintSaveSuccess(saveResult.FilePath)
 	}
 
 	// Copy to clipboard
@@ -292,73 +615,45 @@ func ProcessImageResult(result *ImageResult, cfg ImageOutputConfig, handler Imag
 			handler.PrintViewerError(err)
 		}
 	}
-
-	return nil
 }
 
 // displayImageResult handles displaying, saving, and opening the generated image.
-func displayImageResult(imageData app.ImageData, prompt, size string) error {
+// output is the file path to save to; empty means an auto-generated name.
+func displayImageResult(imageData app.ImageData, prompt, size, output string) error {
 	result := &ImageResult{
 		Data:   imageData,
 		Prompt: prompt,
 		Size:   size,
 	}
 
-	cfg := ImageOutputConfig{
-		Copy:   imageCopy,
-		Show:   imageShow,
-		Output: imageOutput,
-	}
-
 	handler := &DefaultImageOutputHandler{}
 	saver := NewImageSaver(nil)
 
-	return ProcessImageResult(result, cfg, handler, saver)
+	return ProcessImageResult(result, imageOutputConfig(output), handler, saver)
+}
+
+// imageOutputConfig returns the output settings from the image flags.
+func imageOutputConfig(output string) ImageOutputConfig {
+	return ImageOutputConfig{
+		Copy:           imageCopy,
+		Show:           imageShow,
+		Output:         output,
+		OutputDir:      imageOutputDir,
+		OrganizeByDate: imageOrganizeByDate,
+	}
 }
 
 // saveToHistory saves the image to history store.
 func saveToHistory(prompt string, imageData app.ImageData, model string) {
-	historyStore := app.NewFileHistoryStore("")
+	historyStore := newHistoryStore()
 	historyEntry := app.NewImageHistoryEntry(prompt, imageData, model)
 	if err := historyStore.Save(historyEntry); err != nil {
-		fmt.Printf("⚠️  Warning: Failed to save to history: %v\n", err)
+		fmt.Fprintf(imageStatus, "⚠️  Warning: Failed to save to history: %v\n", err)
 	}
 }
 
 func runImageModelList() error {
-	client := newClient()
-
-	ctx, cancel := createContext(30 * time.Second)
-	defer cancel()
-
-	// Note: Using the same ListModels method as chat for now
-	// In a real implementation, this might be a separate endpoint
-	models, err := client.ListModels(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to list models: %w", err)
-	}
-
-	fmt.Println("Available Models:")
-	fmt.Println("─────────────────")
-
-	// Show the image generation model specifically
-	imageModel := viper.GetString("api.image_model")
-	if imageModel == "" {
-		imageModel = "glm-image"
-	}
-
-	fmt.Printf("  %s  (image generation)\n", imageModel)
-
-	// Show other models that might support images
-	for _, m := range models {
-		if strings.Contains(strings.ToLower(m.ID), "image") ||
-			strings.Contains(strings.ToLower(m.ID), "glm-image") ||
-			strings.Contains(strings.ToLower(m.ID), "dall-e") {
-			fmt.Printf("  %s  (image capable)\n", m.ID)
-		}
-	}
-
-	return nil
+	return runCapabilityModelList(capabilityImage, getModelWithDefault("api.image_model", "glm-image"), "image generation")
 }
 
 // ImageSaver handles saving images to disk.
@@ -368,7 +663,9 @@ type ImageSaver struct {
 
 // NewImageSaver creates an ImageSaver with the provided HTTP client.
 func NewImageSaver(httpClient app.HTTPDoer) *ImageSaver {
-	return &ImageSaver{downloader: app.NewMediaDownloader(httpClient)}
+	downloader := app.NewMediaDownloader(httpClient)
+	downloader.FileMode = outputFileMode(0)
+	return &ImageSaver{downloader: downloader}
 }
 
 // ImageSaveResult contains the result of saving an image.
@@ -390,28 +687,6 @@ func (s *ImageSaver) Save(url, filePath string) *ImageSaveResult {
 	}
 }
 
-// copyToClipboard copies URL to clipboard (macOS, Linux, Windows)
-func copyToClipboard(url string) error {
-	var cmd *exec.Cmd
-	var err error
-
-	// Platform-specific commands
-	if _, err = exec.LookPath("pbcopy"); err == nil { //nolint:nestif // platform detection requires chained lookups
-		cmd = exec.Command("pbcopy")
-	} else if _, err = exec.LookPath("xclip"); err == nil { // Linux
-		cmd = exec.Command("xclip", "-selection", "clipboard")
-	} else if _, err = exec.LookPath("xsel"); err == nil { // Linux (alternative)
-		cmd = exec.Command("xsel", "--clipboard", "--input")
-	} else if _, err = exec.LookPath("clip"); err == nil { // Windows
-		cmd = exec.Command("clip")
-	} else {
-		return fmt.Errorf("no suitable clipboard tool found (requires: pbcopy/macOS, xclip/xsel/Linux, or clip/Windows)")
-	}
-
-	cmd.Stdin = strings.NewReader(url)
-	return cmd.Run()
-}
-
 // openImageViewer opens URL with default viewer
 func openImageViewer(url string) error {
 	return app.OpenWith(url)
@@ -2,11 +2,17 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"regexp"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/app"
 )
 
 var modelCmd = &cobra.Command{
@@ -16,26 +22,64 @@ var modelCmd = &cobra.Command{
 }
 
 var (
-	modelJSON bool
+	modelJSON   bool
+	modelFilter string
 )
 
 var modelListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List available models from the API",
+	Long: `List available models from the API.
+
+Examples:
+  zai model list
+  zai model list --filter 'glm-4'
+  zai model list --filter '(?i)flash$' --json`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		return runModelList()
 	},
 }
 
+var modelCompareCmd = &cobra.Command{
+	Use:   "compare <model-a> <model-b> <prompt>",
+	Short: "Send one prompt to two models and compare the answers",
+	Long: `Send the same prompt to two models concurrently and show the responses
+side by side with response time and token usage.
+
+Examples:
+  zai model compare glm-4.6 glm-4.7 "Explain Go channels"
+  zai model compare glm-4.5-flash glm-4.7 "Write a haiku" --json`,
+	Args: cobra.ExactArgs(3),
+	ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) < 2 {
+			return completeModelIDs(cmd, args, toComplete)
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runModelCompare(args[:2], args[2])
+	},
+}
+
 func init() {
 	rootCmd.AddCommand(modelCmd)
 	modelCmd.AddCommand(modelListCmd)
+	modelCmd.AddCommand(modelCompareCmd)
 
 	// Add JSON flag to model list command
 	modelListCmd.Flags().BoolVar(&modelJSON, "json", false, "Output in JSON format")
+	modelListCmd.Flags().StringVar(&modelFilter, "filter", "", "Only show models whose ID matches this regular expression")
 }
 
 func runModelList() error {
+	var filter *regexp.Regexp
+	if modelFilter != "" {
+		var err error
+		if filter, err = regexp.Compile(modelFilter); err != nil {
+			return fmt.Errorf("invalid --filter pattern: %w", err)
+		}
+	}
+
 	client := newClient()
 
 	var ctx context.Context
@@ -46,6 +90,18 @@ func runModelList() error {
 	if err != nil {
 		return fmt.Errorf("failed to list models: %w", err)
 	}
+	// Refresh the list shell completion reads
+	_ = newModelListCache().Set(models)
+
+	if filter != nil {
+		matched := models[:0]
+		for _, m := range models {
+			if filter.MatchString(m.ID) {
+				matched = append(matched, m)
+			}
+		}
+		models = matched
+	}
 
 	if modelJSON {
 		// Create structured JSON output
@@ -55,7 +111,7 @@ func runModelList() error {
 			"timestamp": time.Now().Format(time.RFC3339),
 		}
 
-		data, err := json.MarshalIndent(output, "", "  ")
+		data, err := marshalJSON(output)
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
@@ -72,3 +128,156 @@ func runModelList() error {
 
 	return nil
 }
+
+// modelComparison is one model's result in a model compare run.
+type modelComparison struct {
+	Model      string    `json:"model"`
+	Response   string    `json:"response,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	Usage      app.Usage `json:"usage"`
+}
+
+// compareColumnWidth is the width of each response column in model compare output.
+const compareColumnWidth = 60
+
+func runModelCompare(models []string, prompt string) error {
+	client := newClient()
+	opts := app.DefaultChatOptions()
+	opts.Think = viper.GetBool("think")
+	opts.SystemPrompt = viper.GetString("system")
+	applySamplingFlags(&opts)
+
+	ctx, cancel := createContext(5 * time.Minute)
+	defer cancel()
+
+	results := compareModels(ctx, client, models, prompt, opts)
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(results) {
+		return fmt.Errorf("all models failed: %s: %s", results[0].Model, results[0].Error)
+	}
+
+	if viper.GetBool("json") {
+		data, err := marshalJSON(map[string]interface{}{
+			"prompt":    prompt,
+			"results":   results,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println(lipgloss.JoinHorizontal(lipgloss.Top,
+		renderComparisonColumn(results[0]), "   ", renderComparisonColumn(results[1])))
+	return nil
+}
+
+// compareModels sends prompt to every model concurrently, preserving model order in the results.
+func compareModels(ctx context.Context, client *app.Client, models []string, prompt string, opts app.ChatOptions) []modelComparison {
+	results := make([]modelComparison, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := &results[i]
+			r.Model = model
+
+			modelOpts := opts
+			modelOpts.Model = model
+			modelOpts.OnUsage = func(u app.Usage) { r.Usage = u }
+
+			start := time.Now()
+			response, err := client.Chat(ctx, prompt, modelOpts)
+			r.DurationMs = time.Since(start).Milliseconds()
+			if err != nil {
+				r.Error = err.Error()
+				return
+			}
+			r.Response = response
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// renderComparisonColumn formats one model's result as a fixed-width column.
+func renderComparisonColumn(r modelComparison) string {
+	stats := fmt.Sprintf("%.1fs · %d tokens (%d in / %d out)",
+		float64(r.DurationMs)/1000, r.Usage.TotalTokens, r.Usage.PromptTokens, r.Usage.CompletionTokens)
+	body := r.Response
+	if r.Error != "" {
+		stats = fmt.Sprintf("%.1fs", float64(r.DurationMs)/1000)
+		body = theme.ErrorText.Render("Error: " + r.Error)
+	}
+	return lipgloss.NewStyle().Width(compareColumnWidth).Render(lipgloss.JoinVertical(lipgloss.Left,
+		theme.Section.Render(r.Model),
+		theme.Dim.Render(stats),
+		theme.Divider.Render(strings.Repeat("─", compareColumnWidth)),
+		body,
+	))
+}
+
+// Model capabilities detectable from model IDs.
+const (
+	capabilityImage  = "image"
+	capabilityAudio  = "audio"
+	capabilityVision = "vision"
+)
+
+// capabilityPatterns are lowercase substrings that mark a model ID as having a capability.
+var capabilityPatterns = map[string][]string{
+	capabilityImage:  {"image", "cogview", "dall-e"},
+	capabilityAudio:  {"asr", "whisper", "transcri"},
+	capabilityVision: {"vision"},
+}
+
+// visionSuffix matches GLM vision model IDs such as glm-4.6v or glm-4.5v-flash.
+var visionSuffix = regexp.MustCompile(`\dv($|-)`)
+
+// modelHasCapability reports whether a model ID looks like it supports capability.
+// The API does not report capabilities, so this is a name-based heuristic.
+func modelHasCapability(id, capability string) bool {
+	id = strings.ToLower(id)
+	for _, pattern := range capabilityPatterns[capability] {
+		if strings.Contains(id, pattern) {
+			return true
+		}
+	}
+	return capability == capabilityVision && visionSuffix.MatchString(id)
+}
+
+// runCapabilityModelList prints the configured default model for a capability,
+// followed by any other listed models whose IDs suggest that capability.
+func runCapabilityModelList(capability, defaultModel, label string) error {
+	client := newClient()
+
+	ctx, cancel := createContext(30 * time.Second)
+	defer cancel()
+
+	models, err := client.ListModels(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list models: %w", err)
+	}
+
+	fmt.Println("Available Models:")
+	fmt.Println("─────────────────")
+	fmt.Printf("  %s  (%s)\n", defaultModel, label)
+
+	for _, m := range models {
+		if m.ID != defaultModel && modelHasCapability(m.ID, capability) {
+			fmt.Printf("  %s  (%s capable)\n", m.ID, capability)
+		}
+	}
+
+	return nil
+}
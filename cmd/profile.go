@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// profileEnvVar selects a profile when --profile is not given.
+const profileEnvVar = "ZAI_PROFILE"
+
+// profileError reports a selected profile that could not be loaded. Unlike
+// other config errors it is never ignored, since running with the base config
+// instead would silently use the wrong settings.
+type profileError struct{ error }
+
+func (e profileError) Unwrap() error { return e.error }
+
+var profileCmd = &cobra.Command{
+	Use:   "profile",
+	Short: "Manage named config profiles",
+	Long: `Profiles are YAML files in ~/.config/zai/profiles/ that layer over the
+main config, e.g. separate API keys and base URLs for work and personal use.
+
+Select one with --profile <name> or ZAI_PROFILE. Keys a profile leaves unset
+come from config.yaml.
+
+Examples:
+  zai profile list
+  zai --profile work "summarize this"
+  ZAI_PROFILE=personal zai chat`,
+}
+
+var profileListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List available profiles",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runProfileList()
+	},
+}
+
+func init() {
+	profileCmd.AddCommand(profileListCmd)
+	rootCmd.AddCommand(profileCmd)
+}
+
+// profilesDir returns ~/.config/zai/profiles.
+func profilesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "zai", "profiles"), nil
+}
+
+// activeProfile returns the profile named by --profile or ZAI_PROFILE.
+func activeProfile() string {
+	if profile != "" {
+		return profile
+	}
+	return os.Getenv(profileEnvVar)
+}
+
+// listProfiles returns the profile names in the profiles directory, sorted.
+func listProfiles() ([]string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read profiles directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.IsDir() && (ext == ".yaml" || ext == ".yml") {
+			names = append(names, strings.TrimSuffix(entry.Name(), ext))
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// mergeProfile layers the named profile over the config already read.
+// A profile that doesn't exist is an error, never a silent fallback.
+func mergeProfile(name string) error {
+	if name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid profile name %q", name)
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	for _, ext := range []string{".yaml", ".yml"} {
+		data, err = os.ReadFile(filepath.Join(dir, name+ext))
+		if err == nil || !os.IsNotExist(err) {
+			break
+		}
+	}
+	if os.IsNotExist(err) {
+		return fmt.Errorf("profile %q not found: expected %s (see 'zai profile list')", name, filepath.Join(dir, name+".yaml"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read profile %q: %w", name, err)
+	}
+
+	viper.SetConfigType("yaml")
+	if err := viper.MergeConfig(strings.NewReader(string(data))); err != nil {
+		return fmt.Errorf("failed to parse profile %q: %w", name, err)
+	}
+	return nil
+}
+
+// loadProfile merges the named profile, marking failures as profileError.
+func loadProfile(name string) error {
+	if err := mergeProfile(name); err != nil {
+		return profileError{err}
+	}
+	return nil
+}
+
+func runProfileList() error {
+	names, err := listProfiles()
+	if err != nil {
+		return err
+	}
+	if viper.GetBool("json") {
+		data, err := marshalJSON(map[string]interface{}{"profiles": names, "active": activeProfile()})
+		if err != nil {
+			return fmt.Errorf("failed to marshal profiles: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(names) == 0 {
+		dir, _ := profilesDir()
+		fmt.Printf("No profiles found in %s\n", dir)
+		return nil
+	}
+	active := activeProfile()
+	for _, name := range names {
+		marker := "  "
+		if name == active {
+			marker = "* "
+		}
+		fmt.Println(marker + name)
+	}
+	return nil
+}
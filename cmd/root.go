@@ -1,12 +1,18 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"maps"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
@@ -14,6 +20,7 @@ import (
 	"github.com/spf13/viper"
 
 	"github.com/dotcommander/zai/internal/app"
+	"github.com/dotcommander/zai/internal/config"
 )
 
 // Constants for input size limits
@@ -33,6 +40,51 @@ var (
 	search     bool
 	coding     bool
 	system     string
+
+	systemFile  string
+	systemMerge string
+	// systemFilePrompt holds the --system-file contents, read once before any command runs
+	systemFilePrompt string
+	// extraHeaders holds api.headers merged with --headers-file, read once before any command runs
+	extraHeaders map[string]string
+
+	instructionsFile string
+	retryBudget      time.Duration
+	apiVersion       string
+	compress         bool
+	compressModel    string
+	cachePrefix      bool
+	retryOnEmpty     bool
+	envFile          string
+	throttle         time.Duration
+	showRequestSize  bool
+	stdinAsMessage   bool
+	jsonField        string
+	pipeTo           string
+	strictConfig     bool
+	timeoutBackoff   bool
+	outputMode       string
+	jsonPretty       bool
+	stdinFirst       bool
+	region           string
+	deterministic    bool
+	seed             int
+	webFormat        string
+	stripURLs        bool
+	searchBudget     int
+	preset           string
+	profile          string
+	contextMessages  []string
+	streamOutput     bool
+	fromClipboard    bool
+	temperatureSweep string
+	errorFormat      string
+	notify           bool
+	showUsage        bool
+	historyEncrypt   bool
+	historyMaxChars  int
+	headersFile      string
+	overrideAuth     bool
 )
 
 // RunConfig holds runtime configuration collected from flags and config file.
@@ -44,6 +96,18 @@ type RunConfig struct {
 	Search     bool
 	Verbose    bool
 	System     string
+
+	InstructionsFile string
+	Compress         bool
+	CompressModel    string
+	CachePrefix      bool
+	RetryOnEmpty     bool
+	JSONField        string
+	PipeTo           string
+	WebFormat        string
+	StripURLs        bool
+	SearchBudget     int
+	ShowUsage        bool
 }
 
 // NewRunConfig creates RunConfig from viper settings (collected after flag parsing).
@@ -55,6 +119,18 @@ func NewRunConfig() RunConfig {
 		Search:     viper.GetBool("search"),
 		Verbose:    viper.GetBool("verbose"),
 		System:     viper.GetString("system"),
+
+		InstructionsFile: viper.GetString("chat.instructions_file"),
+		Compress:         viper.GetBool("compress"),
+		CompressModel:    viper.GetString("chat.compress_model"),
+		CachePrefix:      viper.GetBool("cache_prefix"),
+		RetryOnEmpty:     viper.GetBool("api.retry.on_empty"),
+		JSONField:        viper.GetString("json_field"),
+		PipeTo:           viper.GetString("pipe_to"),
+		WebFormat:        viper.GetString("web_reader.chat_format"),
+		StripURLs:        viper.GetBool("strip_urls"),
+		SearchBudget:     viper.GetInt("web_search.context_budget"),
+		ShowUsage:        viper.GetBool("show_usage"),
 	}
 }
 
@@ -67,6 +143,9 @@ One-shot mode:
   zai "Explain quantum computing"
   zai -f main.go "Explain this code"
 
+Inline templates ({N} is the Nth value after --, {{ and }} are literal braces):
+  zai "translate {1} to {2}" -- "hello" "French"
+
 Piped input:
   pbpaste | zai "explain this"
   cat file.txt | zai "summarize"
@@ -77,28 +156,99 @@ Interactive REPL:
   zai chat -f main.go
 
 History:
-  zai history`,
+  zai history
+
+Exit codes:
+  0  success
+  1  error
+  2  partial failure (batch commands where some items failed)`,
 	Args: cobra.ArbitraryArgs,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		// Load the env file first so AutomaticEnv sees its variables
+		if envFile != "" {
+			if err := app.LoadEnvFile(envFile); err != nil {
+				return err
+			}
+		}
+
 		// Skip config init for commands that don't need API
-		if cmd.Name() == "history" || cmd.Name() == "completion" || cmd.Name() == "help" || cmd.Name() == "version" {
-			return nil
+		if skipsConfigInit(cmd) {
+			// Best effort: history still honors history.* settings. A bad
+			// profile still fails, except for 'profile' itself (to list names).
+			var perr profileError
+			if err := readConfigFile(); errors.As(err, &perr) && !isProfileCommand(cmd) {
+				return err
+			}
+		} else if err := initConfig(); err != nil {
+			return err
+		}
+		if err := applyPreset(cmd, preset); err != nil {
+			return err
+		}
+		if err := checkStrictConfig(); err != nil {
+			return err
+		}
+		if err := validateOutputMode(); err != nil {
+			return err
+		}
+		if err := validateWebFormat(); err != nil {
+			return err
+		}
+		if f := viper.GetString("error_format"); f != "text" && f != "json" {
+			return fmt.Errorf("invalid --error-format %q (must be text or json)", f)
 		}
-		return initConfig()
+		if err := validateSystemMerge(); err != nil {
+			return err
+		}
+		if path := viper.GetString("system_file"); path != "" {
+			content, err := readSystemFile(path)
+			if err != nil {
+				return fmt.Errorf("failed to read --system-file: %w", err)
+			}
+			systemFilePrompt = content
+		}
+		headers, err := loadExtraHeaders()
+		if err != nil {
+			return err
+		}
+		extraHeaders = headers
+		if command := viper.GetString("notify.command"); viper.GetBool("notify.enabled") && command != "" {
+			if _, err := app.ResolveNotifyCommand(command); err != nil {
+				return err
+			}
+		}
+		return nil
 	},
 	RunE: func(cmd *cobra.Command, args []string) error {
-		var prompt string
 		var stdinData string
 
 		// Check for stdin data (piped input)
 		if hasStdinData() {
 			data, err := readStdin()
+			if errors.Is(err, errBinaryStdin) {
+				return err
+			}
 			if err != nil {
 				return fmt.Errorf("failed to read stdin: %w", err)
 			}
 			stdinData = data
 		}
 
+		// --from-clipboard supplies the input that would otherwise be piped
+		if fromClipboard {
+			if stdinData != "" {
+				return fmt.Errorf("--from-clipboard cannot be combined with piped stdin")
+			}
+			data, err := readClipboard()
+			if err != nil {
+				return err
+			}
+			if data == "" {
+				return fmt.Errorf("clipboard is empty")
+			}
+			stdinData = data
+		}
+
 		// Handle --system flag: "-", "/dev/stdin", or file paths
 		systemVal := viper.GetString("system")
 		stdinUsedForSystem := false
@@ -118,43 +268,181 @@ History:
 			}
 		}
 
-		// If stdin wasn't used for system prompt, prepend it to user prompt as context
-		if stdinData != "" && !stdinUsedForSystem {
-			var b strings.Builder
-			b.WriteString("<stdin>\n")
-			b.WriteString(stdinData)
-			b.WriteString("\n</stdin>\n\n")
-			b.WriteString(prompt)
-			prompt = b.String()
+		// If stdin wasn't used for system prompt, include it as context around the args
+		if stdinUsedForSystem {
+			stdinData = ""
+		}
+		promptArgs, err := expandInlineTemplate(args, cmd.ArgsLenAtDash())
+		if err != nil {
+			return err
 		}
 
-		// Build prompt from args
-		if len(args) > 0 {
-			var b strings.Builder
-			if prompt != "" {
-				b.WriteString(prompt)
-				b.WriteString(" ")
-			}
-			b.WriteString(strings.Join(args, " "))
-			prompt = b.String()
+		// --stdin-as-message sends stdin as its own user message ahead of the prompt
+		var stdinMessage string
+		if viper.GetBool("stdin_as_message") && len(promptArgs) > 0 {
+			stdinMessage, stdinData = stdinData, ""
 		}
+		prompt := composePrompt(stdinData, promptArgs, viper.GetBool("stdin_first"))
 
 		// Require some input
 		if prompt == "" {
 			return cmd.Help()
 		}
 
-		return runOneShot(prompt)
+		return runOneShot(prompt, stdinMessage)
 	},
 }
 
+// expandInlineTemplate fills {N} placeholders in the args before "--" with the
+// args after it. Without placeholders (e.g. "--" only guards a leading dash),
+// the args are returned unchanged.
+func expandInlineTemplate(args []string, dash int) ([]string, error) {
+	if dash <= 0 {
+		return args, nil
+	}
+	template := strings.Join(args[:dash], " ")
+	if !app.HasPositionalPlaceholders(template) {
+		return args, nil
+	}
+	prompt, err := app.ExpandPositional(template, args[dash:])
+	if err != nil {
+		return nil, fmt.Errorf("inline template: %w", err)
+	}
+	return []string{prompt}, nil
+}
+
+// composePrompt joins args into the prompt and wraps stdin in <stdin> tags,
+// placing it before the prompt (default) or after it when stdinFirst is false.
+// The stdin-first layout is byte-for-byte the one zai always used.
+func composePrompt(stdinData string, args []string, stdinFirst bool) string {
+	prompt := strings.Join(args, " ")
+	if stdinData == "" {
+		return prompt
+	}
+
+	block := "<stdin>\n" + stdinData + "\n</stdin>"
+	switch {
+	case prompt == "":
+		return block + "\n\n"
+	case stdinFirst:
+		return block + "\n\n " + prompt
+	default:
+		return prompt + "\n\n" + block
+	}
+}
+
+// isProfileCommand reports whether cmd is 'profile' or one of its subcommands.
+func isProfileCommand(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		if c == profileCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// skipsConfigInit reports whether cmd (or a parent command) runs without API configuration.
+func skipsConfigInit(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Name() {
+		case "history", "config", "cache", "profile", "completion", "help", "version":
+			return true
+		}
+	}
+	return false
+}
+
+// Exit codes returned by Execute.
+const (
+	ExitError          = 1
+	ExitPartialFailure = 2
+)
+
+// PartialError reports a batch run where some items succeeded and some failed.
+// Execute maps it to ExitPartialFailure so scripts can tell it apart from total failure.
+type PartialError struct {
+	Succeeded int
+	Failed    int
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%d of %d items failed", e.Failed, e.Succeeded+e.Failed)
+}
+
+// batchError returns nil when nothing failed, a PartialError when some items
+// succeeded, and a plain error when every item failed.
+func batchError(succeeded, failed int) error {
+	switch {
+	case failed == 0:
+		return nil
+	case succeeded > 0:
+		return &PartialError{Succeeded: succeeded, Failed: failed}
+	default:
+		return fmt.Errorf("all %d items failed", failed)
+	}
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		printStyledError(err)
-		os.Exit(1)
+	cmd, err := rootCmd.ExecuteC()
+	if viper.GetBool("notify.enabled") {
+		notifyCompletion(cmd, err)
+	}
+	if err != nil {
+		exitCode := ExitError
+		var partial *PartialError
+		if errors.As(err, &partial) {
+			exitCode = ExitPartialFailure
+		}
+		if viper.GetString("error_format") == "json" {
+			printJSONError(err, exitCode)
+		} else {
+			printStyledError(err)
+		}
+		os.Exit(exitCode)
+	}
+}
+
+// notifyCompletion sends the --notify notification for a finished command.
+// A failing notifier only produces a warning.
+func notifyCompletion(cmd *cobra.Command, err error) {
+	if cmd == nil {
+		cmd = rootCmd
+	}
+	status, message := "success", cmd.CommandPath()+" finished"
+	if err != nil {
+		status, message = "failure", cmd.CommandPath()+" failed: "+err.Error()
+	}
+	if nerr := app.Notify(viper.GetString("notify.command"), status, message, os.Stderr); nerr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: notification failed: %v\n", nerr)
 	}
 }
 
+// jsonError is the --error-format json shape of an error on stderr.
+type jsonError struct {
+	Error     string `json:"error"`
+	Type      string `json:"type"`           // api, usage, partial, or error
+	Code      int    `json:"code,omitempty"` // HTTP status for api errors
+	RequestID string `json:"request_id,omitempty"`
+	ExitCode  int    `json:"exit_code"`
+}
+
+// printJSONError prints err to stderr as a single-line JSON object.
+func printJSONError(err error, exitCode int) {
+	out := jsonError{Error: err.Error(), Type: "error", ExitCode: exitCode}
+	var apiErr *app.APIError
+	var partial *PartialError
+	switch {
+	case errors.As(err, &apiErr):
+		out.Type, out.Code, out.RequestID = "api", apiErr.StatusCode, apiErr.RequestID
+	case errors.As(err, &partial):
+		out.Type = "partial"
+	case isUsageError(out.Error):
+		out.Type = "usage"
+	}
+	data, _ := json.Marshal(out) //nolint:errchkjson // plain struct of strings and ints
+	fmt.Fprintln(os.Stderr, string(data))
+}
+
 // printStyledError displays an error with lipgloss styling.
 // Detects usage errors and conditionally shows help hint.
 func printStyledError(err error) {
@@ -195,6 +483,48 @@ func isUsageError(errMsg string) bool {
 	return false
 }
 
+// persistentFlagBindings maps viper keys to the root persistent flags that set them.
+var persistentFlagBindings = map[string]string{
+	"verbose":                   "verbose",
+	"file":                      "file",
+	"think":                     "think",
+	"json":                      "json",
+	"json_field":                "json-field",
+	"json_pretty":               "json-pretty",
+	"pipe_to":                   "pipe-to",
+	"strict_config":             "strict-config",
+	"output.file_mode":          "output-mode",
+	"search":                    "search",
+	"coding":                    "coding",
+	"system":                    "system",
+	"system_file":               "system-file",
+	"system_merge":              "system-merge",
+	"chat.instructions_file":    "instructions-file",
+	"api.retry.max_elapsed":     "retry-budget",
+	"api.version":               "api-version",
+	"api.region":                "region",
+	"stdin_first":               "stdin-first",
+	"stdin_as_message":          "stdin-as-message",
+	"compress":                  "compress",
+	"cache_prefix":              "cache-prefix",
+	"api.retry.on_empty":        "retry-on-empty",
+	"api.retry.timeout_backoff": "timeout-backoff",
+	"throttle":                  "throttle",
+	"show_request_size":         "show-request-size",
+	"chat.compress_model":       "compress-model",
+	"deterministic":             "deterministic",
+	"seed":                      "seed",
+	"web_reader.chat_format":    "web-format",
+	"strip_urls":                "strip-urls",
+	"web_search.context_budget": "search-context-budget",
+	"error_format":              "error-format",
+	"notify.enabled":            "notify",
+	"show_usage":                "usage",
+	"history.encrypt":           "history-encrypt",
+	"history.max_entry_chars":   "history-max-chars",
+	"api.headers_file":          "headers-file",
+}
+
 func init() {
 	// Enable custom styled error output
 	rootCmd.SilenceUsage = true
@@ -202,21 +532,64 @@ func init() {
 	rootCmd.SetHelpFunc(styledHelp)
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default $HOME/.config/zai/config.yaml)")
+	rootCmd.PersistentFlags().BoolVar(&strictConfig, "strict-config", false, "fail if the config file contains unknown keys")
+	rootCmd.PersistentFlags().StringVar(&outputMode, "output-mode", "", "permissions for saved files, e.g. 0600 (default: per command, 0644 or 0600)")
+	rootCmd.PersistentFlags().StringVar(&envFile, "env-file", "", "load ZAI_* variables from a dotenv file (existing env vars take precedence)")
+	rootCmd.PersistentFlags().StringVar(&errorFormat, "error-format", "text", "how errors are printed to stderr: text or json ({error, type, code, request_id, exit_code})")
+	rootCmd.PersistentFlags().BoolVar(&historyEncrypt, "history-encrypt", false, "encrypt new history entries with the passphrase from "+app.HistoryKeyEnv+" (prompted if unset)")
+	rootCmd.PersistentFlags().IntVar(&historyMaxChars, "history-max-chars", 0, "truncate the prompt and response saved to history to N characters (0 = unlimited; output is unaffected)")
+	rootCmd.PersistentFlags().BoolVar(&notify, "notify", false, "run notify.command (or ring the terminal bell) when the command finishes or fails")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "layer ~/.config/zai/profiles/<name>.yaml over the config (default $"+profileEnvVar+")")
+	rootCmd.PersistentFlags().StringVar(&headersFile, "headers-file", "", "add headers from a file of \"Name: value\" lines to every API request (overrides api.headers)")
+	rootCmd.PersistentFlags().BoolVar(&overrideAuth, "override-auth", false, "let api.headers or --headers-file replace the Authorization header")
+	rootCmd.PersistentFlags().StringVar(&preset, "preset", "", "apply a named bundle of settings from the presets section of the config file")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
 	rootCmd.PersistentFlags().StringVarP(&filePath, "file", "f", "", "include file contents in prompt")
 	rootCmd.PersistentFlags().BoolVar(&think, "think", false, "enable thinking/reasoning mode")
 	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "output in JSON format")
+	rootCmd.PersistentFlags().StringVar(&pipeTo, "pipe-to", "", `pipe the one-shot output through a command, e.g. "glow -" (run without a shell)`)
+	rootCmd.PersistentFlags().BoolVar(&jsonPretty, "json-pretty", true, "indent JSON output (--json-pretty=false prints compact single-line JSON)")
+	rootCmd.PersistentFlags().StringVar(&jsonField, "json-field", "", "print only this field of the one-shot JSON output ("+strings.Join(oneShotJSONFields, ", ")+")")
 	rootCmd.PersistentFlags().BoolVar(&search, "search", false, "augment prompt with web search results")
+	rootCmd.PersistentFlags().IntVar(&searchBudget, "search-context-budget", 0, "cap the --search context at about this many tokens, dropping lower-ranked results (0 = no cap)")
 	rootCmd.PersistentFlags().BoolVarP(&coding, "coding", "C", false, "use coding API endpoint")
-	rootCmd.PersistentFlags().StringVar(&system, "system", "", "custom system prompt")
+	rootCmd.PersistentFlags().StringVar(&system, "system", "", "custom system prompt, text or a file path (overrides system_prompt)")
+	rootCmd.PersistentFlags().StringVar(&systemFile, "system-file", "", "file with a system prompt, layered between system_prompt and --system")
+	rootCmd.PersistentFlags().StringVar(&systemMerge, "system-merge", "", "combine system_prompt, --system-file and --system: replace (most specific wins) or append (joined in that order); default replace")
+	rootCmd.PersistentFlags().StringVar(&instructionsFile, "instructions-file", "", "file whose contents are prepended to the prompt (e.g. a review rubric)")
+	rootCmd.PersistentFlags().DurationVar(&retryBudget, "retry-budget", 0, "total time allowed for retries, e.g. 20s (0 = unbounded)")
+	rootCmd.PersistentFlags().BoolVar(&showUsage, "usage", false, "print token usage (and estimated cost, if pricing is configured) on stderr after each response")
+	rootCmd.PersistentFlags().BoolVar(&showRequestSize, "show-request-size", false, "report chat request and response sizes (bytes, estimated tokens) on stderr")
+	rootCmd.PersistentFlags().DurationVar(&throttle, "throttle", 0, "fixed pause between batch requests, e.g. 500ms (applies on top of rate limiting)")
+	rootCmd.PersistentFlags().StringVar(&region, "region", "", "API region: global or cn (sets the base URLs unless api.base_url is configured)")
+	rootCmd.PersistentFlags().StringVar(&apiVersion, "api-version", "", "pin API behavior via the X-API-Version header")
+	rootCmd.PersistentFlags().BoolVar(&stdinFirst, "stdin-first", true, "piped stdin goes before the prompt by default; only --stdin-first=false changes anything, appending it after")
+	rootCmd.PersistentFlags().BoolVar(&stdinAsMessage, "stdin-as-message", false, "send piped stdin as a separate user message before the prompt instead of merging it")
+	rootCmd.PersistentFlags().BoolVar(&compress, "compress", false, "embed a summary of the -f file instead of its raw contents")
+	rootCmd.PersistentFlags().StringVar(&compressModel, "compress-model", "", "model used to summarize for --compress (default "+app.DefaultCompressModel+")")
+	rootCmd.PersistentFlags().BoolVar(&timeoutBackoff, "timeout-backoff", false, "give each chat retry a longer timeout (1x, 1.5x, 2x, ...), bounded by --retry-budget")
+	rootCmd.PersistentFlags().BoolVar(&retryOnEmpty, "retry-on-empty", false, "retry when the API returns an empty response (uses the configured retry attempts)")
+	rootCmd.PersistentFlags().StringVar(&webFormat, "web-format", "", "format for URLs auto-fetched into the prompt: "+strings.Join(webChatFormats, " or ")+" (default markdown)")
+	rootCmd.PersistentFlags().BoolVar(&stripURLs, "strip-urls", false, "remove URLs from the prompt before sending, so they are neither fetched nor seen by the model")
+	rootCmd.PersistentFlags().BoolVar(&deterministic, "deterministic", false, "reproducible output: temperature 0, top-p 1 and a fixed seed (if the API honors it)")
+	rootCmd.PersistentFlags().IntVar(&seed, "seed", 0, fmt.Sprintf("sampling seed sent with chat requests (--deterministic uses %d unless set)", defaultDeterministicSeed))
+	rootCmd.PersistentFlags().BoolVar(&cachePrefix, "cache-prefix", false, "send the system prompt and -f file as a stable prefix the API can cache")
+
+	rootCmd.Flags().StringArrayVar(&contextMessages, "context", nil, `prior message for a one-shot prompt as "role:content" (repeatable)`)
+	rootCmd.Flags().BoolVar(&streamOutput, "stream", false, "print the one-shot response as it is generated")
+	rootCmd.Flags().StringVar(&temperatureSweep, "temperature-sweep", "", fmt.Sprintf("run the prompt once per comma-separated temperature (e.g. 0.2,0.6,1.0; at most %d) and label each response", maxSweepTemperatures))
+	rootCmd.Flags().BoolVar(&fromClipboard, "from-clipboard", false, "read input from the system clipboard, placed like piped stdin around the prompt")
+
+	for key, name := range persistentFlagBindings {
+		_ = viper.BindPFlag(key, rootCmd.PersistentFlags().Lookup(name))
+	}
 
-	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
-	_ = viper.BindPFlag("file", rootCmd.PersistentFlags().Lookup("file"))
-	_ = viper.BindPFlag("think", rootCmd.PersistentFlags().Lookup("think"))
-	_ = viper.BindPFlag("json", rootCmd.PersistentFlags().Lookup("json"))
-	_ = viper.BindPFlag("search", rootCmd.PersistentFlags().Lookup("search"))
-	_ = viper.BindPFlag("coding", rootCmd.PersistentFlags().Lookup("coding"))
-	_ = viper.BindPFlag("system", rootCmd.PersistentFlags().Lookup("system"))
+	_ = rootCmd.RegisterFlagCompletionFunc("preset", completePresetNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("profile", completeProfileNames)
+	_ = rootCmd.RegisterFlagCompletionFunc("web-format", cobra.FixedCompletions(webChatFormats, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("system-merge", cobra.FixedCompletions(systemMergeModes, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("error-format", cobra.FixedCompletions([]string{"text", "json"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.RegisterFlagCompletionFunc("json-field", cobra.FixedCompletions(oneShotJSONFields, cobra.ShellCompDirectiveNoFileComp))
 }
 
 // styledHelp displays the custom styled help output.
@@ -255,6 +628,7 @@ func styledHelp(cmd *cobra.Command, args []string) {
 	commands := [][]string{
 		{"chat", "Interactive chat session (REPL)"},
 		{"search", "Search the web"},
+		{"cache", "Inspect and clear the search cache"},
 		{"reader", "Fetch web content"},
 		{"image", "Generate images with AI enhancement"},
 		{"vision", "Analyze images with AI vision"},
@@ -296,6 +670,55 @@ func styledHelp(cmd *cobra.Command, args []string) {
 }
 
 func initConfig() error {
+	if err := readConfigFile(); err != nil {
+		return err
+	}
+
+	if err := applyRegion(); err != nil {
+		return err
+	}
+
+	if viper.GetString("api.key") == "" {
+		return fmt.Errorf("API key required: set ZAI_API_KEY or configure in ~/.config/zai/config.yaml")
+	}
+
+	return nil
+}
+
+// applyRegion points the API base URLs at api.region's endpoints.
+// An explicit api.base_url (config file or ZAI_API_BASE_URL) wins over the region.
+func applyRegion() error {
+	region := viper.GetString("api.region")
+	if region == "" {
+		return nil
+	}
+
+	endpoints, ok := config.Regions[region]
+	if !ok {
+		return fmt.Errorf("unknown region %q (valid: %s)", region, strings.Join(config.RegionNames(), ", "))
+	}
+
+	if !isExplicitlySet("api.base_url") {
+		viper.Set("api.base_url", endpoints.BaseURL)
+	}
+	if !isExplicitlySet("api.coding_base_url") {
+		viper.Set("api.coding_base_url", endpoints.CodingBaseURL)
+	}
+	return nil
+}
+
+// isExplicitlySet reports whether key comes from the config file or environment
+// rather than a default.
+func isExplicitlySet(key string) bool {
+	if viper.InConfig(key) {
+		return true
+	}
+	_, ok := os.LookupEnv(configEnvVar(key))
+	return ok
+}
+
+// readConfigFile loads the config file and ZAI_* environment overrides.
+func readConfigFile() error {
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -316,17 +739,198 @@ func initConfig() error {
 		}
 	}
 
+	if name := activeProfile(); name != "" {
+		if err := loadProfile(name); err != nil {
+			return err
+		}
+	}
+
 	viper.SetEnvPrefix("ZAI")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
-	if viper.GetString("api.key") == "" {
-		return fmt.Errorf("API key required: set ZAI_API_KEY or configure in ~/.config/zai/config.yaml")
+	return nil
+}
+
+// autoOutputSlugLength caps the prompt-derived part of generated file names.
+const autoOutputSlugLength = 40
+
+// autoOutputName builds a default file name like zai-image-20240115-093000-a-red-fox.png.
+// The prompt slug is sanitized so prompts containing "/", ":", "?" etc. still save.
+func autoOutputName(kind, prompt, ext string) string {
+	name := fmt.Sprintf("zai-%s-%s", kind, time.Now().Format("20060102-150405"))
+	if strings.TrimSpace(prompt) != "" {
+		name += "-" + app.SanitizeFilename(prompt, autoOutputSlugLength)
+	}
+	return name + ext
+}
+
+// autoOutputPath places an auto-generated file name under dir, nesting it in
+// YYYY/MM/DD subdirectories when byDate is set. Empty flag values fall back to
+// media.output_dir and media.organize_by_date. Parent directories are created
+// by the downloader on save.
+func autoOutputPath(kind, prompt, ext, dir string, byDate bool) string {
+	if dir == "" {
+		dir = viper.GetString("media.output_dir")
+	}
+	name := autoOutputName(kind, prompt, ext)
+	if byDate || viper.GetBool("media.organize_by_date") {
+		name = filepath.Join(time.Now().Format("2006/01/02"), name)
+	}
+	return filepath.Join(dir, name)
+}
+
+// applyPreset applies the settings of the named preset from the config file.
+// Keys are flag names of the running command (model, enhance, quality) or
+// config keys (api.model); other keys are skipped. Flags given on the
+// command line take precedence.
+func applyPreset(cmd *cobra.Command, name string) error {
+	if name == "" {
+		return nil
+	}
+	settings := viper.GetStringMap("presets." + name)
+	if len(settings) == 0 {
+		return fmt.Errorf("unknown preset %q (define it under presets.%s in the config file)", name, name)
 	}
 
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := settings[key]
+		if flag := cmd.Flags().Lookup(key); flag != nil {
+			if flagOverridden(cmd, flag.Name) {
+				continue
+			}
+			if err := cmd.Flags().Set(key, presetFlagValue(value)); err != nil {
+				return fmt.Errorf("preset %q: invalid value for --%s: %w", name, key, err)
+			}
+			continue
+		}
+		if !slices.Contains(knownConfigKeys(), key) {
+			// Presets are shared across commands, so a key may belong to another one
+			if viper.GetBool("verbose") {
+				fmt.Fprintln(os.Stderr, theme.Dim.Render(fmt.Sprintf(
+					"preset %q: skipping %q (neither a flag of '%s' nor a config key)", name, key, cmd.CommandPath())))
+			}
+			continue
+		}
+		if flagName, ok := persistentFlagBindings[key]; ok && cmd.Flags().Changed(flagName) {
+			continue
+		}
+		viper.Set(key, value)
+	}
 	return nil
 }
 
+// flagOverridden reports whether flag, or a flag mutually exclusive with it
+// (such as --no-enhance for --enhance), was given on the command line.
+func flagOverridden(cmd *cobra.Command, name string) bool {
+	flag := cmd.Flags().Lookup(name)
+	if flag.Changed {
+		return true
+	}
+	for _, group := range flag.Annotations["cobra_annotation_mutually_exclusive"] {
+		for _, other := range strings.Fields(group) {
+			if cmd.Flags().Changed(other) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// presetFlagValue converts a preset value to flag syntax (lists become comma-separated).
+func presetFlagValue(value interface{}) string {
+	if list, ok := value.([]interface{}); ok {
+		parts := make([]string, len(list))
+		for i, v := range list {
+			parts[i] = fmt.Sprint(v)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
+
+// outputFileMode returns the output.file_mode (--output-mode) permissions for
+// files zai saves, or fallback when unset. The value is checked up front by
+// validateOutputMode.
+func outputFileMode(fallback os.FileMode) os.FileMode {
+	if mode, err := app.ParseFileMode(viper.GetString("output.file_mode")); err == nil {
+		return mode
+	}
+	return fallback
+}
+
+// outputFormatExtensions maps output file extensions to the format they imply.
+var outputFormatExtensions = map[string]string{
+	".srt":      "srt",
+	".vtt":      "vtt",
+	".json":     "json",
+	".jsonl":    "jsonl",
+	".md":       "markdown",
+	".markdown": "markdown",
+	".csv":      "csv",
+	".txt":      "text",
+}
+
+// formatFromExtension returns the output format implied by path's extension.
+func formatFromExtension(path string) (string, bool) {
+	format, ok := outputFormatExtensions[strings.ToLower(filepath.Ext(path))]
+	return format, ok
+}
+
+// inferOutputFormat picks the format for an output file: an explicit --<flag>
+// wins, otherwise the extension of path decides among the supported formats.
+// Paths without an extension keep current (the flag default).
+func inferOutputFormat(cmd *cobra.Command, flag, current, path string, supported []string) (string, error) {
+	if path == "" || cmd.Flags().Changed(flag) || filepath.Ext(path) == "" {
+		return current, nil
+	}
+	format, ok := formatFromExtension(path)
+	if !ok || !slices.Contains(supported, format) {
+		return "", fmt.Errorf("cannot infer the output format from %q (pass --%s: %s)", filepath.Ext(path), flag, strings.Join(supported, ", "))
+	}
+	return format, nil
+}
+
+// validateOutputMode rejects a malformed output.file_mode before any work is done.
+func validateOutputMode() error {
+	value := viper.GetString("output.file_mode")
+	if value == "" {
+		return nil
+	}
+	_, err := app.ParseFileMode(value)
+	return err
+}
+
+// webChatFormats are the formats accepted by --web-format (web_reader.chat_format).
+var webChatFormats = []string{"markdown", "text"}
+
+// validateWebFormat rejects an unsupported web_reader.chat_format before any work is done.
+func validateWebFormat() error {
+	value := viper.GetString("web_reader.chat_format")
+	if value == "" || slices.Contains(webChatFormats, value) {
+		return nil
+	}
+	return fmt.Errorf("invalid web format: %s (must be one of: %s)", value, strings.Join(webChatFormats, ", "))
+}
+
+// systemMergeModes are the values accepted by --system-merge (system_merge).
+var systemMergeModes = []string{app.SystemMergeReplace, app.SystemMergeAppend}
+
+// validateSystemMerge rejects an unknown --system-merge mode.
+func validateSystemMerge() error {
+	value := viper.GetString("system_merge")
+	if value == "" || slices.Contains(systemMergeModes, value) {
+		return nil
+	}
+	return fmt.Errorf("invalid system merge mode: %s (must be one of: %s)", value, strings.Join(systemMergeModes, ", "))
+}
+
 // createContext creates a context with timeout for CLI operations.
 // If timeout is 0, returns a cancelable context without timeout.
 func createContext(timeout time.Duration) (context.Context, context.CancelFunc) {
@@ -352,6 +956,8 @@ func buildClientConfig() app.ClientConfig {
 		MaxAttempts:    viper.GetInt("api.retry.max_attempts"),
 		InitialBackoff: viper.GetDuration("api.retry.initial_backoff"),
 		MaxBackoff:     viper.GetDuration("api.retry.max_backoff"),
+		MaxElapsed:     viper.GetDuration("api.retry.max_elapsed"),
+		TimeoutBackoff: viper.GetBool("api.retry.timeout_backoff"),
 	}
 
 	// Load rate limit config from viper
@@ -376,15 +982,53 @@ func buildClientConfig() app.ClientConfig {
 		Verbose:       viper.GetBool("verbose"),
 		RateLimit:     rateLimitCfg,
 		RetryConfig:   retryCfg,
+		APIVersion:    viper.GetString("api.version"),
+		SystemPrompt:  viper.GetString("system_prompt"),
+		SystemFile:    systemFilePrompt,
+		SystemMerge:   viper.GetString("system_merge"),
+		Headers:       extraHeaders,
+
+		ShowRequestSize: viper.GetBool("show_request_size"),
 	}
 }
 
+// loadExtraHeaders merges api.headers with the --headers-file lines, which
+// win. Repeated names in the file are warned about. Authorization is dropped
+// with a warning unless --override-auth, so the API key auth stays in place.
+func loadExtraHeaders() (map[string]string, error) {
+	headers := map[string]string{}
+	for name, value := range viper.GetStringMapString("api.headers") {
+		headers[http.CanonicalHeaderKey(name)] = value
+	}
+
+	if path := viper.GetString("api.headers_file"); path != "" {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read --headers-file: %w", err)
+		}
+		fileHeaders, duplicates, err := app.ParseHeaderLines(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("invalid --headers-file %s: %w", path, err)
+		}
+		for _, name := range duplicates {
+			fmt.Fprintf(os.Stderr, "⚠️  %s sets %s more than once; using the last value\n", path, name)
+		}
+		maps.Copy(headers, fileHeaders)
+	}
+
+	if _, ok := headers["Authorization"]; ok && !overrideAuth {
+		delete(headers, "Authorization")
+		fmt.Fprintln(os.Stderr, "⚠️  Ignoring the configured Authorization header; pass --override-auth to replace API key auth")
+	}
+	return headers, nil
+}
+
 // newClient creates a fully configured client with dependencies.
 // Uses default http.Client by passing nil for httpClient.
 func newClient() *app.Client {
 	cfg := buildClientConfig()
 	logger := app.NewLogger(cfg.Verbose)
-	history := app.NewFileHistoryStore("")
+	history := newHistoryStore()
 	return app.NewClient(cfg, logger, history, nil)
 }
 
@@ -400,7 +1044,7 @@ func newClientWithoutHistory() *app.Client {
 // Used when command-specific config overrides are needed.
 func newClientWithConfig(cfg app.ClientConfig) *app.Client {
 	logger := app.NewLogger(cfg.Verbose)
-	history := app.NewFileHistoryStore("")
+	history := newHistoryStore()
 	return app.NewClient(cfg, logger, history, nil)
 }
 
@@ -410,7 +1054,11 @@ func hasStdinData() bool {
 	return (stat.Mode() & os.ModeCharDevice) == 0
 }
 
+// errBinaryStdin rejects piped binary data, which would be sent as garbage text.
+var errBinaryStdin = errors.New("stdin appears to be binary; did you mean `zai audio` or `zai vision`?")
+
 // readStdin reads all data from stdin with a size limit.
+// Binary input is rejected with errBinaryStdin.
 func readStdin() (string, error) {
 	limitedReader := io.LimitReader(os.Stdin, MaxStdinSize)
 	data, err := io.ReadAll(limitedReader)
@@ -420,77 +1068,215 @@ func readStdin() (string, error) {
 	if len(data) == MaxStdinSize {
 		return "", fmt.Errorf("stdin exceeds maximum size of %d bytes", MaxStdinSize)
 	}
+	if !app.IsProbablyText(data) {
+		return "", errBinaryStdin
+	}
 	dataStr := string(data)
 	return strings.TrimSpace(dataStr), nil
 }
 
-// validateAndReadSystemFile validates the system file path and reads its content
+// validateAndReadSystemFile reads a --system file path into the system setting.
 func validateAndReadSystemFile(path string) error {
+	content, err := readSystemFile(path)
+	if err != nil {
+		return err
+	}
+	viper.Set("system", content)
+	return nil
+}
+
+// readSystemFile validates that path stays within the working directory and
+// returns the file's contents.
+func readSystemFile(path string) (string, error) {
 	// Clean the path to resolve any . or .. components
 	cleanPath := filepath.Clean(path)
 
 	// Check for path traversal attempts
 	if strings.Contains(cleanPath, "..") {
-		return fmt.Errorf("path traversal not allowed in system file path")
+		return "", fmt.Errorf("path traversal not allowed in system file path")
 	}
 
 	// Get current working directory
 	cwd, err := os.Getwd()
 	if err != nil {
-		return fmt.Errorf("failed to get current working directory: %w", err)
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
 	}
 
 	// Check if the path is within the current working directory using directory walk
 	absPath, err := filepath.Abs(cleanPath)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute path: %w", err)
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
 	}
 
 	absCwd, err := filepath.Abs(cwd)
 	if err != nil {
-		return fmt.Errorf("failed to get absolute current working directory: %w", err)
+		return "", fmt.Errorf("failed to get absolute current working directory: %w", err)
 	}
 
 	// Check if the absolute path starts with the absolute current working directory
 	if !strings.HasPrefix(absPath, absCwd) {
-		return fmt.Errorf("system file path must be within current working directory")
+		return "", fmt.Errorf("system file path must be within current working directory")
 	}
 
 	// Check if file exists and is readable
 	if _, err := os.Stat(cleanPath); err != nil {
-		return err // Return the original error (likely file not found)
+		return "", err // Return the original error (likely file not found)
 	}
 
 	// Read the file content
 	content, err := os.ReadFile(cleanPath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return "", fmt.Errorf("failed to read file: %w", err)
 	}
 
-	viper.Set("system", string(content))
-	return nil
+	return string(content), nil
+}
+
+// parseContextMessages parses the --context values in order.
+func parseContextMessages(values []string) ([]app.Message, error) {
+	var messages []app.Message
+	for _, value := range values {
+		msg, err := app.ParseContextMessage(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --context: %w", err)
+		}
+		messages = append(messages, msg)
+	}
+	return messages, nil
 }
 
 // runOneShot executes a single prompt and exits.
-func runOneShot(prompt string) error {
+func runOneShot(prompt, stdinMessage string) error {
 	cfg := NewRunConfig()
+	if cfg.JSONField != "" && !slices.Contains(oneShotJSONFields, cfg.JSONField) {
+		return fmt.Errorf("unknown --json-field %q (valid: %s)", cfg.JSONField, strings.Join(oneShotJSONFields, ", "))
+	}
+	if cfg.PipeTo != "" {
+		if _, err := app.ResolvePipeCommand(cfg.PipeTo); err != nil {
+			return err
+		}
+	}
+	if streamOutput && (cfg.JSONOutput || cfg.JSONField != "" || cfg.PipeTo != "") {
+		return fmt.Errorf("--stream cannot be combined with --json, --json-field, or --pipe-to")
+	}
+	var sweepTemps []float64
+	if temperatureSweep != "" {
+		temps, err := validateTemperatureSweep(cfg)
+		if err != nil {
+			return err
+		}
+		sweepTemps = temps
+	}
+	priorMessages, err := parseContextMessages(contextMessages)
+	if err != nil {
+		return err
+	}
 	client, opts := setupOneShotConfig(cfg)
+	if cfg.StripURLs {
+		stdinMessage = app.StripURLs(stdinMessage)
+	}
+	if stdinMessage != "" {
+		priorMessages = append(priorMessages, app.Message{Role: "user", Content: stdinMessage})
+	}
+	opts.Context = priorMessages
+
+	instructions, err := loadInstructions(cfg.InstructionsFile)
+	if err != nil {
+		return err
+	}
+	opts.Instructions = instructions
 	logConfigDetails(cfg, opts, prompt)
 
 	ctx, cancel := createContext(5 * time.Minute)
 	defer cancel()
 
 	prompt = augmentWithWebSearch(ctx, client, cfg, prompt)
-	response, err := callChatAPI(ctx, client, prompt, opts)
+	if sweepTemps != nil {
+		return runTemperatureSweep(ctx, client, prompt, opts, sweepTemps, cfg)
+	}
+	if streamOutput {
+		return streamOneShot(ctx, client, prompt, opts, cfg.ShowUsage)
+	}
+	response, usage, err := callChatAPI(ctx, client, prompt, opts)
 	if err != nil {
 		return fmt.Errorf("failed to get response: %w", err)
 	}
+	// JSON output carries usage in its own field
+	if cfg.ShowUsage && !cfg.JSONOutput && cfg.JSONField == "" {
+		defer fmt.Fprintln(os.Stderr, newUsageReport(usage))
+	}
 
-	formatOutput(response, cfg, prompt, opts)
+	if cfg.PipeTo == "" {
+		formatOutput(os.Stdout, response, usage, cfg, prompt, opts)
+		return nil
+	}
 
+	var out bytes.Buffer
+	formatOutput(&out, response, usage, cfg, prompt, opts)
+	return app.PipeThrough(cfg.PipeTo, out.Bytes(), os.Stdout, os.Stderr)
+}
+
+// streamOneShot prints the response to stdout as it arrives.
+func streamOneShot(ctx context.Context, client *app.Client, prompt string, opts app.ChatOptions, showUsage bool) error {
+	var usage app.Usage
+	opts.OnUsage = func(u app.Usage) { usage = u }
+	response, err := client.ChatStream(ctx, prompt, opts, func(delta string) {
+		fmt.Print(delta)
+	})
+	if response != "" && !strings.HasSuffix(response, "\n") {
+		fmt.Println()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get response: %w", err)
+	}
+	if showUsage {
+		fmt.Fprintln(os.Stderr, newUsageReport(usage))
+	}
 	return nil
 }
 
+// usageReport is the --usage summary of one response. Cost is set only
+// when pricing.cost_per_1k_prompt or pricing.cost_per_1k_completion is configured.
+type usageReport struct {
+	PromptTokens     int      `json:"prompt_tokens"`
+	CompletionTokens int      `json:"completion_tokens"`
+	TotalTokens      int      `json:"total_tokens"`
+	Cost             *float64 `json:"estimated_cost,omitempty"`
+}
+
+// newUsageReport builds the --usage summary, estimating cost from the pricing config.
+func newUsageReport(u app.Usage) usageReport {
+	r := usageReport{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+	perPrompt := viper.GetFloat64("pricing.cost_per_1k_prompt")
+	perCompletion := viper.GetFloat64("pricing.cost_per_1k_completion")
+	if perPrompt > 0 || perCompletion > 0 {
+		cost := u.Cost(perPrompt, perCompletion)
+		r.Cost = &cost
+	}
+	return r
+}
+
+// String formats the report as "prompt=X completion=Y total=Z [cost=$N]".
+func (r usageReport) String() string {
+	s := fmt.Sprintf("prompt=%d completion=%d total=%d", r.PromptTokens, r.CompletionTokens, r.TotalTokens)
+	if r.Cost != nil {
+		s += fmt.Sprintf(" cost=$%.6f", *r.Cost)
+	}
+	return s
+}
+
+// loadInstructions reads the shared instructions file, if one is configured.
+func loadInstructions(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to read instructions file: %w", err)
+	}
+	return string(data), nil
+}
+
 // setupOneShotConfig initializes configuration and creates client with options
 func setupOneShotConfig(cfg RunConfig) (*app.Client, app.ChatOptions) {
 	client := newClient()
@@ -498,9 +1284,37 @@ func setupOneShotConfig(cfg RunConfig) (*app.Client, app.ChatOptions) {
 	opts.FilePath = cfg.FilePath
 	opts.Think = cfg.Think
 	opts.SystemPrompt = cfg.System
+	opts.CompressFile = cfg.Compress
+	opts.CompressModel = cfg.CompressModel
+	opts.CachePrefix = cfg.CachePrefix
+	opts.RetryOnEmpty = cfg.RetryOnEmpty
+	opts.WebFormat = cfg.WebFormat
+	opts.StripURLs = cfg.StripURLs
+	applySamplingFlags(&opts)
 	return client, opts
 }
 
+// defaultDeterministicSeed is the seed --deterministic sends when --seed is not given.
+const defaultDeterministicSeed = 42
+
+// applySamplingFlags applies --seed and --deterministic to chat options.
+// Determinism is best effort: the API may ignore the seed, so a notice is printed.
+func applySamplingFlags(opts *app.ChatOptions) {
+	if s := viper.GetInt("seed"); s != 0 {
+		opts.Seed = app.IntPtr(s)
+	}
+	if !viper.GetBool("deterministic") {
+		return
+	}
+	opts.Temperature = app.Float64Ptr(0)
+	opts.TopP = app.Float64Ptr(1)
+	if opts.Seed == nil {
+		opts.Seed = app.IntPtr(defaultDeterministicSeed)
+	}
+	fmt.Fprintln(os.Stderr, theme.Dim.Render(fmt.Sprintf(
+		"Deterministic mode (temperature 0, top-p 1, seed %d): identical output depends on API support", *opts.Seed)))
+}
+
 // logConfigDetails logs configuration details if verbose mode is enabled
 func logConfigDetails(cfg RunConfig, opts app.ChatOptions, prompt string) {
 	if cfg.Verbose {
@@ -511,6 +1325,9 @@ func logConfigDetails(cfg RunConfig, opts app.ChatOptions, prompt string) {
 		if opts.SystemPrompt != "" {
 			fmt.Fprintf(os.Stderr, "System prompt: %s\n", opts.SystemPrompt)
 		}
+		if cfg.InstructionsFile != "" {
+			fmt.Fprintf(os.Stderr, "Instructions: %s\n", cfg.InstructionsFile)
+		}
 	}
 }
 
@@ -537,7 +1354,7 @@ func augmentWithWebSearch(ctx context.Context, client *app.Client, cfg RunConfig
 	}
 
 	if len(results.SearchResult) > 0 {
-		searchContext := app.FormatSearchForContext(results.SearchResult)
+		searchContext := app.FormatSearchForContextWithBudget(results.SearchResult, cfg.SearchBudget)
 		var b strings.Builder
 		b.WriteString(searchContext)
 		b.WriteString("\n\nUser question: ")
@@ -554,31 +1371,62 @@ func augmentWithWebSearch(ctx context.Context, client *app.Client, cfg RunConfig
 	return prompt
 }
 
-// callChatAPI makes the chat API call and returns the response
-func callChatAPI(ctx context.Context, client *app.Client, prompt string, opts app.ChatOptions) (string, error) {
-	return client.Chat(ctx, prompt, opts)
+// callChatAPI makes the chat API call and returns the response and its token usage
+func callChatAPI(ctx context.Context, client *app.Client, prompt string, opts app.ChatOptions) (string, app.Usage, error) {
+	return client.ChatWithUsage(ctx, prompt, opts)
 }
 
+// marshalJSON encodes JSON output: indented by default, a single line with
+// --json-pretty=false for logs and line-based processing.
+func marshalJSON(v interface{}) ([]byte, error) {
+	if viper.GetBool("json_pretty") {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return json.Marshal(v)
+}
+
+// oneShotJSONFields are the keys of the one-shot --json output, selectable with --json-field.
+var oneShotJSONFields = []string{"prompt", "response", "model", "file", "think", "search", "timestamp", "usage"}
+
 // formatOutput formats and prints the response according to configuration
-func formatOutput(response string, cfg RunConfig, prompt string, opts app.ChatOptions) {
-	if cfg.JSONOutput {
-		output := map[string]interface{}{
-			"prompt":    prompt,
-			"response":  response,
-			"model":     viper.GetString("api.model"),
-			"file":      opts.FilePath,
-			"think":     opts.Think,
-			"search":    cfg.Search,
-			"timestamp": time.Now().Format(time.RFC3339),
-		}
-
-		data, err := json.MarshalIndent(output, "", "  ")
+func formatOutput(w io.Writer, response string, usage app.Usage, cfg RunConfig, prompt string, opts app.ChatOptions) {
+	if !cfg.JSONOutput && cfg.JSONField == "" {
+		fmt.Fprintln(w, response)
+		return
+	}
+
+	output := map[string]interface{}{
+		"prompt":    prompt,
+		"response":  response,
+		"model":     viper.GetString("api.model"),
+		"file":      opts.FilePath,
+		"think":     opts.Think,
+		"search":    cfg.Search,
+		"timestamp": time.Now().Format(time.RFC3339),
+	}
+	if cfg.ShowUsage || cfg.JSONField == "usage" {
+		output["usage"] = newUsageReport(usage)
+	}
+
+	// A single field prints raw for strings, so pipelines don't need jq
+	if cfg.JSONField != "" {
+		if str, ok := output[cfg.JSONField].(string); ok {
+			fmt.Fprintln(w, str)
+			return
+		}
+		data, err := json.Marshal(output[cfg.JSONField])
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "failed to marshal JSON: %v\n", err)
 			return
 		}
-		fmt.Println(string(data))
-	} else {
-		fmt.Println(response)
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	data, err := marshalJSON(output)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to marshal JSON: %v\n", err)
+		return
 	}
+	fmt.Fprintln(w, string(data))
 }
@@ -2,12 +2,14 @@ package cmd
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -22,8 +24,24 @@ var (
 	searchRecency string
 	searchDomain  string
 	searchFormat  string
+	searchChars   int
+	searchFull    bool
+	searchOutput  string
+	searchExpand  int
+	searchNoCache bool
+	searchRefresh bool
+	searchNews    bool
 )
 
+// searchFormats are the output formats accepted by --format ("text" is an alias for table).
+var searchFormats = []string{"table", "detailed", "json", "jsonl", "csv", "text"}
+
+// maxExpandedQueries caps --expand; each related query is a separate search.
+const maxExpandedQueries = 5
+
+// searchExpandWorkers bounds concurrent searches for --expand.
+const searchExpandWorkers = 3
+
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search the web using Z.AI search engine",
@@ -35,7 +53,27 @@ Examples:
   zai search "golang best practices"
   echo "golang best practices" | zai search
   zai search "latest AI news" -c 5 -r oneWeek
-  zai search "site:github.com golang" -d github.com`,
+  zai search "site:github.com golang" -d github.com
+  zai search "golang generics" -o csv --content-chars 200 > results.csv
+  zai search "rfc 9110 caching" -o detailed --full-content
+  zai search "vector databases" -o jsonl --output data/results.jsonl
+  zai search "wasm on the server" --expand 3  # Also search 3 related queries
+  zai search "go 1.25 release" --refresh      # Skip the cached answer and re-cache
+  zai search "chip export rules" --news       # Past week, newest first
+
+Detailed output trims each snippet to 300 characters so a page of results
+stays readable. --full-content prints snippets in full, which can be long;
+JSON output always carries the untruncated content.
+
+Results are cached for web_search.cache_ttl (default 1h), keyed by the query,
+count, recency and domain. --no-cache bypasses the cache entirely; --refresh
+ignores a cached answer but stores the new one.
+
+--news limits results to the past week (unless --recency is given), drops
+results without a publish date, and sorts the rest newest first.
+
+--expand asks the chat model for related queries, searches them all
+concurrently (using the search cache), and merges results, dropping duplicate links.`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runSearch,
 }
@@ -46,7 +84,15 @@ func init() {
 	searchCmd.Flags().IntVarP(&searchCount, "count", "c", 0, "Number of results (1-50)")
 	searchCmd.Flags().StringVarP(&searchRecency, "recency", "r", "", "Time filter: oneDay, oneWeek, oneMonth, oneYear, noLimit")
 	searchCmd.Flags().StringVarP(&searchDomain, "domain", "d", "", "Limit to specific domain")
-	searchCmd.Flags().StringVarP(&searchFormat, "format", "o", "table", "Output format: table, detailed, json")
+	searchCmd.Flags().StringVarP(&searchFormat, "format", "o", "table", "Output format: table, detailed, json, jsonl, csv")
+	_ = searchCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(searchFormats, cobra.ShellCompDirectiveNoFileComp))
+	searchCmd.Flags().StringVar(&searchOutput, "output", "", "Write formatted results to this file instead of stdout (format follows the extension unless -o is given)")
+	searchCmd.Flags().BoolVar(&searchFull, "full-content", false, "Show full snippets in detailed output instead of 300 characters (can be long)")
+	searchCmd.Flags().IntVar(&searchExpand, "expand", 0, fmt.Sprintf("Also search N model-generated related queries (max %d) and merge the results", maxExpandedQueries))
+	searchCmd.Flags().IntVar(&searchChars, "content-chars", 0, "Truncate content to this many characters in csv output (0 = full)")
+	searchCmd.Flags().BoolVar(&searchNoCache, "no-cache", false, "Neither read nor write the search cache")
+	searchCmd.Flags().BoolVar(&searchNews, "news", false, "News mode: past week unless --recency is set, dated results only, newest first")
+	searchCmd.Flags().BoolVar(&searchRefresh, "refresh", false, "Ignore cached results and overwrite them with a fresh search")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
@@ -79,12 +125,25 @@ func runSearch(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("search query is required")
 	}
 
+	// Infer the format from the --output extension unless --format was given
+	format, err := inferOutputFormat(cmd, "format", searchFormat, searchOutput, searchFormats)
+	if err != nil {
+		return err
+	}
+	if format == "text" {
+		format = "table" // plain-text table is the text format
+	}
+	searchFormat = format
+
 	// Validate format
 	validFormats := map[string]bool{
-		"table": true, "detailed": true, "json": true,
+		"table": true, "detailed": true, "json": true, "jsonl": true, "csv": true,
 	}
 	if !validFormats[searchFormat] {
-		return fmt.Errorf("invalid format: %s (must be table, detailed, or json)", searchFormat)
+		return fmt.Errorf("invalid format: %s (must be table, detailed, json, jsonl, or csv)", searchFormat)
+	}
+	if searchExpand < 0 || searchExpand > maxExpandedQueries {
+		return fmt.Errorf("--expand must be between 0 and %d", maxExpandedQueries)
 	}
 
 	// Prepare search options
@@ -98,17 +157,22 @@ func runSearch(cmd *cobra.Command, args []string) error {
 	if opts.Count == 0 {
 		opts.Count = cfg.WebSearch.DefaultCount
 	}
+	if opts.RecencyFilter == "" && searchNews {
+		opts.RecencyFilter = "oneWeek"
+	}
 	if opts.RecencyFilter == "" {
 		opts.RecencyFilter = cfg.WebSearch.DefaultRecency
 	}
 
 	// Create client using factory with custom timeout
 	client := newClientWithConfig(app.ClientConfig{
-		APIKey:  cfg.API.Key,
-		BaseURL: cfg.API.BaseURL,
-		Model:   cfg.API.Model,
-		Timeout: time.Duration(cfg.WebSearch.Timeout) * time.Second,
-		Verbose: viper.GetBool("verbose"),
+		APIKey:     cfg.API.Key,
+		BaseURL:    cfg.API.BaseURL,
+		Model:      cfg.API.Model,
+		Timeout:    time.Duration(cfg.WebSearch.Timeout) * time.Second,
+		Verbose:    viper.GetBool("verbose"),
+		APIVersion: cfg.API.Version,
+		Headers:    extraHeaders,
 	})
 
 	// Set context with timeout
@@ -117,37 +181,180 @@ func runSearch(cmd *cobra.Command, args []string) error {
 
 	// Perform search
 	start := time.Now()
-	resp, err := client.SearchWeb(ctx, query, opts)
-	if err != nil {
-		return fmt.Errorf("search failed: %w", err)
+	var results []app.SearchResult
+	if searchExpand > 0 {
+		results, err = runExpandedSearch(client, query, opts, cfg.WebSearch)
+		if err != nil {
+			return err
+		}
+	} else {
+		var cached bool
+		results, cached, err = cachedSearch(ctx, client, newSearchCache(cfg.WebSearch), query, opts, cfg.WebSearch.CacheTTL)
+		if err != nil {
+			return fmt.Errorf("search failed: %w", err)
+		}
+		if cached && viper.GetBool("verbose") {
+			fmt.Fprintln(os.Stderr, theme.Dim.Render("(cached)"))
+		}
+	}
+
+	if searchNews {
+		var undated int
+		results, undated = app.SortByPublishDate(results, time.Now())
+		fmt.Fprintf(os.Stderr, "📰 %d dated results, newest first (%d undated dropped)\n", len(results), undated)
 	}
 
 	duration := time.Since(start)
 
 	// Format and display results
 	// Use JSON format if either --json global flag or --format json is specified
-	format := searchFormat
+	format = searchFormat
 	if viper.GetBool("json") {
 		format = "json"
 	}
 
-	output, err := formatSearchOutput(resp.SearchResult, format, query, duration, viper.GetBool("verbose"))
+	output, err := formatSearchOutput(results, format, query, duration, viper.GetBool("verbose"))
 	if err != nil {
 		return fmt.Errorf("failed to format output: %w", err)
 	}
 
+	if searchOutput != "" {
+		if err := app.WriteFileAtomic(searchOutput, []byte(output), outputFileMode(0644)); err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %d results to %s\n", len(results), searchOutput)
+		return nil
+	}
+
 	fmt.Print(output)
 
 	return nil
 }
 
+// runExpandedSearch searches query plus up to searchExpand related queries
+// from the chat model, and merges the results by link. If expansion fails,
+// only the original query is searched.
+func runExpandedSearch(client *app.Client, query string, opts app.SearchOptions, cfg config.WebSearchConfig) ([]app.SearchResult, error) {
+	expandCtx, cancel := createContext(2 * time.Minute)
+	related, err := expandSearchQuery(expandCtx, client, query, searchExpand)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Query expansion failed, searching only the original query: %v\n", err)
+	}
+
+	queries := append([]string{query}, related...)
+	fmt.Fprintf(os.Stderr, "Searching %d queries:\n", len(queries))
+	for _, q := range queries {
+		fmt.Fprintf(os.Stderr, "  - %s\n", q)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Timeout)*time.Second)
+	defer cancel()
+	lists, errs := searchParallel(ctx, client, queries, opts, newSearchCache(cfg), cfg.CacheTTL)
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "⚠️  Search failed for %q: %v\n", queries[i], err)
+		}
+	}
+	if failed == len(queries) {
+		return nil, fmt.Errorf("search failed: %w", errs[0])
+	}
+	return app.MergeSearchResults(lists...), nil
+}
+
+// expandSearchQuery asks the chat model for n related search queries.
+func expandSearchQuery(ctx context.Context, client *app.Client, query string, n int) ([]string, error) {
+	prompt := fmt.Sprintf(`Write %d web search queries related to the query below that would find different, complementary results (synonyms, subtopics, alternative phrasings).
+Output only the queries, one per line, with no numbering or commentary.
+
+Query: %s`, n, query)
+
+	opts := app.ChatOptions{Temperature: app.Float64Ptr(0.7), MaxTokens: app.IntPtr(300)}
+	reply, err := client.Chat(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Drop echoes of the original query
+	var related []string
+	for _, q := range app.ParseQueryList(reply, n+1) {
+		if !strings.EqualFold(q, query) && len(related) < n {
+			related = append(related, q)
+		}
+	}
+	return related, nil
+}
+
+// newSearchCache returns the search cache, or nil when it is disabled
+// in config or bypassed with --no-cache.
+func newSearchCache(cfg config.WebSearchConfig) app.SearchCache {
+	if !cfg.CacheEnabled || searchNoCache {
+		return nil
+	}
+	return app.NewFileSearchCache(cfg.CacheDir)
+}
+
+// cachedSearch returns cached results for query when present (unless
+// --refresh), otherwise searches and stores the results for ttl.
+// cached reports a cache hit; cache may be nil.
+func cachedSearch(ctx context.Context, client *app.Client, cache app.SearchCache, query string, opts app.SearchOptions, ttl time.Duration) (results []app.SearchResult, cached bool, err error) {
+	if cache != nil && !searchRefresh {
+		if hit, ok := cache.Get(query, opts); ok {
+			return hit, true, nil
+		}
+	}
+	resp, err := client.SearchWeb(ctx, query, opts)
+	if err != nil {
+		return nil, false, err
+	}
+	if cache != nil {
+		_ = cache.Set(query, opts, resp.SearchResult, ttl) // best effort
+	}
+	return resp.SearchResult, false, nil
+}
+
+// searchParallel runs one search per query with a small worker pool.
+// Results and errors are indexed like queries; cache may be nil.
+func searchParallel(ctx context.Context, client *app.Client, queries []string, opts app.SearchOptions, cache app.SearchCache, ttl time.Duration) ([][]app.SearchResult, []error) {
+	lists := make([][]app.SearchResult, len(queries))
+	errs := make([]error, len(queries))
+	jobs := make(chan int, len(queries))
+
+	var wg sync.WaitGroup
+	for w := 0; w < min(searchExpandWorkers, len(queries)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				lists[idx], _, errs[idx] = cachedSearch(ctx, client, cache, queries[idx], opts, ttl)
+			}
+		}()
+	}
+
+	indices := make([]int, len(queries))
+	for i := range indices {
+		indices[i] = i
+	}
+	feedJobs(ctx, jobs, indices, viper.GetDuration("throttle"))
+	wg.Wait()
+
+	return lists, errs
+}
+
 // formatSearchOutput formats search results according to the specified format
 func formatSearchOutput(results []app.SearchResult, format, query string, duration time.Duration, verbose bool) (string, error) {
 	switch format {
 	case "json":
 		return formatSearchJSON(results, query, duration)
+	case "jsonl":
+		return formatSearchJSONL(results)
 	case "detailed":
-		return formatSearchDetailed(results, query, duration)
+		return formatSearchDetailed(results, query, duration, searchFull)
+	case "csv":
+		return formatSearchCSV(results, searchChars)
 	default: // table
 		return formatSearchTable(results, query, duration, verbose)
 	}
@@ -222,8 +429,9 @@ func formatSearchTable(results []app.SearchResult, query string, duration time.D
 	return sb.String(), nil
 }
 
-// formatSearchDetailed formats results with full details
-func formatSearchDetailed(results []app.SearchResult, query string, duration time.Duration) (string, error) {
+// formatSearchDetailed formats results with full details.
+// Snippets are flattened and cut to 300 characters unless fullContent is set.
+func formatSearchDetailed(results []app.SearchResult, query string, duration time.Duration, fullContent bool) (string, error) {
 	var sb strings.Builder
 
 	// Header
@@ -249,9 +457,14 @@ func formatSearchDetailed(results []app.SearchResult, query string, duration tim
 		sb.WriteString("\n")
 
 		// Content
-		content := strings.ReplaceAll(result.Content, "\n", " ")
-		if len(content) > 300 {
-			content = content[:300] + "..."
+		var content string
+		if fullContent {
+			content = strings.ReplaceAll(strings.TrimSpace(result.Content), "\n", "\n   ")
+		} else {
+			content = strings.ReplaceAll(result.Content, "\n", " ")
+			if len(content) > 300 {
+				content = content[:300] + "..."
+			}
 		}
 		sb.WriteString("   ")
 		sb.WriteString(content)
@@ -263,6 +476,20 @@ func formatSearchDetailed(results []app.SearchResult, query string, duration tim
 	return sb.String(), nil
 }
 
+// formatSearchJSONL formats results as one JSON object per line.
+func formatSearchJSONL(results []app.SearchResult) (string, error) {
+	var sb strings.Builder
+	for _, result := range results {
+		data, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		sb.Write(data)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
+
 // formatSearchJSON formats results as JSON
 func formatSearchJSON(results []app.SearchResult, query string, duration time.Duration) (string, error) {
 	// Create a structured output
@@ -275,7 +502,7 @@ func formatSearchJSON(results []app.SearchResult, query string, duration time.Du
 	}
 
 	// Convert to JSON
-	data, err := json.MarshalIndent(output, "", "  ")
+	data, err := marshalJSON(output)
 	if err != nil {
 		return "", err
 	}
@@ -283,6 +510,31 @@ func formatSearchJSON(results []app.SearchResult, query string, duration time.Du
 	return string(data), nil
 }
 
+// formatSearchCSV formats results as RFC 4180 CSV with a header row.
+// Content is cut to contentChars runes when contentChars > 0.
+func formatSearchCSV(results []app.SearchResult, contentChars int) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	w.UseCRLF = true
+
+	if err := w.Write([]string{"title", "link", "domain", "publish_date", "content"}); err != nil {
+		return "", err
+	}
+	for _, result := range results {
+		content := result.Content
+		if runes := []rune(content); contentChars > 0 && len(runes) > contentChars {
+			content = string(runes[:contentChars])
+		}
+		record := []string{result.Title, result.Link, extractDomain(result.Link), result.PublishDate, content}
+		if err := w.Write(record); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+
+	return sb.String(), w.Error()
+}
+
 // extractDomain extracts domain from URL using net/url stdlib.
 // Handles edge cases like ports, IPv6, and malformed URLs.
 func extractDomain(rawURL string) string {
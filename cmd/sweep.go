@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/dotcommander/zai/internal/app"
+)
+
+// maxSweepTemperatures caps --temperature-sweep, since every value is a full request.
+const maxSweepTemperatures = 5
+
+// sweepResult is one --temperature-sweep run.
+type sweepResult struct {
+	Temperature float64      `json:"temperature"`
+	Response    string       `json:"response,omitempty"`
+	Usage       *usageReport `json:"usage,omitempty"`
+	Error       string       `json:"error,omitempty"`
+}
+
+// parseTemperatureSweep parses a comma-separated list of temperatures in [0, 1].
+func parseTemperatureSweep(s string) ([]float64, error) {
+	var temps []float64
+	for _, field := range strings.Split(s, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		t, err := strconv.ParseFloat(field, 64)
+		if err != nil || t < 0 || t > 1 {
+			return nil, fmt.Errorf("invalid --temperature-sweep value %q (must be between 0 and 1)", field)
+		}
+		temps = append(temps, t)
+	}
+	if len(temps) == 0 {
+		return nil, fmt.Errorf("--temperature-sweep needs at least one temperature, e.g. 0.2,0.6,1.0")
+	}
+	if len(temps) > maxSweepTemperatures {
+		return nil, fmt.Errorf("--temperature-sweep accepts at most %d temperatures, got %d", maxSweepTemperatures, len(temps))
+	}
+	return temps, nil
+}
+
+// validateTemperatureSweep rejects flags that conflict with a sweep.
+func validateTemperatureSweep(cfg RunConfig) ([]float64, error) {
+	if streamOutput || cfg.JSONField != "" || cfg.PipeTo != "" {
+		return nil, fmt.Errorf("--temperature-sweep cannot be combined with --stream, --json-field, or --pipe-to")
+	}
+	if viper.GetBool("deterministic") {
+		return nil, fmt.Errorf("--temperature-sweep cannot be combined with --deterministic")
+	}
+	return parseTemperatureSweep(temperatureSweep)
+}
+
+// runTemperatureSweep sends prompt once per temperature, concurrently, and
+// prints the responses in the order the temperatures were given.
+func runTemperatureSweep(ctx context.Context, client *app.Client, prompt string, opts app.ChatOptions, temps []float64, cfg RunConfig) error {
+	fmt.Fprintln(os.Stderr, theme.Dim.Render(fmt.Sprintf(
+		"Temperature sweep: %d requests, so token usage is %dx a single run", len(temps), len(temps))))
+
+	results := make([]sweepResult, len(temps))
+	for i, t := range temps {
+		results[i].Temperature = t
+	}
+	jobs := make(chan int, len(temps))
+
+	var wg sync.WaitGroup
+	for range temps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				runOpts := opts
+				runOpts.Temperature = app.Float64Ptr(temps[idx])
+				response, usage, err := callChatAPI(ctx, client, prompt, runOpts)
+				if err != nil {
+					results[idx].Error = err.Error()
+					continue
+				}
+				report := newUsageReport(usage)
+				results[idx].Response = response
+				results[idx].Usage = &report
+			}
+		}()
+	}
+
+	indices := make([]int, len(temps))
+	for i := range indices {
+		indices[i] = i
+	}
+	feedJobs(ctx, jobs, indices, viper.GetDuration("throttle"))
+	wg.Wait()
+
+	failed := 0
+	for i := range results {
+		if results[i].Error == "" && results[i].Usage == nil {
+			// Never sent: the context was canceled first
+			results[i].Error = fmt.Sprintf("not run: %v", ctx.Err())
+		}
+		if results[i].Error != "" {
+			failed++
+		}
+	}
+	printSweepResults(results, prompt, cfg)
+	return batchError(len(results)-failed, failed)
+}
+
+// printSweepResults prints each response under its temperature, or all of
+// them as one JSON document with --json.
+func printSweepResults(results []sweepResult, prompt string, cfg RunConfig) {
+	if cfg.JSONOutput {
+		data, err := marshalJSON(map[string]interface{}{
+			"prompt":    prompt,
+			"model":     viper.GetString("api.model"),
+			"results":   results,
+			"timestamp": time.Now().Format(time.RFC3339),
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to marshal JSON: %v\n", err)
+			return
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	for i, r := range results {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Println(theme.Title.Render(fmt.Sprintf(" temperature %g ", r.Temperature)))
+		if r.Error != "" {
+			fmt.Println(theme.ErrorText.Render("Error: " + r.Error))
+			continue
+		}
+		fmt.Println(r.Response)
+		if cfg.ShowUsage {
+			fmt.Fprintf(os.Stderr, "temperature %g: %s\n", r.Temperature, r.Usage)
+		}
+	}
+}
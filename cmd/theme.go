@@ -1,6 +1,9 @@
 package cmd
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+)
 
 // Theme holds all lipgloss styles for consistent UI across commands.
 // Centralizes color definitions and style configuration.
@@ -130,3 +133,23 @@ var theme = DefaultTheme()
 // SpinnerFrames contains the Braille animation frames for loading spinners.
 // Used consistently across chat.go and video.go for visual feedback.
 var SpinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// SpinnerFrameSets maps ui.spinner names to animation frames.
+// "none" has no frames: callers show a static message instead.
+var SpinnerFrameSets = map[string][]string{
+	"braille": SpinnerFrames,
+	"dots":    {".  ", ".. ", "...", " ..", "  .", "   "},
+	"line":    {"-", "\\", "|", "/"},
+	"arc":     {"◜", "◠", "◝", "◞", "◡", "◟"},
+	"none":    nil,
+}
+
+// spinnerFrames returns the frames selected by ui.spinner.
+// Unknown names fall back to Braille.
+func spinnerFrames() []string {
+	frames, ok := SpinnerFrameSets[viper.GetString("ui.spinner")]
+	if !ok {
+		return SpinnerFrames
+	}
+	return frames
+}
@@ -23,6 +23,9 @@ var (
 	videoRequestID   string
 	videoImageURLs   []string
 	videoPollTimeout time.Duration
+
+	videoOutputDir      string
+	videoOrganizeByDate bool
 )
 
 var videoCmd = &cobra.Command{
@@ -57,8 +60,11 @@ func init() {
 	videoCmd.Flags().IntVar(&videoDuration, "duration", 5, "Duration: 5 or 10 seconds")
 	videoCmd.Flags().BoolVar(&videoWithAudio, "with-audio", false, "Generate AI sound effects")
 	videoCmd.Flags().StringVarP(&videoOutput, "output", "o", "", "Save video to file path")
+	videoCmd.Flags().StringVar(&videoOutputDir, "output-dir", "", "Directory for auto-named videos (default: media.output_dir or current dir)")
+	videoCmd.Flags().BoolVar(&videoOrganizeByDate, "organize-by-date", false, "Nest auto-named videos under YYYY/MM/DD in the output dir")
 	videoCmd.Flags().BoolVarP(&videoShow, "show", "S", false, "Open video with default player after generation")
 	videoCmd.Flags().StringVarP(&videoModel, "model", "m", "", "Override default video model")
+	_ = videoCmd.RegisterFlagCompletionFunc("model", completeModelIDs)
 	videoCmd.Flags().StringVar(&videoUserID, "user-id", "", "User ID for analytics")
 	videoCmd.Flags().StringVar(&videoRequestID, "request-id", "", "Unique request ID")
 	videoCmd.Flags().StringArrayVarP(&videoImageURLs, "file", "f", []string{}, "Image URL(s) for image-to-video or first/last frame mode (can specify 1 or 2)")
@@ -110,6 +116,7 @@ func pollForResult(ctx context.Context, client *app.Client, taskID string) (*app
 	ticker := time.NewTicker(5 * time.Second)
 	defer ticker.Stop()
 
+	frames := spinnerFrames()
 	spinnerIdx := 0
 	startTime := time.Now()
 
@@ -124,18 +131,21 @@ func pollForResult(ctx context.Context, client *app.Client, taskID string) (*app
 				return nil, err
 			}
 
-			// Update spinner
-			spinner := SpinnerFrames[spinnerIdx%len(SpinnerFrames)]
-			spinnerIdx++
+			// Update spinner (ui.spinner "none" leaves it blank)
+			spinner := ""
+			if len(frames) > 0 {
+				spinner = frames[spinnerIdx%len(frames)] + " "
+				spinnerIdx++
+			}
 
 			switch result.TaskStatus {
 			case "SUCCESS":
-				fmt.Printf("\r%s ✅ Video generation complete! (%.1fs elapsed)\n", spinner, elapsed.Seconds())
+				fmt.Printf("\r%s✅ Video generation complete! (%.1fs elapsed)\n", spinner, elapsed.Seconds())
 				return result, nil
 			case "FAIL":
 				return nil, fmt.Errorf("video generation failed on server")
 			case "PROCESSING":
-				fmt.Printf("\r%s ⏳ Processing... (%.1fs elapsed)   ", spinner, elapsed.Seconds())
+				fmt.Printf("\r%s⏳ Processing... (%.1fs elapsed)   ", spinner, elapsed.Seconds())
 			}
 		}
 	}
@@ -155,17 +165,21 @@ func displayVideoResult(result *app.VideoResultResponse, prompt string) error {
 		fmt.Printf("🖼️  Cover: %s\n", videoData.CoverImageURL)
 	}
 
+	entry := app.NewVideoHistoryEntry(prompt, videoData, result.Model)
+	if err := newHistoryStore().Save(entry); err != nil {
+		fmt.Printf("⚠️  Warning: Failed to save to history: %v\n", err)
+	}
+
 	// Determine output path
 	outputPath := videoOutput
 	if outputPath == "" {
-		timestamp := time.Now().Format("20060102-150405")
-		ext := ".mp4"
-		outputPath = fmt.Sprintf("zai-video-%s%s", timestamp, ext)
+		outputPath = autoOutputPath("video", prompt, ".mp4", videoOutputDir, videoOrganizeByDate)
 	}
 
 	// Save video to disk
 	fmt.Printf("💾 Downloading to: %s\n", outputPath)
 	downloader := app.NewMediaDownloader(nil)
+	downloader.FileMode = outputFileMode(0)
 	downloadResult := downloader.Download(videoData.URL, outputPath)
 	if downloadResult.Error != nil {
 		return fmt.Errorf("failed to save video: %w", downloadResult.Error)
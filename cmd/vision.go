@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
 
 	"github.com/dotcommander/zai/internal/app"
 	"github.com/dotcommander/zai/internal/app/utils"
@@ -16,6 +18,14 @@ var (
 	visionPrompt string
 	visionModel  string
 	visionTemp   float64
+	visionDetail string
+	visionStream bool
+
+	visionExtractTables bool
+	visionTableFormat   string
+	visionMaxImageBytes int64
+	visionAutoResize    bool
+	visionJSON          bool
 )
 
 var visionCmd = &cobra.Command{
@@ -29,7 +39,14 @@ Examples:
   zai vision -f photo.jpg                     # Describe image
   zai vision -f screenshot.png "What text?"   # Extract text
   zai vision -f https://example.com/img.jpg   # Analyze URL
-  zai vision -f chart.png -p "Explain trends" # With prompt flag`,
+  zai vision -f chart.png -p "Explain trends" # With prompt flag
+  zai vision -f receipt.jpg --detail high     # Read fine print
+  zai vision -f huge-photo.jpg --auto-resize  # Downscale if over the size limit
+  zai vision -f dense-doc.png --stream        # Show analysis as it arrives
+  zai vision -f sheet.png --extract-tables    # Tables as Markdown
+  zai vision -f sheet.png --extract-tables --table-format csv > out.csv
+  zai vision -f photo.jpg --json | jq -r .analysis
+  zai vision models                           # List vision models`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if visionFile == "" {
@@ -39,6 +56,9 @@ Examples:
 		if len(args) > 0 {
 			prompt = args[0]
 		}
+		if visionExtractTables {
+			return runVisionTables(cmd, visionFile, prompt)
+		}
 		return runVision(visionFile, prompt)
 	},
 }
@@ -85,11 +105,68 @@ func encodeLocalImage(imagePath string, fileReader utils.FileReader) (string, er
 	return utils.EncodeBytesToDataURI(data, mimeType), nil
 }
 
+// visionImageLimit returns the upload size limit from --max-image-bytes or config.
+func visionImageLimit() int64 {
+	if visionMaxImageBytes > 0 {
+		return visionMaxImageBytes
+	}
+	return viper.GetInt64("vision.max_image_bytes")
+}
+
+// encodeVisionImage encodes a local image as a data URI, enforcing maxBytes.
+// Oversized images are downscaled when autoResize is set, otherwise rejected.
+func encodeVisionImage(imagePath string, fileReader utils.FileReader, maxBytes int64, autoResize bool) (string, error) {
+	data, err := fileReader.ReadFile(imagePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image file: %w", err)
+	}
+
+	mimeType, err := utils.DetectImageMimeType(imagePath)
+	if err != nil {
+		return "", err
+	}
+
+	if maxBytes > 0 && int64(len(data)) > maxBytes {
+		if !autoResize {
+			return "", fmt.Errorf("image is %.1f MB, over the %.1f MB limit: downscale it, pass --auto-resize, or raise --max-image-bytes",
+				float64(len(data))/(1024*1024), float64(maxBytes)/(1024*1024))
+		}
+		original := len(data)
+		data, mimeType, err = app.ShrinkImage(data, maxBytes)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(os.Stderr, "Resized image from %d to %d bytes\n", original, len(data))
+	}
+
+	return utils.EncodeBytesToDataURI(data, mimeType), nil
+}
+
+var visionModelsCmd = &cobra.Command{
+	Use:   "models",
+	Short: "List vision-capable models",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCapabilityModelList(capabilityVision, app.DefaultVisionModel, "vision, default")
+	},
+}
+
 func init() {
+	visionCmd.AddCommand(visionModelsCmd)
 	visionCmd.Flags().StringVarP(&visionFile, "file", "f", "", "Image file path or URL (required)")
 	visionCmd.Flags().StringVarP(&visionPrompt, "prompt", "p", "", "Analysis prompt (default: describe the image)")
 	visionCmd.Flags().StringVarP(&visionModel, "model", "m", "", "Override vision model (default: glm-4.6v)")
+	_ = visionCmd.RegisterFlagCompletionFunc("model", completeModelIDs)
 	visionCmd.Flags().Float64VarP(&visionTemp, "temperature", "t", 0.3, "Temperature (0.0-1.0, default: 0.3)")
+	visionCmd.Flags().StringVar(&visionDetail, "detail", "auto", "Image resolution: low (cheaper), high (fine text), or auto")
+	visionCmd.Flags().BoolVar(&visionStream, "stream", false, "Stream the analysis as it is generated")
+	visionCmd.Flags().Int64Var(&visionMaxImageBytes, "max-image-bytes", 0, "Largest local image to upload (default: vision.max_image_bytes, 5MB)")
+	visionCmd.Flags().BoolVar(&visionAutoResize, "auto-resize", false, "Downscale local images over the size limit instead of failing")
+	visionCmd.Flags().BoolVar(&visionExtractTables, "extract-tables", false, "Output only the tables found in the image")
+	visionCmd.Flags().StringVar(&visionTableFormat, "table-format", "markdown", "Table output format for --extract-tables: markdown or csv")
+	visionCmd.Flags().BoolVar(&visionJSON, "json", false, "Output in JSON format")
+	visionCmd.MarkFlagsMutuallyExclusive("json", "stream")
+	visionCmd.MarkFlagsMutuallyExclusive("json", "extract-tables")
 
 	// Register with root
 	rootCmd.AddCommand(visionCmd)
@@ -104,8 +181,14 @@ func runVision(imageSource, prompt string) error {
 	// Build the prompt using pure function
 	prompt = buildVisionPrompt(prompt, visionPrompt, "What do you see in this image? Please provide a detailed description.")
 
-	// Determine image source type and handle accordingly
-	imageBase64, err := processImageSource(imageSource, client)
+	// Determine image source type and handle accordingly (quietly for --json)
+	var imageBase64 string
+	var err error
+	if visionJSON {
+		imageBase64, err = resolveImageSource(imageSource)
+	} else {
+		imageBase64, err = processImageSource(imageSource, client)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to process image: %w", err)
 	}
@@ -114,17 +197,46 @@ func runVision(imageSource, prompt string) error {
 	opts := app.VisionOptions{
 		Model:       visionModel,
 		Temperature: app.Float64Ptr(visionTemp),
+		Detail:      visionDetail,
+	}
+
+	if visionJSON {
+		response, err := client.Vision(ctx, prompt, imageBase64, opts)
+		if err != nil {
+			return fmt.Errorf("vision analysis failed: %w", err)
+		}
+		saveVisionToHistory(prompt, response, imageSource)
+		return printVisionJSON(prompt, response, imageSource)
 	}
 
 	fmt.Printf("🔍 Analyzing with prompt: %s\n", prompt)
 	fmt.Println()
 
+	if visionStream {
+		fmt.Println("📝 Analysis:")
+		fmt.Println(strings.Repeat("─", 50))
+		response, err := client.VisionStream(ctx, prompt, imageBase64, opts, func(delta string) {
+			fmt.Print(delta)
+		})
+		if response != "" && !strings.HasSuffix(response, "\n") {
+			fmt.Println()
+		}
+		fmt.Println(strings.Repeat("─", 50))
+		if err != nil {
+			return fmt.Errorf("vision analysis failed: %w", err)
+		}
+		saveVisionToHistory(prompt, response, imageSource)
+		return nil
+	}
+
 	// Call vision API
 	response, err := client.Vision(ctx, prompt, imageBase64, opts)
 	if err != nil {
 		return fmt.Errorf("vision analysis failed: %w", err)
 	}
 
+	saveVisionToHistory(prompt, response, imageSource)
+
 	// Output response
 	fmt.Println("📝 Analysis:")
 	fmt.Println(strings.Repeat("─", 50))
@@ -134,18 +246,132 @@ func runVision(imageSource, prompt string) error {
 	return nil
 }
 
+// visionModelName returns the model the analysis ran on.
+func visionModelName() string {
+	if visionModel != "" {
+		return visionModel
+	}
+	return app.DefaultVisionModel
+}
+
+// printVisionJSON prints the analysis as a structured JSON object.
+func printVisionJSON(prompt, analysis, imageSource string) error {
+	output := map[string]interface{}{
+		"model":        visionModelName(),
+		"prompt":       prompt,
+		"image_source": imageSource,
+		"analysis":     analysis,
+		"timestamp":    time.Now().Format(time.RFC3339),
+	}
+	data, err := marshalJSON(output)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+// saveVisionToHistory saves the analysis to history.
+func saveVisionToHistory(prompt, analysis, imageSource string) {
+	entry := app.NewVisionHistoryEntry(time.Now(), prompt, analysis, visionModelName(), []string{imageSource})
+	if err := newHistoryStore().Save(entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Failed to save to history: %v\n", err)
+	}
+}
+
+// visionTableTemperature is used for --extract-tables unless -t is given.
+const visionTableTemperature = 0.1
+
+// tableExtractionPrompt asks the model to transcribe every table in format.
+func tableExtractionPrompt(format, extra string) string {
+	var sb strings.Builder
+	sb.WriteString("Find every table in this image and transcribe it exactly, cell by cell. ")
+	if format == "csv" {
+		sb.WriteString("Output each table as RFC 4180 CSV with a header row, quoting cells that contain commas or quotes. ")
+	} else {
+		sb.WriteString("Output each table as a GitHub-flavored Markdown table with a header row. ")
+	}
+	sb.WriteString("Put each table in its own fenced code block. Keep numbers and text as shown; use an empty cell for blanks. ")
+	sb.WriteString("Do not add commentary. If there are no tables, output nothing.")
+	if extra != "" {
+		sb.WriteString("\n\nAdditional instructions: ")
+		sb.WriteString(extra)
+	}
+	return sb.String()
+}
+
+// runVisionTables extracts tables from an image and prints only the tables,
+// separated by a blank line (CSV) or a "Table N" heading (Markdown).
+func runVisionTables(cmd *cobra.Command, imageSource, prompt string) error {
+	if visionTableFormat != "markdown" && visionTableFormat != "csv" {
+		return fmt.Errorf("invalid --table-format %q (must be markdown or csv)", visionTableFormat)
+	}
+	if visionStream {
+		return fmt.Errorf("--extract-tables cannot be combined with --stream")
+	}
+
+	client := newClient()
+
+	ctx, cancel := createContext(5 * time.Minute)
+	defer cancel()
+
+	// Stay quiet on stdout so the tables can be redirected to a file
+	imageBase64, err := resolveImageSource(imageSource)
+	if err != nil {
+		return fmt.Errorf("failed to process image: %w", err)
+	}
+
+	temperature := visionTableTemperature
+	if cmd.Flags().Changed("temperature") {
+		temperature = visionTemp
+	}
+
+	opts := app.VisionOptions{
+		Model:       visionModel,
+		Temperature: app.Float64Ptr(temperature),
+		Detail:      visionDetail,
+	}
+
+	response, err := client.Vision(ctx, tableExtractionPrompt(visionTableFormat, buildVisionPrompt(prompt, visionPrompt, "")), imageBase64, opts)
+	if err != nil {
+		return fmt.Errorf("table extraction failed: %w", err)
+	}
+
+	tables := app.ExtractTables(response, visionTableFormat)
+	if len(tables) == 0 {
+		return fmt.Errorf("no tables found in image")
+	}
+
+	for i, table := range tables {
+		if i > 0 {
+			fmt.Println()
+		}
+		if visionTableFormat == "markdown" && len(tables) > 1 {
+			fmt.Printf("### Table %d\n\n", i+1)
+		}
+		fmt.Println(table)
+	}
+	return nil
+}
+
 // processImageSource handles URL and local image sources appropriately
 func processImageSource(imageSource string, client *app.Client) (string, error) {
-	sourceType := detectImageSource(imageSource)
-
-	switch sourceType {
+	switch detectImageSource(imageSource) {
 	case ImageSourceURL:
 		fmt.Printf("🌐 Fetching image from URL: %s\n", imageSource)
-		return imageSource, nil
 	case ImageSourceFile:
 		fmt.Printf("📁 Analyzing image: %s\n", imageSource)
-		fileReader := utils.OSFileReader{}
-		return encodeLocalImage(imageSource, fileReader)
+	}
+	return resolveImageSource(imageSource)
+}
+
+// resolveImageSource returns a URL as-is or a local file as a base64 data URI.
+func resolveImageSource(imageSource string) (string, error) {
+	switch detectImageSource(imageSource) {
+	case ImageSourceURL:
+		return imageSource, nil
+	case ImageSourceFile:
+		return encodeVisionImage(imageSource, utils.OSFileReader{}, visionImageLimit(), visionAutoResize)
 	default:
 		return "", fmt.Errorf("unsupported image source: %s", imageSource)
 	}
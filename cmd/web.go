@@ -2,8 +2,9 @@ package cmd
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -23,7 +24,22 @@ Examples:
   zai reader https://example.com --format text
   zai reader https://example.com --no-cache
   zai reader https://example.com --timeout 30
-  zai reader https://example.com --with-links-summary`,
+  zai reader https://example.com --with-links-summary
+  zai reader https://example.com --metadata-only --json
+  zai reader https://example.com --raw-html > page.html
+  zai reader https://example.com --diff
+  zai reader https://example.com/docs --toc --toc-offsets
+
+The html format asks the reader API for the page markup (return_format: "html").
+If the server does not support it, the API error is reported as-is.
+
+--diff compares the page with the snapshot from the previous --diff run, prints
+a unified diff, and stores the new content. The first run stores a baseline.
+Snapshots live in web_reader.snapshot_dir (default ~/.cache/zai/web-snapshots).
+
+--toc prints the page's headings as an indented table of contents instead of
+the content; --toc-offsets adds each heading's character offset. With --json
+the headings are emitted as a nested tree.`,
 	Args: cobra.ExactArgs(1),
 	RunE: runReader,
 }
@@ -38,8 +54,16 @@ var (
 	readerWithLinksSum   bool
 	readerNoRetainImages bool
 	readerJSON           bool
+	readerMetadataOnly   bool
+	readerRawHTML        bool
+	readerDiff           bool
+	readerTOC            bool
+	readerTOCOffsets     bool
 )
 
+// readerFormats are the return formats accepted by --format.
+var readerFormats = []string{"markdown", "text", "html"}
+
 func runReader(cmd *cobra.Command, args []string) error {
 	var ctx context.Context
 	ctx, cancel := createContext(2 * time.Minute)
@@ -47,13 +71,23 @@ func runReader(cmd *cobra.Command, args []string) error {
 
 	url := args[0]
 
+	if readerRawHTML {
+		readerFormat = "html"
+	}
+	if readerDiff {
+		// A cached page would hide the change we are looking for
+		readerNoCache = true
+	}
+
 	// Create client using factory with custom timeout (no history needed)
 	clientConfig := app.ClientConfig{
-		APIKey:  viper.GetString("api.key"),
-		BaseURL: viper.GetString("api.base_url"),
-		Model:   viper.GetString("api.model"),
-		Verbose: viper.GetBool("verbose"),
-		Timeout: time.Duration(readerTimeout) * time.Second,
+		APIKey:     viper.GetString("api.key"),
+		BaseURL:    viper.GetString("api.base_url"),
+		Model:      viper.GetString("api.model"),
+		Verbose:    viper.GetBool("verbose"),
+		Timeout:    time.Duration(readerTimeout) * time.Second,
+		APIVersion: viper.GetString("api.version"),
+		Headers:    extraHeaders,
 	}
 	logger := app.NewLogger(clientConfig.Verbose)
 	client := app.NewClient(clientConfig, logger, nil, nil)
@@ -74,8 +108,8 @@ func runReader(cmd *cobra.Command, args []string) error {
 	opts.RetainImages = &retainImages
 
 	// Validate format
-	if readerFormat != "markdown" && readerFormat != "text" {
-		return fmt.Errorf("invalid format: %s (must be 'markdown' or 'text')", readerFormat)
+	if !slices.Contains(readerFormats, readerFormat) {
+		return fmt.Errorf("invalid format: %s (must be one of: %s)", readerFormat, strings.Join(readerFormats, ", "))
 	}
 
 	// Validate timeout
@@ -83,6 +117,10 @@ func runReader(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("timeout must be positive")
 	}
 
+	if readerTOC && readerFormat != "markdown" {
+		return fmt.Errorf("--toc reads headings from markdown; it cannot be used with --format %s", readerFormat)
+	}
+
 	// Fetch web content
 	resp, err := client.FetchWebContent(ctx, url, opts)
 	if err != nil {
@@ -90,19 +128,32 @@ func runReader(cmd *cobra.Command, args []string) error {
 	}
 
 	// Output results
-	if readerJSON { //nolint:nestif // JSON vs human-readable output branching
+	if readerTOC {
+		if err := printReaderTOC(resp.ReaderResult); err != nil {
+			return err
+		}
+	} else if readerDiff {
+		if err := printReaderDiff(url, resp.ReaderResult.Content); err != nil {
+			return err
+		}
+	} else if readerRawHTML {
+		// Passthrough: markup only, so it can be piped to a parser or file
+		fmt.Println(resp.ReaderResult.Content)
+	} else if readerJSON { //nolint:nestif // JSON vs human-readable output branching
 		// Create structured JSON output
 		output := map[string]interface{}{
-			"url":                resp.ReaderResult.URL,
-			"title":              resp.ReaderResult.Title,
-			"description":        resp.ReaderResult.Description,
-			"content":            resp.ReaderResult.Content,
-			"metadata":           resp.ReaderResult.Metadata,
-			"external_resources": resp.ReaderResult.ExternalResources,
-			"timestamp":          time.Now().Format(time.RFC3339),
+			"url":         resp.ReaderResult.URL,
+			"title":       resp.ReaderResult.Title,
+			"description": resp.ReaderResult.Description,
+			"metadata":    resp.ReaderResult.Metadata,
+			"timestamp":   time.Now().Format(time.RFC3339),
+		}
+		if !readerMetadataOnly {
+			output["content"] = resp.ReaderResult.Content
+			output["external_resources"] = resp.ReaderResult.ExternalResources
 		}
 
-		data, err := json.MarshalIndent(output, "", "  ")
+		data, err := marshalJSON(output)
 		if err != nil {
 			return fmt.Errorf("failed to marshal JSON: %w", err)
 		}
@@ -114,7 +165,9 @@ func runReader(cmd *cobra.Command, args []string) error {
 		if resp.ReaderResult.Description != "" {
 			fmt.Printf("Description: %s\n", resp.ReaderResult.Description)
 		}
-		fmt.Printf("\nContent:\n%s\n", resp.ReaderResult.Content)
+		if !readerMetadataOnly {
+			fmt.Printf("\nContent:\n%s\n", resp.ReaderResult.Content)
+		}
 
 		// Display metadata if available
 		if len(resp.ReaderResult.Metadata) > 0 {
@@ -125,7 +178,7 @@ func runReader(cmd *cobra.Command, args []string) error {
 		}
 
 		// Display external resources if available
-		if len(resp.ReaderResult.ExternalResources) > 0 {
+		if !readerMetadataOnly && len(resp.ReaderResult.ExternalResources) > 0 {
 			fmt.Printf("\nExternal Resources:\n")
 			for k, v := range resp.ReaderResult.ExternalResources {
 				fmt.Printf("  %s: %v\n", k, v)
@@ -134,7 +187,7 @@ func runReader(cmd *cobra.Command, args []string) error {
 	}
 
 	// Save to history (using default location)
-	history := app.NewFileHistoryStore("")
+	history := newHistoryStore()
 
 	// Create a history entry for web content
 	entry := app.NewWebHistoryEntry(
@@ -154,7 +207,8 @@ func init() {
 	rootCmd.AddCommand(readerCmd)
 
 	// Web reader flags
-	readerCmd.Flags().StringVar(&readerFormat, "format", "markdown", "Return format (markdown or text)")
+	readerCmd.Flags().StringVar(&readerFormat, "format", "markdown", "Return format (markdown, text, or html)")
+	_ = readerCmd.RegisterFlagCompletionFunc("format", cobra.FixedCompletions(readerFormats, cobra.ShellCompDirectiveNoFileComp))
 	readerCmd.Flags().IntVar(&readerTimeout, "timeout", 20, "Request timeout in seconds")
 	readerCmd.Flags().BoolVar(&readerNoCache, "no-cache", false, "Disable caching")
 	readerCmd.Flags().BoolVar(&readerNoGFM, "no-gfm", false, "Disable GitHub Flavored Markdown")
@@ -163,4 +217,103 @@ func init() {
 	readerCmd.Flags().BoolVar(&readerWithLinksSum, "with-links-summary", false, "Include links summary")
 	readerCmd.Flags().BoolVar(&readerNoRetainImages, "no-retain-images", false, "Do not retain images")
 	readerCmd.Flags().BoolVar(&readerJSON, "json", false, "Output in JSON format")
+	readerCmd.Flags().BoolVar(&readerMetadataOnly, "metadata-only", false, "Print only title, description, URL, and metadata (omit content)")
+	readerCmd.Flags().BoolVar(&readerRawHTML, "raw-html", false, "Print only the page HTML (implies --format html)")
+	readerCmd.Flags().BoolVar(&readerTOC, "toc", false, "Print a table of contents built from the page headings instead of the content")
+	readerCmd.Flags().BoolVar(&readerTOCOffsets, "toc-offsets", false, "With --toc, show each heading's character offset in the content")
+	readerCmd.Flags().BoolVar(&readerDiff, "diff", false, "Show a unified diff against the last snapshot of this URL and update it")
+	readerCmd.MarkFlagsMutuallyExclusive("raw-html", "json")
+	readerCmd.MarkFlagsMutuallyExclusive("diff", "raw-html")
+	readerCmd.MarkFlagsMutuallyExclusive("diff", "metadata-only")
+	readerCmd.MarkFlagsMutuallyExclusive("raw-html", "metadata-only")
+	readerCmd.MarkFlagsMutuallyExclusive("toc", "raw-html")
+	readerCmd.MarkFlagsMutuallyExclusive("toc", "diff")
+	readerCmd.MarkFlagsMutuallyExclusive("toc", "metadata-only")
+}
+
+// printReaderTOC prints the headings of the fetched markdown, indented by
+// nesting, or as a JSON heading tree with --json.
+func printReaderTOC(result app.ReaderResult) error {
+	headings := app.ParseMarkdownHeadings(result.Content)
+
+	if readerJSON {
+		data, err := marshalJSON(map[string]interface{}{
+			"url":      result.URL,
+			"title":    result.Title,
+			"headings": headings,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	if len(headings) == 0 {
+		fmt.Println("No headings found")
+		return nil
+	}
+	var printHeadings func(hs []*app.Heading, depth int)
+	printHeadings = func(hs []*app.Heading, depth int) {
+		for _, h := range hs {
+			line := strings.Repeat("  ", depth) + h.Title
+			if readerTOCOffsets {
+				line += " " + theme.Dim.Render(fmt.Sprintf("@%d", h.Offset))
+			}
+			fmt.Println(line)
+			printHeadings(h.Children, depth+1)
+		}
+	}
+	printHeadings(headings, 0)
+	return nil
+}
+
+// printReaderDiff compares content with the stored snapshot of url, prints
+// the result, and stores content as the new snapshot.
+func printReaderDiff(url, content string) error {
+	store := app.NewWebSnapshotStore(viper.GetString("web_reader.snapshot_dir"))
+	previous, ok, err := store.Load(url, readerFormat)
+	if err != nil {
+		return err
+	}
+
+	current := app.WebSnapshot{URL: url, Format: readerFormat, Content: content, FetchedAt: time.Now()}
+	diff := ""
+	if ok {
+		diff = app.UnifiedDiff(
+			fmt.Sprintf("%s\t%s", url, previous.FetchedAt.Format(time.RFC3339)),
+			fmt.Sprintf("%s\t%s", url, current.FetchedAt.Format(time.RFC3339)),
+			previous.Content, content)
+	}
+	if err := store.Save(current); err != nil {
+		return err
+	}
+
+	if readerJSON {
+		output := map[string]interface{}{
+			"url":      url,
+			"baseline": !ok,
+			"changed":  diff != "",
+			"diff":     diff,
+		}
+		if ok {
+			output["previous_fetched_at"] = previous.FetchedAt.Format(time.RFC3339)
+		}
+		data, err := marshalJSON(output)
+		if err != nil {
+			return fmt.Errorf("failed to marshal JSON: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	switch {
+	case !ok:
+		fmt.Println(theme.Dim.Render(fmt.Sprintf("Baseline stored for %s", url)))
+	case diff == "":
+		fmt.Println(theme.Dim.Render(fmt.Sprintf("No changes since %s", previous.FetchedAt.Format(time.RFC3339))))
+	default:
+		fmt.Print(diff)
+	}
+	return nil
 }
@@ -257,3 +257,87 @@ func (fsc *FileSearchCache) Stats() (*CacheStats, error) {
 
 	return stats, nil
 }
+
+// WebContentCache holds pages fetched during one session, keyed by URL.
+// Lets a REPL reuse a page instead of fetching it again on every mention.
+type WebContentCache struct {
+	mu    sync.RWMutex
+	pages map[string]ReaderResult
+}
+
+// NewWebContentCache creates an empty in-memory web content cache.
+func NewWebContentCache() *WebContentCache {
+	return &WebContentCache{pages: make(map[string]ReaderResult)}
+}
+
+// Get returns the cached page for url.
+func (w *WebContentCache) Get(url string) (ReaderResult, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	page, ok := w.pages[url]
+	return page, ok
+}
+
+// Set stores a fetched page for url.
+func (w *WebContentCache) Set(url string, page ReaderResult) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.pages[url] = page
+}
+
+// Delete forgets url so the next mention fetches it again.
+func (w *WebContentCache) Delete(url string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pages, url)
+}
+
+// ModelListCache keeps the models list in a file so lookups such as shell
+// completion don't call the API every time.
+type ModelListCache struct {
+	path string
+}
+
+// modelListCacheEntry is the on-disk form of a cached models list.
+type modelListCacheEntry struct {
+	Models   []Model   `json:"models"`
+	CachedAt time.Time `json:"cached_at"`
+}
+
+// NewModelListCache creates a models cache stored at path.
+func NewModelListCache(path string) *ModelListCache {
+	return &ModelListCache{path: path}
+}
+
+// Get returns the cached models if they were stored less than ttl ago.
+func (mc *ModelListCache) Get(ttl time.Duration) ([]Model, bool) {
+	data, err := os.ReadFile(mc.path)
+	if err != nil {
+		return nil, false
+	}
+
+	var entry modelListCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+	if time.Since(entry.CachedAt) > ttl {
+		return nil, false
+	}
+	return entry.Models, true
+}
+
+// Set replaces the cached models list.
+func (mc *ModelListCache) Set(models []Model) error {
+	if err := os.MkdirAll(filepath.Dir(mc.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	data, err := json.Marshal(modelListCacheEntry{Models: models, CachedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal models cache: %w", err)
+	}
+	if err := os.WriteFile(mc.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write models cache: %w", err)
+	}
+	return nil
+}
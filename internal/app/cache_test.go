@@ -216,3 +216,20 @@ func TestFileSearchCacheNonExistentDirectory(t *testing.T) {
 	_, err = os.Stat(nonExistentDir)
 	assert.NoError(t, err)
 }
+
+func TestModelListCache(t *testing.T) {
+	cache := NewModelListCache(filepath.Join(t.TempDir(), "zai", "models.json"))
+
+	_, found := cache.Get(time.Hour)
+	assert.False(t, found)
+
+	models := []Model{{ID: "glm-4.7", OwnedBy: "z-ai"}}
+	require.NoError(t, cache.Set(models))
+
+	cached, found := cache.Get(time.Hour)
+	assert.True(t, found)
+	assert.Equal(t, models, cached)
+
+	_, found = cache.Get(0)
+	assert.False(t, found, "entries older than the TTL are ignored")
+}
@@ -6,12 +6,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"image"
+	"image/png"
 	"io"
 	"log/slog"
 	"math/rand/v2"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
 	"time"
@@ -42,6 +45,13 @@ type ClientConfig struct {
 	RateLimit      RateLimitConfig
 	RetryConfig    RetryConfig
 	CircuitBreaker config.CircuitBreakerConfig
+	APIVersion     string            // Sent as X-API-Version when set
+	SystemPrompt   string            // Replaces the built-in default system prompt when set
+	SystemFile     string            // Contents of --system-file, layered after SystemPrompt
+	SystemMerge    string            // SystemMergeReplace (default) or SystemMergeAppend
+	Headers        map[string]string // Extra headers for every request (api.headers, --headers-file)
+
+	ShowRequestSize bool // Report chat request/response body sizes on stderr without --verbose
 }
 
 // RateLimitConfig holds rate limiting configuration.
@@ -87,11 +97,13 @@ func DiscardLogger() *slog.Logger {
 // Provides the main chat functionality.
 type ChatClient interface {
 	Chat(ctx context.Context, prompt string, opts ChatOptions) (string, error)
+	ChatStream(ctx context.Context, prompt string, opts ChatOptions, onDelta func(string)) (string, error)
 }
 
 // VisionClient interface for image analysis (ISP compliance).
 type VisionClient interface {
 	Vision(ctx context.Context, prompt string, imageBase64 string, opts VisionOptions) (string, error)
+	VisionStream(ctx context.Context, prompt string, imageBase64 string, opts VisionOptions, onDelta func(string)) (string, error)
 }
 
 // ImageClient interface for image generation (ISP compliance).
@@ -143,6 +155,7 @@ type FullClient interface {
 type HistoryStore interface {
 	Save(entry HistoryEntry) error
 	GetRecent(limit int) ([]HistoryEntry, error)
+	Search(term string, limit int) ([]HistoryEntry, error)
 }
 
 // HTTPDoer interface for HTTP operations (DIP compliance, enables testing).
@@ -203,7 +216,7 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 	if cb.state == Open {
 		// Check if timeout has passed
 		if time.Since(cb.lastStateChange) < cb.config.Timeout {
-			return fmt.Errorf("circuit breaker '%s' is open (timeout: %v)", cb.name, cb.config.Timeout)
+			return circuitOpenError{name: cb.name, timeout: cb.config.Timeout}
 		}
 		// Move to half-open state
 		cb.state = HalfOpen
@@ -223,6 +236,22 @@ func (cb *CircuitBreaker) Execute(fn func() error) error {
 	return err
 }
 
+// ErrCircuitOpen matches (with errors.Is) the error returned while a circuit
+// breaker is open and rejecting calls.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// circuitOpenError names the open circuit breaker and its timeout.
+type circuitOpenError struct {
+	name    string
+	timeout time.Duration
+}
+
+func (e circuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker '%s' is open (timeout: %v)", e.name, e.timeout)
+}
+
+func (e circuitOpenError) Is(target error) bool { return target == ErrCircuitOpen }
+
 // Reset manually resets the circuit breaker to closed state.
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
@@ -329,6 +358,69 @@ func (c *RateLimitedClient) Do(req *http.Request) (*http.Response, error) {
 	return c.client.Do(req)
 }
 
+// HeaderClient implements HTTPDoer, adding fixed headers to every request.
+type HeaderClient struct {
+	client  HTTPDoer
+	headers map[string]string
+}
+
+// NewHeaderClient wraps client so every request carries headers.
+// Returns client unchanged when there are no headers.
+func NewHeaderClient(client HTTPDoer, headers map[string]string) HTTPDoer {
+	if len(headers) == 0 {
+		return client
+	}
+	return &HeaderClient{client: client, headers: headers}
+}
+
+// Do implements HTTPDoer interface, setting headers before delegating.
+func (c *HeaderClient) Do(req *http.Request) (*http.Response, error) {
+	for k, v := range c.headers {
+		req.Header.Set(k, v)
+	}
+	return c.client.Do(req)
+}
+
+// clientHeaders returns the extra headers implied by cfg.
+// Configured headers override X-API-Version.
+func clientHeaders(cfg ClientConfig) map[string]string {
+	headers := map[string]string{}
+	if cfg.APIVersion != "" {
+		headers["X-API-Version"] = cfg.APIVersion
+	}
+	for k, v := range cfg.Headers {
+		headers[http.CanonicalHeaderKey(k)] = v
+	}
+	return headers
+}
+
+// headerNameRegex matches a valid HTTP header field name (RFC 9110 token).
+var headerNameRegex = regexp.MustCompile("^[!#$%&'*+.^_`|~0-9A-Za-z-]+$")
+
+// ParseHeaderLines parses "Key: Value" lines, skipping blank lines and
+// # comments. Names are canonicalized; a repeated name keeps the last value
+// and is reported in duplicates. A malformed line is an error naming it.
+func ParseHeaderLines(data string) (headers map[string]string, duplicates []string, err error) {
+	headers = map[string]string{}
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		name = strings.TrimSpace(name)
+		if !ok || !headerNameRegex.MatchString(name) {
+			return nil, nil, fmt.Errorf("line %d: expected \"Name: value\", got %q", i+1, line)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if _, seen := headers[name]; seen {
+			duplicates = append(duplicates, name)
+		}
+		headers[name] = strings.TrimSpace(value)
+	}
+	return headers, duplicates, nil
+}
+
 // FileReader interface for file operations (DIP compliance, enables testing).
 // Deprecated: Use utils.FileReader instead. Kept for backward compatibility.
 type FileReader = utils.FileReader
@@ -366,7 +458,12 @@ func NewClient(cfg ClientConfig, logger *slog.Logger, history HistoryStore, http
 func NewClientWithDeps(cfg ClientConfig, logger *slog.Logger, history HistoryStore, deps *ClientDeps) *Client {
 	timeout := cfg.Timeout
 	if timeout == 0 {
-		timeout = 60 * time.Second
+		timeout = defaultRequestTimeout
+	}
+	if cfg.RetryConfig.TimeoutBackoff {
+		// Chat retries set their own growing deadlines; allow the longest of
+		// them, and keep a timeout for every other request
+		timeout = attemptTimeout(timeout, max(cfg.RetryConfig.MaxAttempts, 1))
 	}
 
 	var httpClient HTTPDoer
@@ -378,13 +475,14 @@ func NewClientWithDeps(cfg ClientConfig, logger *slog.Logger, history HistorySto
 	}
 
 	if httpClient == nil {
-		httpClient = &http.Client{Timeout: timeout}
+		httpClient = &http.Client{Timeout: timeout, Transport: newTransport()}
 	}
 	if fileReader == nil {
 		fileReader = OSFileReader{}
 	}
 
-	// Wrap HTTP client with rate limiting
+	// Wrap HTTP client with extra headers and rate limiting
+	httpClient = NewHeaderClient(httpClient, clientHeaders(cfg))
 	httpClient = NewRateLimitedClient(httpClient, cfg.RateLimit, logger)
 
 	client := &Client{
@@ -404,6 +502,17 @@ func NewClientWithDeps(cfg ClientConfig, logger *slog.Logger, history HistorySto
 	return client
 }
 
+// idleConnTimeout keeps pooled connections open across REPL think time.
+const idleConnTimeout = 5 * time.Minute
+
+// newTransport returns the default transport tuned to keep API connections warm.
+func newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.IdleConnTimeout = idleConnTimeout
+	transport.MaxIdleConnsPerHost = 10
+	return transport
+}
+
 // HTTPClient returns the underlying HTTP client for connection reuse.
 func (c *Client) HTTPClient() HTTPDoer {
 	return c.httpClient
@@ -438,39 +547,190 @@ func (c *Client) requireAPIKey() error {
 // Chat sends a prompt and returns the response.
 // Orchestrates content building, URL enrichment, and request execution.
 func (c *Client) Chat(ctx context.Context, prompt string, opts ChatOptions) (string, error) {
+	response, _, err := c.ChatWithUsage(ctx, prompt, opts)
+	return response, err
+}
+
+// ChatWithUsage is Chat that also returns the token usage of the response.
+func (c *Client) ChatWithUsage(ctx context.Context, prompt string, opts ChatOptions) (string, Usage, error) {
 	if err := c.requireAPIKey(); err != nil {
-		return "", err
+		return "", Usage{}, err
+	}
+
+	if opts.StripURLs {
+		prompt = StripURLs(prompt)
 	}
 
-	// Build message content (with optional file)
-	content, err := c.buildContent(ctx, prompt, opts.FilePath)
+	messages, opts, err := c.prepareChat(ctx, prompt, opts)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
-	// Enrich content with web URLs if enabled
-	content = c.enrichWithURLContent(ctx, prompt, content, opts)
+	// Execute request with retry
+	response, usage, err := c.doRequestWithRetry(ctx, messages, opts)
+	if err != nil {
+		return "", Usage{}, err
+	}
 
-	// Build messages array with context
-	messages := c.buildMessagesWithContext(content, opts)
+	if opts.OnUsage != nil {
+		opts.OnUsage(usage)
+	}
 
-	// Handle legacy Think field
-	if opts.Think && opts.Thinking == nil {
-		opts.Thinking = &opts.Think
+	// Save to history (non-blocking, log errors)
+	c.saveToHistory(prompt, response, usage)
+
+	return response, usage, nil
+}
+
+// ChatStream is Chat with incremental output: onDelta receives text as it arrives
+// and the assembled response is returned and saved to history. If the server
+// answers with a plain JSON body instead of an event stream, the full response
+// is passed to onDelta once. Failures are retried like Chat until the first
+// text arrives; once output has started, a broken stream is returned as is.
+func (c *Client) ChatStream(ctx context.Context, prompt string, opts ChatOptions, onDelta func(string)) (string, error) {
+	if err := c.requireAPIKey(); err != nil {
+		return "", err
 	}
 
-	// Execute request with retry
-	response, usage, err := c.doRequestWithRetry(ctx, messages, opts)
+	if opts.StripURLs {
+		prompt = StripURLs(prompt)
+	}
+
+	messages, opts, err := c.prepareChat(ctx, prompt, opts)
 	if err != nil {
 		return "", err
 	}
 
-	// Save to history (non-blocking, log errors)
+	reqData := c.buildChatRequest(messages, opts)
+	reqData.Stream = true
+
+	started := false
+	var partial string
+	response, usage, err := c.withRetry(ctx, opts.RetryOnEmpty, func(ctx context.Context) (string, Usage, error) {
+		response, usage, err := c.doStreamRequest(ctx, reqData, func(delta string) {
+			started = true
+			if onDelta != nil {
+				onDelta(delta)
+			}
+		})
+		if err != nil && started {
+			partial = response
+			return response, usage, fmt.Errorf("%w: %w", errStreamInterrupted, err)
+		}
+		return response, usage, err
+	})
+	if err != nil {
+		return partial, err
+	}
+
+	c.logger.Debug("stream complete", "total_tokens", usage.TotalTokens)
+	if opts.OnUsage != nil {
+		opts.OnUsage(usage)
+	}
 	c.saveToHistory(prompt, response, usage)
 
 	return response, nil
 }
 
+// errStreamInterrupted marks a stream that failed after output had started,
+// which can't be retried without repeating text.
+var errStreamInterrupted = errors.New("chat stream interrupted")
+
+// doStreamRequest sends one streaming chat/completions request (chat or
+// vision) and reads the response, passing text to onDelta as it arrives.
+// If the server answers with a plain JSON body instead of an event stream,
+// the full response is passed to onDelta once.
+func (c *Client) doStreamRequest(ctx context.Context, reqData any, onDelta func(string)) (string, Usage, error) {
+	jsonData, err := json.Marshal(reqData)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	url := fmt.Sprintf("%s/chat/completions", c.config.BaseURL)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	setJSONHeaders(req, c.config.APIKey)
+	req.Header.Set("Accept", "text/event-stream")
+
+	c.logger.Debug("sending streaming request", "url", url)
+	c.logBodySize("request size", len(jsonData))
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", Usage{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", Usage{}, newAPIError(resp, body)
+	}
+
+	body := &countingReader{r: resp.Body}
+	defer func() { c.logBodySize("response size", body.n) }()
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		response, usage, err := parseSSEStream(body, onDelta)
+		if err != nil {
+			return response, usage, fmt.Errorf("chat stream failed: %w", err)
+		}
+		return response, usage, nil
+	}
+
+	// Buffered fallback: server ignored stream=true
+	var chatResp ChatResponse
+	if err := json.NewDecoder(body).Decode(&chatResp); err != nil {
+		return "", Usage{}, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", Usage{}, fmt.Errorf("no choices in response")
+	}
+	response := chatResp.Choices[0].Message.Content
+	if onDelta != nil && response != "" {
+		onDelta(response)
+	}
+	return response, chatResp.Usage, nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += n
+	return n, err
+}
+
+// prepareChat builds the messages for a chat request: instructions, file
+// context, fetched URLs, and prior messages around the prompt.
+func (c *Client) prepareChat(ctx context.Context, prompt string, opts ChatOptions) ([]Message, ChatOptions, error) {
+	// Build message content (instructions frame the optional file)
+	var content string
+	var err error
+	if opts.CachePrefix {
+		content, opts, err = c.buildCachePrefixContent(ctx, PrependInstructions(opts.Instructions, prompt), opts)
+	} else {
+		content, err = c.buildContent(ctx, PrependInstructions(opts.Instructions, prompt), opts)
+	}
+	if err != nil {
+		return nil, opts, err
+	}
+
+	// Enrich content with web URLs if enabled
+	content = c.enrichWithURLContent(ctx, prompt, content, opts)
+
+	// Handle legacy Think field
+	if opts.Think && opts.Thinking == nil {
+		opts.Thinking = &opts.Think
+	}
+
+	// Build messages array with context
+	return c.buildMessagesWithContext(content, opts), opts, nil
+}
+
 // enrichWithURLContent fetches web content for URLs in the prompt if web is enabled.
 // Uses concurrent fetching with errgroup for improved performance.
 func (c *Client) enrichWithURLContent(ctx context.Context, prompt, content string, opts ChatOptions) string {
@@ -484,6 +744,9 @@ func (c *Client) enrichWithURLContent(ctx context.Context, prompt, content strin
 	}
 
 	webOpts := c.defaultWebReaderOptions(opts.WebTimeout)
+	if opts.WebFormat != "" {
+		webOpts.ReturnFormat = opts.WebFormat
+	}
 
 	// Use errgroup for concurrent URL fetching
 	g, ctx := errgroup.WithContext(ctx)
@@ -497,11 +760,23 @@ func (c *Client) enrichWithURLContent(ctx context.Context, prompt, content strin
 	for i, url := range urls {
 		i, url := i, url // capture loop variables
 		g.Go(func() error {
+			if opts.WebCache != nil {
+				if page, ok := opts.WebCache.Get(url); ok {
+					c.logger.Debug("reusing fetched web content", "url", url)
+					results[i].url = url
+					results[i].title = page.Title
+					results[i].body = page.Content
+					return nil
+				}
+			}
 			webResp, err := c.FetchWebContent(ctx, url, webOpts)
 			if err != nil {
 				c.logger.Warn("failed to fetch web content", "url", url, "error", err)
 				return nil // Don't fail entire group for single URL error
 			}
+			if opts.WebCache != nil {
+				opts.WebCache.Set(url, webResp.ReaderResult)
+			}
 			results[i].url = url
 			results[i].title = webResp.ReaderResult.Title
 			results[i].body = webResp.ReaderResult.Content
@@ -552,9 +827,11 @@ func (c *Client) defaultWebReaderOptions(timeout *int) *WebReaderOptions {
 func (c *Client) buildMessagesWithContext(content string, opts ChatOptions) []Message {
 	messages := c.buildMessages(content, opts)
 
-	// Prepend context messages if provided
+	// Insert context messages before the new user message.
+	// Keeping the system message first also keeps a --cache-prefix prefix stable.
 	if len(opts.Context) > 0 {
-		messages = append(opts.Context, messages...)
+		last := len(messages) - 1
+		return append(append(messages[:last:last], opts.Context...), messages[last])
 	}
 
 	return messages
@@ -572,9 +849,19 @@ func (c *Client) saveToHistory(prompt, response string, usage Usage) {
 }
 
 // buildContent combines prompt with optional file contents or URL content.
-func (c *Client) buildContent(ctx context.Context, prompt, filePath string) (string, error) {
+func (c *Client) buildContent(ctx context.Context, prompt string, opts ChatOptions) (string, error) {
+	fileContext, err := c.buildFileContext(ctx, opts)
+	if err != nil || fileContext == "" {
+		return prompt, err
+	}
+	return prompt + "\n\n" + fileContext, nil
+}
+
+// buildFileContext renders the -f file or URL as a prompt section, or "" if none.
+func (c *Client) buildFileContext(ctx context.Context, opts ChatOptions) (string, error) {
+	filePath := opts.FilePath
 	if filePath == "" {
-		return prompt, nil
+		return "", nil
 	}
 
 	// Check if it's a URL
@@ -587,8 +874,8 @@ func (c *Client) buildContent(ctx context.Context, prompt, filePath string) (str
 		if err != nil {
 			return "", fmt.Errorf("failed to fetch URL %s: %w", filePath, err)
 		}
-		return fmt.Sprintf("%s\n\n<web_content url=\"%s\" title=\"%s\">\n%s\n</web_content>",
-			prompt, filePath, resp.ReaderResult.Title, resp.ReaderResult.Content), nil
+		return fmt.Sprintf("<web_content url=\"%s\" title=\"%s\">\n%s\n</web_content>",
+			filePath, resp.ReaderResult.Title, resp.ReaderResult.Content), nil
 	}
 
 	// Local file
@@ -597,22 +884,139 @@ func (c *Client) buildContent(ctx context.Context, prompt, filePath string) (str
 		return "", fmt.Errorf("failed to read file %s: %w", filePath, err)
 	}
 
-	return fmt.Sprintf("%s\n\nFile contents (%s):\n```\n%s\n```", prompt, filePath, string(data)), nil
+	if opts.CompressFile {
+		summary := c.compressFileContent(ctx, filePath, string(data), opts.CompressModel)
+		return fmt.Sprintf("File summary (%s):\n```\n%s\n```", filePath, summary), nil
+	}
+
+	return fmt.Sprintf("File contents (%s):\n```\n%s\n```", filePath, string(data)), nil
+}
+
+// MinCachePrefixChars is the smallest stable prefix worth arranging for caching
+// (roughly 1k tokens); shorter prefixes are sent the normal way.
+const MinCachePrefixChars = 4096
+
+// buildCachePrefixContent moves the file context into the system message so the
+// request starts with a byte-identical prefix across calls. The API caches
+// repeated prefixes automatically and bills cached input tokens at a discount,
+// so a REPL session over a large -f file pays full price for it only once.
+// Returns the user content and the adjusted options.
+func (c *Client) buildCachePrefixContent(ctx context.Context, prompt string, opts ChatOptions) (string, ChatOptions, error) {
+	system, fileContext, err := c.cachePrefixSystem(ctx, opts)
+	if err != nil {
+		return "", opts, err
+	}
+	if system != "" {
+		opts.SystemPrompt = system
+		return prompt, opts, nil
+	}
+	if fileContext == "" {
+		return prompt, opts, nil
+	}
+	return prompt + "\n\n" + fileContext, opts, nil
+}
+
+// ApplyCachePrefix resolves the --cache-prefix system message once, for
+// callers that send several turns with the same options, like the chat REPL.
+// When the prefix is worth caching it becomes the system prompt and FilePath
+// is cleared, so every turn sends it without reading the file again.
+// Otherwise opts is returned unchanged. opts.Context should hold any seeded
+// messages, since a system message there replaces the prefix.
+func (c *Client) ApplyCachePrefix(ctx context.Context, opts ChatOptions) (ChatOptions, error) {
+	if !opts.CachePrefix || opts.FilePath == "" {
+		return opts, nil
+	}
+	system, _, err := c.cachePrefixSystem(ctx, opts)
+	if err != nil || system == "" {
+		return opts, err
+	}
+	opts.SystemPrompt = system
+	opts.FilePath = ""
+	return opts, nil
+}
+
+// cachePrefixSystem returns the system message with the file context
+// appended, or "" when the prefix is too short to be worth caching or a
+// system message in the context would replace it. The rendered file context
+// is returned either way.
+func (c *Client) cachePrefixSystem(ctx context.Context, opts ChatOptions) (string, string, error) {
+	fileContext, err := c.buildFileContext(ctx, opts)
+	if err != nil || fileContext == "" {
+		return "", fileContext, err
+	}
+
+	// A system message in the context replaces ours, so there is no prefix to extend
+	system := c.systemPrompt(opts.SystemPrompt)
+	if hasSystemMessage(opts.Context) || len(system)+len(fileContext) < MinCachePrefixChars {
+		return "", fileContext, nil
+	}
+	return system + "\n\n" + fileContext, fileContext, nil
+}
+
+// DefaultCompressModel is the cheap model used to summarize files for --compress.
+const DefaultCompressModel = "glm-4.5-flash"
+
+// compressFallbackChars is how much of a file is kept when summarization fails.
+const compressFallbackChars = 20000
+
+// compressFileContent summarizes file contents with a cheap model so a large
+// file can be included in spirit. Falls back to truncation if the call fails.
+func (c *Client) compressFileContent(ctx context.Context, filePath, content, model string) string {
+	if model == "" {
+		model = DefaultCompressModel
+	}
+
+	messages := []Message{
+		{Role: "system", Content: "Summarize the file for another model that will answer questions about it. " +
+			"Keep structure, key identifiers, names, numbers, and anything unusual. Omit boilerplate. Output only the summary."},
+		{Role: "user", Content: fmt.Sprintf("File: %s\n\n%s", filePath, content)},
+	}
+	opts := ChatOptions{Model: model, Temperature: Float64Ptr(0.2)}
+
+	summary, _, err := c.doRequestWithRetry(ctx, messages, opts)
+	if err != nil || strings.TrimSpace(summary) == "" {
+		truncated := truncateRunes(content, compressFallbackChars)
+		c.logger.Warn("file summarization failed, truncating instead",
+			"file", filePath, "error", err, "from_chars", len(content), "to_chars", len(truncated))
+		return truncated
+	}
+
+	summary = strings.TrimSpace(summary)
+	c.logger.Info("compressed file",
+		"file", filePath, "from_chars", len(content), "to_chars", len(summary),
+		"ratio", fmt.Sprintf("%.0f%%", 100*float64(len(summary))/float64(max(len(content), 1))))
+	return summary
+}
+
+// truncateRunes cuts s to at most n runes, marking the cut.
+func truncateRunes(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "\n... [truncated]"
+}
+
+// PrependInstructions places shared instructions ahead of the user prompt.
+func PrependInstructions(instructions, prompt string) string {
+	instructions = strings.TrimSpace(instructions)
+	if instructions == "" {
+		return prompt
+	}
+	return instructions + "\n\n" + prompt
 }
 
 // buildMessages constructs the messages array for the API.
 func (c *Client) buildMessages(content string, opts ChatOptions) []Message {
 	var messages []Message
 
-	// Add system prompt (custom or default)
-	systemPrompt := opts.SystemPrompt
-	if systemPrompt == "" {
-		systemPrompt = "Be concise and direct. Answer briefly and to the point."
+	// Add system prompt (custom, configured, or default) unless the context has its own
+	if !hasSystemMessage(opts.Context) {
+		messages = append(messages, Message{
+			Role:    "system",
+			Content: c.systemPrompt(opts.SystemPrompt),
+		})
 	}
-	messages = append(messages, Message{
-		Role:    "system",
-		Content: systemPrompt,
-	})
 
 	// Add current user message
 	messages = append(messages, Message{
@@ -623,12 +1027,78 @@ func (c *Client) buildMessages(content string, opts ChatOptions) []Message {
 	return messages
 }
 
+// defaultSystemPrompt is used when no custom system prompt is set.
+const defaultSystemPrompt = "Be concise and direct. Answer briefly and to the point."
+
+// System prompt merge modes (--system-merge).
+const (
+	SystemMergeReplace = "replace" // The most specific source wins
+	SystemMergeAppend  = "append"  // All sources are joined, most general first
+)
+
+// systemPrompt combines the configured system prompt, the system file, and the
+// per-request prompt, in that order from general to specific. In replace mode
+// the last non-empty one wins; in append mode all are joined with blank lines.
+// Falls back to the built-in default when every source is empty.
+func (c *Client) systemPrompt(prompt string) string {
+	var layers []string
+	for _, layer := range []string{c.config.SystemPrompt, c.config.SystemFile, prompt} {
+		if layer = strings.TrimSpace(layer); layer != "" {
+			layers = append(layers, layer)
+		}
+	}
+	switch {
+	case len(layers) == 0:
+		return defaultSystemPrompt
+	case c.config.SystemMerge == SystemMergeAppend:
+		return strings.Join(layers, "\n\n")
+	default:
+		return layers[len(layers)-1]
+	}
+}
+
+// hasSystemMessage reports whether messages include a system message.
+func hasSystemMessage(messages []Message) bool {
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrEmptyResponse reports a successful response whose content was empty.
+// Only produced when ChatOptions.RetryOnEmpty is set.
+var ErrEmptyResponse = errors.New("empty response content")
+
+// ErrRetryBudgetExhausted is wrapped when retries stop because the retry
+// budget (api.retry.max_elapsed) ran out.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// IsUnrecoverableError reports whether err means further requests will fail
+// the same way until the user steps in: rejected credentials, an open circuit
+// breaker, or an exhausted retry budget.
+func IsUnrecoverableError(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrRetryBudgetExhausted) {
+		return true
+	}
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden)
+}
+
 // isRetryableError checks if an error should trigger a retry.
 func isRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
 
+	if errors.Is(err, ErrEmptyResponse) {
+		return true
+	}
+	if errors.Is(err, errStreamInterrupted) {
+		return false
+	}
+
 	// Network errors: timeout, connection refused, etc.
 	var netErr interface{ Timeout() bool }
 	if errors.As(err, &netErr) && netErr.Timeout() {
@@ -786,7 +1256,7 @@ func (c *Client) executeJSONRequestInternal(ctx context.Context, endpoint string
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		return nil, newAPIError(resp, body)
 	}
 
 	return body, nil
@@ -830,15 +1300,14 @@ func (c *Client) executeGetRequestInternal(ctx context.Context, endpoint string)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		return nil, newAPIError(resp, body)
 	}
 
 	return body, nil
 }
 
-// doRequest executes the HTTP request to Z.AI API.
-// Single place for all HTTP logic (DRY compliance).
-func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOptions) (string, Usage, error) {
+// buildChatRequest fills a chat request from the options, applying defaults.
+func (c *Client) buildChatRequest(messages []Message, opts ChatOptions) ChatRequest {
 	// Use opts.Thinking (bool pointer) to build the API request structure
 	var thinking *Thinking
 	if opts.Thinking != nil && *opts.Thinking {
@@ -873,11 +1342,21 @@ func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOpt
 		reqData.TopP = 0.9 // default
 	}
 
+	reqData.Seed = opts.Seed
+
 	// Apply model override if provided
 	if opts.Model != "" {
 		reqData.Model = opts.Model
 	}
 
+	return reqData
+}
+
+// doRequest executes the HTTP request to Z.AI API.
+// Single place for all HTTP logic (DRY compliance).
+func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOptions) (string, Usage, error) {
+	reqData := c.buildChatRequest(messages, opts)
+
 	jsonData, err := json.Marshal(reqData)
 	if err != nil {
 		return "", Usage{}, fmt.Errorf("failed to marshal request: %w", err)
@@ -894,6 +1373,7 @@ func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOpt
 	req.Header.Set("Accept-Language", "en-US,en")
 
 	c.logger.Debug("sending request", "url", url)
+	c.logBodySize("request size", len(jsonData))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -906,8 +1386,10 @@ func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOpt
 		return "", Usage{}, fmt.Errorf("failed to read response: %w", err)
 	}
 
+	c.logBodySize("response size", len(body))
+
 	if resp.StatusCode != http.StatusOK {
-		return "", Usage{}, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+		return "", Usage{}, newAPIError(resp, body)
 	}
 
 	var chatResp ChatResponse
@@ -922,13 +1404,45 @@ func (c *Client) doRequest(ctx context.Context, messages []Message, opts ChatOpt
 	c.logger.Debug("usage",
 		"total_tokens", chatResp.Usage.TotalTokens,
 		"prompt_tokens", chatResp.Usage.PromptTokens,
-		"completion_tokens", chatResp.Usage.CompletionTokens)
+		"completion_tokens", chatResp.Usage.CompletionTokens,
+		"cached_tokens", chatResp.Usage.CachedTokens())
 
 	return chatResp.Choices[0].Message.Content, chatResp.Usage, nil
 }
 
+// logBodySize reports a body size with a rough token estimate (~4 bytes per token).
+// Logged at info level with ShowRequestSize, otherwise only in verbose mode.
+func (c *Client) logBodySize(msg string, n int) {
+	level := slog.LevelDebug
+	if c.config.ShowRequestSize {
+		level = slog.LevelInfo
+	}
+	c.logger.Log(context.Background(), level, msg,
+		"bytes", n,
+		"kb", fmt.Sprintf("%.1f", float64(n)/1024),
+		"est_tokens", n/4)
+}
+
+// defaultRequestTimeout is the HTTP timeout when ClientConfig.Timeout is unset.
+const defaultRequestTimeout = 60 * time.Second
+
+// attemptTimeout scales the base timeout by 1 + 0.5 per prior attempt,
+// so retries of slow responses get 1x, 1.5x, 2x, ... the base.
+func attemptTimeout(base time.Duration, attempt int) time.Duration {
+	return base + base*time.Duration(attempt-1)/2
+}
+
 // doRequestWithRetry executes doRequest with exponential backoff retry logic.
 func (c *Client) doRequestWithRetry(ctx context.Context, messages []Message, opts ChatOptions) (string, Usage, error) {
+	return c.withRetry(ctx, opts.RetryOnEmpty, func(ctx context.Context) (string, Usage, error) {
+		return c.doRequest(ctx, messages, opts)
+	})
+}
+
+// withRetry calls do with exponential backoff, the retry budget, and (with
+// TimeoutBackoff) a growing per-attempt timeout. retryOnEmpty treats a blank
+// response as retryable.
+func (c *Client) withRetry(ctx context.Context, retryOnEmpty bool, do func(context.Context) (string, Usage, error)) (string, Usage, error) {
 	var lastErr error
 
 	// Apply defaults for zero values
@@ -947,6 +1461,9 @@ func (c *Client) doRequestWithRetry(ctx context.Context, messages []Message, opt
 		maxBackoff = 30 * time.Second
 	}
 
+	maxElapsed := c.config.RetryConfig.MaxElapsed
+	start := time.Now()
+
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		// Check context before attempting
 		select {
@@ -958,6 +1475,12 @@ func (c *Client) doRequestWithRetry(ctx context.Context, messages []Message, opt
 		// On retry (not first attempt), log and wait
 		if attempt > 1 {
 			backoff := calculateBackoff(attempt, initialBackoff, maxBackoff)
+
+			// Stop retrying once the total budget would be exceeded
+			if maxElapsed > 0 && time.Since(start)+backoff > maxElapsed {
+				return "", Usage{}, fmt.Errorf("request failed after %d attempts (%w after %v): %w", attempt-1, ErrRetryBudgetExhausted, maxElapsed, lastErr)
+			}
+
 			c.logger.Debug("retrying request",
 				"attempt", attempt,
 				"max_attempts", maxAttempts,
@@ -971,8 +1494,25 @@ func (c *Client) doRequestWithRetry(ctx context.Context, messages []Message, opt
 			}
 		}
 
-		// Execute request
-		response, usage, err := c.doRequest(ctx, messages, opts)
+		// Execute request, with a longer deadline on each retry if enabled
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if c.config.RetryConfig.TimeoutBackoff {
+			base := c.config.Timeout
+			if base == 0 {
+				base = defaultRequestTimeout
+			}
+			timeout := attemptTimeout(base, attempt)
+			if maxElapsed > 0 {
+				timeout = min(timeout, maxElapsed-time.Since(start))
+			}
+			attemptCtx, cancel = context.WithTimeout(ctx, timeout)
+			c.logger.Debug("request timeout", "attempt", attempt, "timeout", timeout)
+		}
+		response, usage, err := do(attemptCtx)
+		cancel()
+		if err == nil && retryOnEmpty && strings.TrimSpace(response) == "" {
+			err = ErrEmptyResponse
+		}
 		if err == nil {
 			return response, usage, nil
 		}
@@ -1024,11 +1564,12 @@ func (c *Client) GenerateImage(ctx context.Context, prompt string, opts ImageOpt
 	}
 
 	reqData := ImageGenerationRequest{
-		Model:   model,
-		Prompt:  prompt,
-		Quality: opts.Quality,
-		Size:    opts.Size,
-		UserID:  opts.UserID,
+		Model:    model,
+		Prompt:   prompt,
+		Quality:  opts.Quality,
+		Size:     opts.Size,
+		UserID:   opts.UserID,
+		ImageURL: opts.ReferenceImage,
 	}
 
 	// Set defaults
@@ -1042,6 +1583,10 @@ func (c *Client) GenerateImage(ctx context.Context, prompt string, opts ImageOpt
 	var imageResp ImageResponse
 	body, err := c.executeJSONRequest(ctx, "images/generations", reqData)
 	if err != nil {
+		var apiErr *APIError
+		if opts.ReferenceImage != "" && errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			return nil, fmt.Errorf("model %s rejected the reference image (it may not support style references): %w", model, err)
+		}
 		return nil, fmt.Errorf("image generation API error: %w", err)
 	}
 	if err := json.Unmarshal(body, &imageResp); err != nil {
@@ -1060,6 +1605,83 @@ func (c *Client) GenerateImage(ctx context.Context, prompt string, opts ImageOpt
 	return &imageResp, nil
 }
 
+// Upscale methods reported in UpscaleResult.
+const (
+	UpscaleMethodAPI   = "ai"
+	UpscaleMethodLocal = "local"
+)
+
+// MaxUpscaleSide is the largest width or height UpscaleImage produces.
+const MaxUpscaleSide = 8192
+
+// UpscaleImage enlarges an image by scale. It asks the images/upscale endpoint
+// first and falls back to a local Lanczos resize when the API has no such
+// endpoint (404, 405, or 501) or no API key is configured. localOnly skips the API.
+func (c *Client) UpscaleImage(ctx context.Context, data []byte, scale int, localOnly bool) (*UpscaleResult, error) {
+	if scale < 2 {
+		return nil, fmt.Errorf("upscale factor must be at least 2, got %d", scale)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot decode image: %w", err)
+	}
+	// Compare before multiplying, so a huge factor can't overflow past the check
+	dx, dy := src.Bounds().Dx(), src.Bounds().Dy()
+	if scale > MaxUpscaleSide/max(dx, dy, 1) {
+		return nil, fmt.Errorf("upscaling %dx%d by %d would exceed the %d px limit", dx, dy, scale, MaxUpscaleSide)
+	}
+	width, height := dx*scale, dy*scale
+
+	if !localOnly && c.config.APIKey != "" {
+		result, err := c.upscaleWithAPI(ctx, data, scale)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			return result, nil
+		case !errors.As(err, &apiErr) || !isUnsupportedEndpoint(apiErr.StatusCode):
+			return nil, fmt.Errorf("image upscale API error: %w", err)
+		}
+		c.logger.Debug("upscale endpoint unavailable, resizing locally", "status", apiErr.StatusCode)
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, ResizeLanczos(src, width, height)); err != nil {
+		return nil, fmt.Errorf("failed to encode upscaled image: %w", err)
+	}
+	return &UpscaleResult{Data: buf.Bytes(), Method: UpscaleMethodLocal, Width: width, Height: height}, nil
+}
+
+// isUnsupportedEndpoint reports whether status means the API lacks the endpoint.
+func isUnsupportedEndpoint(status int) bool {
+	return status == http.StatusNotFound || status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented
+}
+
+// upscaleWithAPI sends the image to images/upscale and downloads the result.
+func (c *Client) upscaleWithAPI(ctx context.Context, data []byte, scale int) (*UpscaleResult, error) {
+	reqData := ImageUpscaleRequest{
+		Image: utils.EncodeBytesToDataURI(data, http.DetectContentType(data)),
+		Scale: scale,
+	}
+	body, err := c.executeJSONRequest(ctx, "images/upscale", reqData)
+	if err != nil {
+		return nil, err
+	}
+	var imageResp ImageResponse
+	if err := json.Unmarshal(body, &imageResp); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal upscale response: %w", err)
+	}
+	if len(imageResp.Data) == 0 {
+		return nil, fmt.Errorf("no images in response")
+	}
+
+	// The result lives on a CDN: fetch it without the API headers (auth included)
+	var out bytes.Buffer
+	if _, err := NewMediaDownloader(nil).CopyTo(&out, imageResp.Data[0].URL); err != nil {
+		return nil, fmt.Errorf("failed to download upscaled image: %w", err)
+	}
+	return &UpscaleResult{Data: out.Bytes(), Method: UpscaleMethodAPI, Width: imageResp.Data[0].Width, Height: imageResp.Data[0].Height}, nil
+}
+
 // FetchWebContent retrieves and processes web content from a URL.
 func (c *Client) FetchWebContent(ctx context.Context, url string, opts *WebReaderOptions) (*WebReaderResponse, error) { //nolint:gocognit
 	if err := c.requireAPIKey(); err != nil {
@@ -1258,13 +1880,11 @@ func (c *Client) SearchWeb(ctx context.Context, query string, opts SearchOptions
 	return &searchResp, nil
 }
 
-// Vision analyzes an image using Z.AI's vision model (glm-4.6v).
-// imageBase64 should be a data URI like "data:image/jpeg;base64,<base64-data>" or a raw base64 string.
-func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string, opts VisionOptions) (string, error) {
-	if err := c.requireAPIKey(); err != nil {
-		return "", err
-	}
+// DefaultVisionModel is used when VisionOptions.Model is empty.
+const DefaultVisionModel = "glm-4.6v"
 
+// buildVisionRequest validates inputs and builds a vision request with defaults applied.
+func buildVisionRequest(prompt string, imageBase64 string, opts VisionOptions) (VisionRequest, error) {
 	// Validate prompt
 	if prompt == "" {
 		prompt = "What do you see in this image? Please describe it in detail."
@@ -1272,13 +1892,19 @@ func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string,
 
 	// Validate image input
 	if imageBase64 == "" {
-		return "", fmt.Errorf("image data is required")
+		return VisionRequest{}, fmt.Errorf("image data is required")
+	}
+
+	switch opts.Detail {
+	case "", "low", "high", "auto":
+	default:
+		return VisionRequest{}, fmt.Errorf("invalid detail: %s (must be 'low', 'high', or 'auto')", opts.Detail)
 	}
 
 	// Build vision model
 	model := opts.Model
 	if model == "" {
-		model = "glm-4.6v" // Default vision model
+		model = DefaultVisionModel
 	}
 
 	// Build multimodal messages
@@ -1293,7 +1919,8 @@ func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string,
 				{
 					Type: "image_url",
 					ImageURL: &ImageURLContent{
-						URL: imageBase64,
+						URL:    imageBase64,
+						Detail: opts.Detail,
 					},
 				},
 			},
@@ -1326,6 +1953,21 @@ func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string,
 		reqData.TopP = 0.9
 	}
 
+	return reqData, nil
+}
+
+// Vision analyzes an image using Z.AI's vision model (glm-4.6v).
+// imageBase64 should be a data URI like "data:image/jpeg;base64,<base64-data>" or a raw base64 string.
+func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string, opts VisionOptions) (string, error) {
+	if err := c.requireAPIKey(); err != nil {
+		return "", err
+	}
+
+	reqData, err := buildVisionRequest(prompt, imageBase64, opts)
+	if err != nil {
+		return "", err
+	}
+
 	var chatResp ChatResponse
 	body, err := c.executeJSONRequest(ctx, "chat/completions", reqData)
 	if err != nil {
@@ -1347,6 +1989,28 @@ func (c *Client) Vision(ctx context.Context, prompt string, imageBase64 string,
 	return chatResp.Choices[0].Message.Content, nil
 }
 
+// VisionStream is Vision with incremental output: onDelta receives text as it arrives.
+// If the server answers with a plain JSON body instead of an event stream,
+// the full response is passed to onDelta once. Streaming requests are not retried.
+func (c *Client) VisionStream(ctx context.Context, prompt string, imageBase64 string, opts VisionOptions, onDelta func(string)) (string, error) {
+	if err := c.requireAPIKey(); err != nil {
+		return "", err
+	}
+
+	reqData, err := buildVisionRequest(prompt, imageBase64, opts)
+	if err != nil {
+		return "", err
+	}
+	reqData.Stream = true
+
+	content, usage, err := c.doStreamRequest(ctx, reqData, onDelta)
+	if err != nil {
+		return content, fmt.Errorf("vision API error: %w", err)
+	}
+	c.logger.Debug("vision stream complete", "total_tokens", usage.TotalTokens)
+	return content, nil
+}
+
 // TranscribeAudio transcribes an audio file using Z.AI's ASR model.
 func (c *Client) TranscribeAudio(ctx context.Context, audioPath string, opts TranscriptionOptions) (*TranscriptionResponse, error) { //nolint:gocyclo,funlen
 	if err := c.requireAPIKey(); err != nil {
@@ -1439,7 +2103,7 @@ func (c *Client) TranscribeAudio(ctx context.Context, audioPath string, opts Tra
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("transcription API error: %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("transcription API error: %w", newAPIError(resp, bodyBytes))
 	}
 
 	var transcriptionResp TranscriptionResponse
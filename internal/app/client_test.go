@@ -1,17 +1,28 @@
 package app
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"log/slog"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+
+	"github.com/dotcommander/zai/internal/config"
 )
 
 // TestClientChat tests the Chat method with mocked HTTP responses.
@@ -380,3 +391,686 @@ func TestCalculateBackoff(t *testing.T) {
 	assert.Greater(t, smallBackoff, 50*time.Millisecond)
 	assert.Less(t, smallBackoff, 200*time.Millisecond)
 }
+
+// TestPrependInstructions tests that shared instructions frame the prompt.
+func TestPrependInstructions(t *testing.T) {
+	assert.Equal(t, "review this", PrependInstructions("", "review this"))
+	assert.Equal(t, "review this", PrependInstructions("  \n", "review this"))
+	assert.Equal(t, "Check error handling.\n\nreview this", PrependInstructions("Check error handling.\n", "review this"))
+}
+
+// TestClientRetryBudget tests that retries stop once the total time budget is spent.
+func TestClientRetryBudget(t *testing.T) {
+	attemptCount := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attemptCount++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	config := ClientConfig{
+		APIKey:  "test-api-key",
+		BaseURL: server.URL,
+		Model:   "glm-4.7",
+		Timeout: 30 * time.Second,
+		RetryConfig: RetryConfig{
+			MaxAttempts:    10,
+			InitialBackoff: 50 * time.Millisecond,
+			MaxBackoff:     50 * time.Millisecond,
+			MaxElapsed:     120 * time.Millisecond,
+		},
+	}
+	client := NewClient(config, DiscardLogger(), nil, nil)
+
+	start := time.Now()
+	_, err := client.Chat(context.Background(), "test", DefaultChatOptions())
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "retry budget")
+	assert.Contains(t, err.Error(), "503")
+	assert.Less(t, attemptCount, 10)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.True(t, IsUnrecoverableError(err))
+}
+
+func TestIsUnrecoverableError(t *testing.T) {
+	cb := NewCircuitBreaker("chat", config.CircuitBreakerConfig{Enabled: true, FailureThreshold: 1, Timeout: time.Minute}, DiscardLogger())
+	_ = cb.Execute(func() error { return errors.New("boom") })
+	openErr := cb.Execute(func() error { return nil })
+	require.Error(t, openErr)
+
+	assert.True(t, IsUnrecoverableError(fmt.Errorf("chat: %w", openErr)))
+	assert.True(t, IsUnrecoverableError(fmt.Errorf("request failed: %w", &APIError{StatusCode: http.StatusUnauthorized})))
+	assert.True(t, IsUnrecoverableError(&APIError{StatusCode: http.StatusForbidden}))
+	assert.False(t, IsUnrecoverableError(&APIError{StatusCode: http.StatusServiceUnavailable}))
+	assert.False(t, IsUnrecoverableError(errors.New("connection reset")))
+	assert.False(t, IsUnrecoverableError(nil))
+}
+
+// TestGenerateImageReference tests that a reference image is sent and rejections are explained.
+func TestGenerateImageReference(t *testing.T) {
+	var received ImageGenerationRequest
+	reject := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received) //nolint:errcheck // test mock
+		if reject {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":"unsupported parameter image_url"}`) //nolint:errcheck // test mock
+			return
+		}
+		json.NewEncoder(w).Encode(ImageResponse{Data: []ImageData{{URL: "https://img/1.png"}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), nil, nil)
+	opts := ImageOptions{ReferenceImage: "data:image/png;base64,AAAA"}
+
+	_, err := client.GenerateImage(context.Background(), "a castle", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "data:image/png;base64,AAAA", received.ImageURL)
+
+	reject = true
+	_, err = client.GenerateImage(context.Background(), "a castle", opts)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rejected the reference image")
+}
+
+// TestUpscaleImageFallback tests that a missing upscale endpoint falls back to a local resize.
+func TestUpscaleImageFallback(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		assert.Equal(t, "/images/upscale", r.URL.Path)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 6))))
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), nil, nil)
+
+	result, err := client.UpscaleImage(context.Background(), buf.Bytes(), 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, UpscaleMethodLocal, result.Method)
+	assert.Equal(t, 16, result.Width)
+	assert.Equal(t, 12, result.Height)
+
+	_, err = client.UpscaleImage(context.Background(), buf.Bytes(), 2, true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls, "--local must not call the API")
+
+	_, err = client.UpscaleImage(context.Background(), buf.Bytes(), 4096, true)
+	assert.ErrorContains(t, err, "px limit")
+
+	_, err = client.UpscaleImage(context.Background(), buf.Bytes(), math.MaxInt/4, true)
+	assert.ErrorContains(t, err, "px limit", "an overflowing factor is rejected, not wrapped")
+}
+
+// TestUpscaleImageDownloadHeaders tests that the upscaled image is fetched without the API headers.
+func TestUpscaleImageDownloadHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 8, 6))))
+
+	var apiHeaders, cdnHeaders http.Header
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cdn/up.png" {
+			cdnHeaders = r.Header.Clone()
+			w.Write(buf.Bytes()) //nolint:errcheck // test mock
+			return
+		}
+		apiHeaders = r.Header.Clone()
+		json.NewEncoder(w).Encode(ImageResponse{Data: []ImageData{{URL: server.URL + "/cdn/up.png", Width: 16, Height: 12}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		APIKey:  "test-api-key",
+		BaseURL: server.URL,
+		Headers: map[string]string{"X-Team": "ml", "Authorization": "Bearer override"},
+	}, DiscardLogger(), nil, nil)
+
+	result, err := client.UpscaleImage(context.Background(), buf.Bytes(), 2, false)
+	require.NoError(t, err)
+	assert.Equal(t, UpscaleMethodAPI, result.Method)
+	assert.Equal(t, buf.Bytes(), result.Data)
+
+	assert.Equal(t, "ml", apiHeaders.Get("X-Team"))
+	require.NotNil(t, cdnHeaders)
+	assert.Empty(t, cdnHeaders.Get("X-Team"))
+	assert.Empty(t, cdnHeaders.Get("Authorization"))
+}
+
+// TestClientVisionDetail tests that the detail level is sent and validated.
+func TestClientVisionDetail(t *testing.T) {
+	var received VisionRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received) //nolint:errcheck // test mock
+		resp := ChatResponse{Choices: []Choice{{Message: Message{Content: "a cat"}}}}
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), nil, nil)
+
+	_, err := client.Vision(context.Background(), "describe", "data:image/png;base64,AAAA", VisionOptions{Detail: "low"})
+	require.NoError(t, err)
+	require.Len(t, received.Messages, 1)
+	require.Len(t, received.Messages[0].Content, 2)
+	assert.Equal(t, "low", received.Messages[0].Content[1].ImageURL.Detail)
+
+	_, err = client.Vision(context.Background(), "describe", "data:image/png;base64,AAAA", VisionOptions{Detail: "ultra"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid detail")
+}
+
+// TestClientAPIVersionHeader tests that X-API-Version is sent only when configured.
+func TestClientAPIVersionHeader(t *testing.T) {
+	var header string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header.Get("X-API-Version")
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, APIVersion: "2025-01-01"}, DiscardLogger(), nil, nil)
+	_, err := client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-01", header)
+
+	client = NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), nil, nil)
+	_, err = client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Empty(t, header)
+}
+
+// TestParseHeaderLines tests header file parsing and that headers reach the request.
+func TestParseHeaderLines(t *testing.T) {
+	headers, dups, err := ParseHeaderLines("# gateway\nx-gateway-token: abc\n\nX-Tenant:  acme \nX-GATEWAY-TOKEN: def:ghi\n")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"X-Gateway-Token": "def:ghi", "X-Tenant": "acme"}, headers)
+	assert.Equal(t, []string{"X-Gateway-Token"}, dups)
+
+	_, _, err = ParseHeaderLines("X-Ok: 1\nno colon here")
+	assert.ErrorContains(t, err, "line 2")
+	_, _, err = ParseHeaderLines("Bad Name: 1")
+	assert.Error(t, err)
+
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Headers: headers}, DiscardLogger(), nil, nil)
+	_, err = client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "acme", got.Get("X-Tenant"))
+	assert.Equal(t, "Bearer test-api-key", got.Get("Authorization"))
+}
+
+// TestClientCompressFile tests embedding a file summary, with truncation as fallback.
+func TestClientCompressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "big.txt")
+	content := strings.Repeat("line of a large file\n", 50)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	failSummary := false
+	var lastUser string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		if reqData.Model == DefaultCompressModel {
+			if failSummary {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "SUMMARY"}}}}) //nolint:errcheck // test mock
+			return
+		}
+		lastUser = reqData.Messages[len(reqData.Messages)-1].Content
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.FilePath = path
+	opts.CompressFile = true
+
+	_, err := client.Chat(context.Background(), "explain", opts)
+	require.NoError(t, err)
+	assert.Contains(t, lastUser, "File summary")
+	assert.Contains(t, lastUser, "SUMMARY")
+	assert.NotContains(t, lastUser, "line of a large file")
+
+	failSummary = true
+	_, err = client.Chat(context.Background(), "explain", opts)
+	require.NoError(t, err)
+	assert.Contains(t, lastUser, "line of a large file")
+}
+
+func TestClientCachePrefix(t *testing.T) {
+	dir := t.TempDir()
+	big := filepath.Join(dir, "big.txt")
+	require.NoError(t, os.WriteFile(big, []byte(strings.Repeat("stable context line\n", 300)), 0600))
+	small := filepath.Join(dir, "small.txt")
+	require.NoError(t, os.WriteFile(small, []byte("tiny"), 0600))
+
+	var messages []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		messages = reqData.Messages
+		resp := ChatResponse{
+			Choices: []Choice{{Message: Message{Content: "ok"}}},
+			Usage:   Usage{PromptTokens: 1200, PromptTokensDetails: &PromptTokensDetails{CachedTokens: 1024}},
+		}
+		json.NewEncoder(w).Encode(resp) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.FilePath = big
+	opts.CachePrefix = true
+	opts.Context = []Message{{Role: "user", Content: "earlier"}, {Role: "assistant", Content: "reply"}}
+
+	_, err := client.Chat(context.Background(), "question one", opts)
+	require.NoError(t, err)
+	require.Len(t, messages, 4)
+	assert.Equal(t, "system", messages[0].Role)
+	assert.Contains(t, messages[0].Content, "stable context line")
+	assert.Equal(t, "earlier", messages[1].Content)
+	assert.Equal(t, "question one", messages[3].Content)
+	firstSystem := messages[0].Content
+
+	_, err = client.Chat(context.Background(), "question two", opts)
+	require.NoError(t, err)
+	assert.Equal(t, firstSystem, messages[0].Content)
+
+	// Small prefixes are not worth caching and stay in the user message
+	opts.FilePath = small
+	opts.Context = nil
+	_, err = client.Chat(context.Background(), "question three", opts)
+	require.NoError(t, err)
+	assert.Equal(t, defaultSystemPrompt, messages[0].Content)
+	assert.Contains(t, messages[1].Content, "tiny")
+}
+
+func TestClientApplyCachePrefixMultiTurn(t *testing.T) {
+	big := filepath.Join(t.TempDir(), "big.txt")
+	require.NoError(t, os.WriteFile(big, []byte(strings.Repeat("stable context line\n", 300)), 0600))
+
+	var systems []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		systems = append(systems, reqData.Messages[0].Content)
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.FilePath = big
+	opts.CachePrefix = true
+
+	opts, err := client.ApplyCachePrefix(context.Background(), opts)
+	require.NoError(t, err)
+	assert.Empty(t, opts.FilePath)
+
+	// Like the REPL: later turns carry the conversation and drop the file
+	_, err = client.Chat(context.Background(), "question one", opts)
+	require.NoError(t, err)
+	turn2 := opts
+	turn2.FilePath = ""
+	turn2.Context = []Message{{Role: "user", Content: "question one"}, {Role: "assistant", Content: "ok"}}
+	_, err = client.Chat(context.Background(), "question two", turn2)
+	require.NoError(t, err)
+
+	require.Len(t, systems, 2)
+	assert.Contains(t, systems[0], "stable context line")
+	assert.Equal(t, systems[0], systems[1])
+
+	// Too short to cache: options are left for the first user message
+	small := DefaultChatOptions()
+	small.FilePath = filepath.Join(t.TempDir(), "small.txt")
+	require.NoError(t, os.WriteFile(small.FilePath, []byte("tiny"), 0600))
+	small.CachePrefix = true
+	resolved, err := client.ApplyCachePrefix(context.Background(), small)
+	require.NoError(t, err)
+	assert.Equal(t, small.FilePath, resolved.FilePath)
+	assert.Empty(t, resolved.SystemPrompt)
+}
+
+func TestClientRetryOnEmpty(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		content := ""
+		if calls > 1 {
+			content = "full answer"
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: content}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		APIKey:  "test-api-key",
+		BaseURL: server.URL,
+		Model:   "glm-4.7",
+		RetryConfig: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     10 * time.Millisecond,
+		},
+	}, DiscardLogger(), nil, nil)
+
+	// Without the option an empty response is returned as-is
+	resp, err := client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Empty(t, resp)
+	assert.Equal(t, 1, calls)
+
+	calls = 0
+	opts := DefaultChatOptions()
+	opts.RetryOnEmpty = true
+	resp, err = client.Chat(context.Background(), "hi", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "full answer", resp)
+	assert.Equal(t, 2, calls)
+}
+
+func TestClientTimeoutBackoff(t *testing.T) {
+	var timeouts []time.Duration
+	mockHTTP := new(MockHTTPDoer)
+	mockHTTP.On("Do", mock.Anything).Return(nil, context.DeadlineExceeded).Run(func(args mock.Arguments) {
+		deadline, ok := args.Get(0).(*http.Request).Context().Deadline()
+		require.True(t, ok, "each attempt should carry a deadline")
+		// Round to absorb the time spent building the request
+		timeouts = append(timeouts, time.Until(deadline).Round(100*time.Millisecond))
+	})
+
+	client := NewClient(ClientConfig{
+		APIKey:  "test-api-key",
+		BaseURL: "https://api.example.com",
+		Model:   "glm-4.7",
+		Timeout: time.Second,
+		RetryConfig: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+			TimeoutBackoff: true,
+		},
+	}, DiscardLogger(), nil, mockHTTP)
+
+	_, err := client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.Error(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 1500 * time.Millisecond, 2 * time.Second}, timeouts)
+}
+
+func TestClientTimeoutBackoffKeepsHTTPTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release // Stalled server
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := NewClient(ClientConfig{
+		APIKey:      "test-api-key",
+		BaseURL:     server.URL,
+		Timeout:     100 * time.Millisecond,
+		RetryConfig: RetryConfig{MaxAttempts: 2, TimeoutBackoff: true},
+	}, DiscardLogger(), nil, nil)
+
+	// Requests outside the chat retry loop still time out (at the longest retry deadline)
+	start := time.Now()
+	_, err := client.ListModels(context.Background())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+}
+
+func TestClientWebContentCache(t *testing.T) {
+	readerCalls := 0
+	var lastUser string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/reader") {
+			readerCalls++
+			json.NewEncoder(w).Encode(WebReaderResponse{ReaderResult: ReaderResult{Title: "Docs", Content: "page body"}}) //nolint:errcheck // test mock
+			return
+		}
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		lastUser = reqData.Messages[len(reqData.Messages)-1].Content
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.WebCache = NewWebContentCache()
+
+	for range 2 {
+		_, err := client.Chat(context.Background(), "summarize https://example.com/docs", opts)
+		require.NoError(t, err)
+		assert.Contains(t, lastUser, "page body")
+	}
+	assert.Equal(t, 1, readerCalls)
+
+	opts.WebCache.Delete("https://example.com/docs")
+	_, err := client.Chat(context.Background(), "again https://example.com/docs", opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, readerCalls)
+}
+
+func TestClientShowRequestSize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	var logs strings.Builder
+	logger := slog.New(slog.NewTextHandler(&logs, nil))
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7", ShowRequestSize: true}, logger, nil, nil)
+
+	_, err := client.Chat(context.Background(), strings.Repeat("x", 4000), DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "request size")
+	assert.Contains(t, logs.String(), "response size")
+	assert.Regexp(t, `"request size" bytes=4\d{3} kb=\d+\.\d est_tokens=1\d{3}`, logs.String())
+
+	// Streamed chat and vision report sizes too (the mock answers with buffered JSON)
+	for _, stream := range []func() error{
+		func() error {
+			_, err := client.ChatStream(context.Background(), "hi", DefaultChatOptions(), nil)
+			return err
+		},
+		func() error {
+			_, err := client.VisionStream(context.Background(), "describe", "data:image/png;base64,AAAA", VisionOptions{}, nil)
+			return err
+		},
+	} {
+		logs.Reset()
+		require.NoError(t, stream())
+		assert.Contains(t, logs.String(), "request size")
+		assert.Contains(t, logs.String(), "response size")
+	}
+}
+
+func TestClientContextFollowsSystemMessage(t *testing.T) {
+	var messages []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		messages = reqData.Messages
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.Context = []Message{{Role: "user", Content: "piped stdin"}}
+
+	_, err := client.Chat(context.Background(), "summarize the above", opts)
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+	assert.Equal(t, "system", messages[0].Role)
+	assert.Equal(t, Message{Role: "user", Content: "piped stdin"}, messages[1])
+	assert.Equal(t, Message{Role: "user", Content: "summarize the above"}, messages[2])
+}
+
+func TestClientSystemPrompt(t *testing.T) {
+	var messages []Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var reqData ChatRequest
+		json.NewDecoder(r.Body).Decode(&reqData) //nolint:errcheck // test mock
+		messages = reqData.Messages
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7", SystemPrompt: "Answer in depth."}, DiscardLogger(), nil, nil)
+
+	// The configured prompt replaces the default
+	_, err := client.Chat(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Equal(t, Message{Role: "system", Content: "Answer in depth."}, messages[0])
+
+	// A per-request prompt wins over the configured one
+	opts := DefaultChatOptions()
+	opts.SystemPrompt = "Be a pirate."
+	_, err = client.Chat(context.Background(), "hi", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "Be a pirate.", messages[0].Content)
+
+	// A system message in the context is not joined by a second one
+	opts.Context = []Message{{Role: "system", Content: "Enhance image prompts."}}
+	_, err = client.Chat(context.Background(), "a fox", opts)
+	require.NoError(t, err)
+	assert.Equal(t, []Message{
+		{Role: "system", Content: "Enhance image prompts."},
+		{Role: "user", Content: "a fox"},
+	}, messages)
+}
+
+func TestClientSystemMerge(t *testing.T) {
+	cfg := ClientConfig{SystemPrompt: "Project rules.", SystemFile: "Review rubric.\n"}
+
+	replace := NewClient(cfg, DiscardLogger(), nil, nil)
+	assert.Equal(t, "Be terse.", replace.systemPrompt("Be terse."))
+	assert.Equal(t, "Review rubric.", replace.systemPrompt(""))
+
+	cfg.SystemMerge = SystemMergeAppend
+	appendClient := NewClient(cfg, DiscardLogger(), nil, nil)
+	assert.Equal(t, "Project rules.\n\nReview rubric.\n\nBe terse.", appendClient.systemPrompt("Be terse."))
+
+	empty := NewClient(ClientConfig{SystemMerge: SystemMergeAppend}, DiscardLogger(), nil, nil)
+	assert.Equal(t, defaultSystemPrompt, empty.systemPrompt(""))
+}
+
+func TestClientChatWithUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{ //nolint:errcheck // test mock
+			Choices: []Choice{{Message: Message{Content: "ok"}}},
+			Usage:   Usage{PromptTokens: 1500, CompletionTokens: 500, TotalTokens: 2000},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	response, usage, err := client.ChatWithUsage(context.Background(), "hi", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.Equal(t, "ok", response)
+	assert.Equal(t, 2000, usage.TotalTokens)
+	assert.InDelta(t, 0.0025, usage.Cost(0.001, 0.002), 1e-9)
+}
+
+func TestClientSendsZeroTemperatureAndSeed(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)                                                         //nolint:errcheck // test mock
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+	opts.Temperature = Float64Ptr(0)
+	opts.TopP = Float64Ptr(1)
+	opts.Seed = IntPtr(42)
+
+	_, err := client.Chat(context.Background(), "hello", opts)
+	require.NoError(t, err)
+	assert.Equal(t, 0.0, body["temperature"])
+	assert.Equal(t, 1.0, body["top_p"])
+	assert.Equal(t, 42.0, body["seed"])
+
+	_, err = client.Chat(context.Background(), "hello", DefaultChatOptions())
+	require.NoError(t, err)
+	assert.NotContains(t, body, "seed")
+}
+
+func TestClientChatReportsUsage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(ChatResponse{ //nolint:errcheck // test mock
+			Choices: []Choice{{Message: Message{Content: "ok"}}},
+			Usage:   Usage{PromptTokens: 12, CompletionTokens: 3, TotalTokens: 15},
+		})
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	var usage Usage
+	opts := DefaultChatOptions()
+	opts.OnUsage = func(u Usage) { usage = u }
+
+	_, err := client.Chat(context.Background(), "hello", opts)
+	require.NoError(t, err)
+	assert.Equal(t, 15, usage.TotalTokens)
+	assert.Equal(t, 12, usage.PromptTokens)
+}
+
+func TestClientWebFormat(t *testing.T) {
+	var format string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/reader") {
+			var reqData WebReaderRequest
+			json.NewDecoder(r.Body).Decode(&reqData)                                                                 //nolint:errcheck // test mock
+			json.NewEncoder(w).Encode(WebReaderResponse{ReaderResult: ReaderResult{Title: "Docs", Content: "body"}}) //nolint:errcheck // test mock
+			format = reqData.ReturnFormat
+			return
+		}
+		json.NewEncoder(w).Encode(ChatResponse{Choices: []Choice{{Message: Message{Content: "ok"}}}}) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL, Model: "glm-4.7"}, DiscardLogger(), nil, nil)
+	opts := DefaultChatOptions()
+
+	_, err := client.Chat(context.Background(), "summarize https://example.com/docs", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "markdown", format)
+
+	opts.WebFormat = "text"
+	_, err = client.Chat(context.Background(), "summarize https://example.com/docs", opts)
+	require.NoError(t, err)
+	assert.Equal(t, "text", format)
+}
+
+func TestNewAPIErrorRequestID(t *testing.T) {
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	assert.Equal(t, "req-body", newAPIError(resp, []byte(`{"request_id":"req-body"}`)).RequestID)
+	assert.Equal(t, "req-nested", newAPIError(resp, []byte(`{"error":{"code":"1302","request_id":"req-nested"}}`)).RequestID)
+	assert.Empty(t, newAPIError(resp, []byte(`{"error":"rate limited"}`)).RequestID)
+	assert.Empty(t, newAPIError(resp, []byte(`not json`)).RequestID)
+
+	resp.Header.Set("X-Request-Id", "req-header")
+	apiErr := newAPIError(resp, []byte(`{"request_id":"req-body"}`))
+	assert.Equal(t, "req-header", apiErr.RequestID)
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+}
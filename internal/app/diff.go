@@ -0,0 +1,274 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines surround each hunk in UnifiedDiff.
+const diffContext = 3
+
+// diffOp is one line of a line diff: ' ' unchanged, '-' removed, '+' added.
+// aLine and bLine are the 0-based positions in each input before this line.
+type diffOp struct {
+	kind  byte
+	text  string
+	aLine int
+	bLine int
+}
+
+// UnifiedDiff returns a unified diff of oldText and newText with the given
+// file labels, or "" when they have the same lines.
+func UnifiedDiff(oldLabel, newLabel, oldText, newText string) string {
+	ops := diffLines(splitLines(oldText), splitLines(newText))
+
+	var changed []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changed = append(changed, i)
+		}
+	}
+	if len(changed) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldLabel, newLabel)
+	for start := 0; start < len(changed); {
+		// Extend the hunk while the next change is within two contexts
+		end := start
+		for end+1 < len(changed) && changed[end+1]-changed[end] <= 2*diffContext {
+			end++
+		}
+		from := max(changed[start]-diffContext, 0)
+		to := min(changed[end]+diffContext+1, len(ops))
+		writeHunk(&sb, ops[from:to])
+		start = end + 1
+	}
+	return sb.String()
+}
+
+// writeHunk writes one "@@ -a,n +b,m @@" hunk.
+func writeHunk(sb *strings.Builder, ops []diffOp) {
+	oldCount, newCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			oldCount++
+		}
+		if op.kind != '-' {
+			newCount++
+		}
+	}
+	fmt.Fprintf(sb, "@@ -%s +%s @@\n", hunkRange(ops[0].aLine, oldCount), hunkRange(ops[0].bLine, newCount))
+	for _, op := range ops {
+		sb.WriteByte(op.kind)
+		sb.WriteString(op.text)
+		sb.WriteByte('\n')
+	}
+}
+
+// hunkRange formats a hunk range; an empty range starts at the line before it.
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// maxDiffTableCells bounds the LCS table diffTable may allocate (16 MB of
+// int32). Larger inputs are split with Hirschberg's linear-space method.
+const maxDiffTableCells = 4 << 20
+
+// diffLines computes a line diff from the longest common subsequence of a and b.
+// The common prefix and suffix are matched up front, so mostly-identical
+// inputs only pay for the part that changed.
+func diffLines(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for i := range prefix {
+		ops = append(ops, diffOp{kind: ' ', text: a[i], aLine: i, bLine: i})
+	}
+
+	// Compare line IDs rather than strings in the quadratic part
+	ids := map[string]int{}
+	intern := func(lines []string) []int {
+		out := make([]int, len(lines))
+		for i, line := range lines {
+			id, ok := ids[line]
+			if !ok {
+				id = len(ids)
+				ids[line] = id
+			}
+			out[i] = id
+		}
+		return out
+	}
+	d := &lineDiff{a: a, b: b, ops: ops}
+	d.diff(intern(a[prefix:len(a)-suffix]), intern(b[prefix:len(b)-suffix]), prefix, prefix)
+
+	for k := range suffix {
+		i, j := len(a)-suffix+k, len(b)-suffix+k
+		d.ops = append(d.ops, diffOp{kind: ' ', text: a[i], aLine: i, bLine: j})
+	}
+	return d.ops
+}
+
+// lineDiff accumulates the ops for a and b. The diff methods work on line
+// IDs of a sub-range starting at aOff and bOff.
+type lineDiff struct {
+	a, b []string
+	ops  []diffOp
+}
+
+func (d *lineDiff) keep(i, j int) {
+	d.ops = append(d.ops, diffOp{kind: ' ', text: d.a[i], aLine: i, bLine: j})
+}
+func (d *lineDiff) remove(i, j int) {
+	d.ops = append(d.ops, diffOp{kind: '-', text: d.a[i], aLine: i, bLine: j})
+}
+func (d *lineDiff) add(i, j int) {
+	d.ops = append(d.ops, diffOp{kind: '+', text: d.b[j], aLine: i, bLine: j})
+}
+
+// diff emits the ops turning a into b, splitting the problem in half
+// (Hirschberg) while the LCS table would be too large.
+func (d *lineDiff) diff(a, b []int, aOff, bOff int) {
+	n, m := len(a), len(b)
+	switch {
+	case n == 0:
+		for j := range m {
+			d.add(aOff, bOff+j)
+		}
+		return
+	case m == 0:
+		for i := range n {
+			d.remove(aOff+i, bOff)
+		}
+		return
+	case (n+1)*(m+1) <= maxDiffTableCells:
+		d.table(a, b, aOff, bOff)
+		return
+	case n == 1:
+		d.single(a[0], b, aOff, bOff)
+		return
+	}
+
+	// Split b where the LCS of the two halves of a is longest
+	mid := n / 2
+	front := lcsLengths(a[:mid], b)
+	back := lcsSuffixLengths(a[mid:], b)
+	split, best := 0, int32(-1)
+	for j := 0; j <= m; j++ {
+		if l := front[j] + back[j]; l > best {
+			split, best = j, l
+		}
+	}
+	d.diff(a[:mid], b[:split], aOff, bOff)
+	d.diff(a[mid:], b[split:], aOff+mid, bOff+split)
+}
+
+// single diffs one line of a against b.
+func (d *lineDiff) single(line int, b []int, aOff, bOff int) {
+	match := -1
+	for j, id := range b {
+		if id == line {
+			match = j
+			break
+		}
+	}
+	if match < 0 {
+		d.remove(aOff, bOff)
+		for j := range b {
+			d.add(aOff+1, bOff+j)
+		}
+		return
+	}
+	for j := range match {
+		d.add(aOff, bOff+j)
+	}
+	d.keep(aOff, bOff+match)
+	for j := match + 1; j < len(b); j++ {
+		d.add(aOff+1, bOff+j)
+	}
+}
+
+// table diffs a and b with a full LCS table.
+func (d *lineDiff) table(a, b []int, aOff, bOff int) {
+	n, m := len(a), len(b)
+	// lcs[i*(m+1)+j] is the LCS length of a[i:] and b[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else {
+				lcs[i*(m+1)+j] = max(lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && a[i] == b[j]:
+			d.keep(aOff+i, bOff+j)
+			i++
+			j++
+		case j == m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			d.remove(aOff+i, bOff+j)
+			i++
+		default:
+			d.add(aOff+i, bOff+j)
+			j++
+		}
+	}
+}
+
+// lcsLengths returns, for each j, the LCS length of a and b[:j], in O(len(b)) space.
+func lcsLengths(a, b []int) []int32 {
+	prev, cur := make([]int32, len(b)+1), make([]int32, len(b)+1)
+	for _, x := range a {
+		for j, y := range b {
+			if x == y {
+				cur[j+1] = prev[j] + 1
+			} else {
+				cur[j+1] = max(prev[j+1], cur[j])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// lcsSuffixLengths returns, for each j, the LCS length of a and b[j:].
+func lcsSuffixLengths(a, b []int) []int32 {
+	m := len(b)
+	prev, cur := make([]int32, m+1), make([]int32, m+1)
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				cur[j] = prev[j+1] + 1
+			} else {
+				cur[j] = max(prev[j], cur[j+1])
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev
+}
+
+// splitLines splits text into lines, ignoring a trailing newline.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
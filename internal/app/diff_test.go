@@ -0,0 +1,83 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Empty(t, UnifiedDiff("a", "b", "same\ntext\n", "same\ntext"))
+
+	oldText := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	newText := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n16\n"
+	want := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -13,3 +13,4 @@
+ 13
+ 14
+ 15
++16
+`
+	assert.Equal(t, want, UnifiedDiff("old", "new", oldText, newText))
+
+	assert.Equal(t, "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+first\n", UnifiedDiff("old", "new", "", "first"))
+}
+
+func TestUnifiedDiffLarge(t *testing.T) {
+	// Changes near both ends leave a middle far too big for a full LCS table
+	const lines = 5000
+	oldLines := make([]string, lines)
+	for i := range oldLines {
+		oldLines[i] = fmt.Sprintf("line %d", i%50) // Repeats, like boilerplate in docs
+	}
+	newLines := slices.Clone(oldLines)
+	newLines[10] = "changed near the top"
+	newLines = slices.Insert(newLines, 4990, "inserted near the bottom")
+	oldText, newText := strings.Join(oldLines, "\n"), strings.Join(newLines, "\n")
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	diff := UnifiedDiff("old", "new", oldText, newText)
+	runtime.ReadMemStats(&after)
+
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(32<<20), "a full table would need ~100 MB")
+	assert.Contains(t, diff, "@@ -8,7 +8,7 @@\n line 7\n line 8\n line 9\n-line 10\n+changed near the top\n")
+	assert.Contains(t, diff, "+inserted near the bottom\n")
+	assert.Equal(t, 2, strings.Count(diff, "\n+")-strings.Count(diff, "\n+++"))
+	assert.Equal(t, 1, strings.Count(diff, "\n-line"))
+}
+
+func TestWebSnapshotStore(t *testing.T) {
+	store := NewWebSnapshotStore(t.TempDir())
+
+	_, ok, err := store.Load("https://example.com", "markdown")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	fetched := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	assert.NoError(t, store.Save(WebSnapshot{URL: "https://example.com", Format: "markdown", Content: "v1", FetchedAt: fetched}))
+
+	snapshot, ok, err := store.Load("https://example.com", "markdown")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "v1", snapshot.Content)
+	assert.True(t, fetched.Equal(snapshot.FetchedAt))
+
+	_, ok, err = store.Load("https://example.com", "text")
+	assert.NoError(t, err)
+	assert.False(t, ok, "snapshots are kept per format")
+}
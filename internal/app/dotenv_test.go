@@ -0,0 +1,56 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDotenv(t *testing.T) {
+	input := `# secrets for local dev
+ZAI_API_KEY=abc123
+export ZAI_API_MODEL = glm-4.7
+EMPTY=
+PLAIN=value # trailing comment
+SINGLE='literal \n $HOME'
+DOUBLE="line1\nline2 \"quoted\""
+HASH="a # not a comment"
+`
+	vars, err := ParseDotenv(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"ZAI_API_KEY":   "abc123",
+		"ZAI_API_MODEL": "glm-4.7",
+		"EMPTY":         "",
+		"PLAIN":         "value",
+		"SINGLE":        `literal \n $HOME`,
+		"DOUBLE":        "line1\nline2 \"quoted\"",
+		"HASH":          "a # not a comment",
+	}, vars)
+
+	_, err = ParseDotenv(strings.NewReader("OK=1\nnot a pair\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = ParseDotenv(strings.NewReader(`KEY="unterminated`))
+	require.Error(t, err)
+}
+
+func TestLoadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("ZAI_TEST_FROM_FILE=file\nZAI_TEST_PRESET=file\n"), 0600))
+
+	t.Setenv("ZAI_TEST_PRESET", "env")
+	t.Setenv("ZAI_TEST_FROM_FILE", "")
+	require.NoError(t, os.Unsetenv("ZAI_TEST_FROM_FILE"))
+
+	require.NoError(t, LoadEnvFile(path))
+	assert.Equal(t, "file", os.Getenv("ZAI_TEST_FROM_FILE"))
+	assert.Equal(t, "env", os.Getenv("ZAI_TEST_PRESET"))
+
+	assert.Error(t, LoadEnvFile(filepath.Join(t.TempDir(), "missing.env")))
+}
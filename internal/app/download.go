@@ -1,13 +1,42 @@
 package app
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// MediaURLLifetime is how long generated image and video URLs stay downloadable.
+const MediaURLLifetime = 30 * 24 * time.Hour
+
+// ErrMediaExpired reports a generated media URL that is no longer served.
+var ErrMediaExpired = errors.New("this generated media URL has expired; regenerate to get a fresh one")
+
+// generatedMediaHosts are the domains Z.AI serves generated media from.
+var generatedMediaHosts = []string{"bigmodel.cn", "z.ai"}
+
+// IsGeneratedMediaURL reports whether raw points at Z.AI-hosted generated media.
+func IsGeneratedMediaURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, h := range generatedMediaHosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
 // closeBodyResponse closes the response body and logs any error.
 func closeBodyResponse(resp *http.Response) {
 	if err := resp.Body.Close(); err != nil {
@@ -18,6 +47,9 @@ func closeBodyResponse(resp *http.Response) {
 // MediaDownloader handles downloading media files with DI support.
 type MediaDownloader struct {
 	httpClient HTTPDoer
+
+	// FileMode is applied to downloaded files; 0 keeps the default (0666 before umask).
+	FileMode os.FileMode
 }
 
 // NewMediaDownloader creates a MediaDownloader with the provided HTTP client.
@@ -42,27 +74,74 @@ func (d *MediaDownloader) Download(url, filePath string) *DownloadResult {
 		return &DownloadResult{FilePath: filePath, Error: err}
 	}
 
+	resp, err := d.get(url)
+	if err != nil {
+		return &DownloadResult{FilePath: filePath, Error: err}
+	}
+	defer closeBodyResponse(resp)
+
+	size, err := writeToFile(filePath, resp.Body, d.FileMode)
+	if err != nil {
+		return &DownloadResult{FilePath: filePath, Error: err}
+	}
+
+	return &DownloadResult{FilePath: filePath, Size: size, Error: nil}
+}
+
+// CopyTo fetches a URL and streams the body to w, returning bytes written.
+func (d *MediaDownloader) CopyTo(w io.Writer, url string) (int64, error) {
+	resp, err := d.get(url)
+	if err != nil {
+		return 0, err
+	}
+	defer closeBodyResponse(resp)
+
+	size, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return size, fmt.Errorf("write output: %w", err)
+	}
+	return size, nil
+}
+
+// get requests a URL and returns the response if it is 200 OK.
+// The caller closes the body.
+func (d *MediaDownloader) get(url string) (*http.Response, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
-		return &DownloadResult{FilePath: filePath, Error: fmt.Errorf("create request: %w", err)}
+		return nil, fmt.Errorf("create request: %w", err)
 	}
 
 	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return &DownloadResult{FilePath: filePath, Error: fmt.Errorf("download: %w", err)}
+		return nil, fmt.Errorf("download: %w", err)
 	}
-	defer closeBodyResponse(resp)
 
 	if resp.StatusCode != http.StatusOK {
-		return &DownloadResult{FilePath: filePath, Error: fmt.Errorf("download failed: status %d", resp.StatusCode)}
+		closeBodyResponse(resp)
+		return nil, downloadStatusError(url, resp.StatusCode)
 	}
+	return resp, nil
+}
 
-	size, err := writeToFile(filePath, resp.Body)
-	if err != nil {
-		return &DownloadResult{FilePath: filePath, Error: err}
+// downloadStatusError explains a failed download. Generated media that is
+// forbidden or gone has almost certainly passed its expiry.
+func downloadStatusError(url string, status int) error {
+	switch status {
+	case http.StatusForbidden, http.StatusNotFound, http.StatusGone:
+		if IsGeneratedMediaURL(url) {
+			return fmt.Errorf("download failed: status %d: %w", status, ErrMediaExpired)
+		}
 	}
+	return fmt.Errorf("download failed: status %d", status)
+}
 
-	return &DownloadResult{FilePath: filePath, Size: size, Error: nil}
+// ParseFileMode parses an octal permission string such as "0600" or "644".
+func ParseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(strings.TrimSpace(s), 8, 32)
+	if err != nil || mode > 0o777 {
+		return 0, fmt.Errorf("invalid file mode %q (use octal permissions like 0600 or 0644)", s)
+	}
+	return os.FileMode(mode), nil
 }
 
 // ensureDir creates the parent directory for a file path if needed.
@@ -77,13 +156,20 @@ func ensureDir(filePath string) error {
 }
 
 // writeToFile writes reader content to a file and returns bytes written.
-func writeToFile(filePath string, r io.Reader) (int64, error) {
+// A non-zero mode is also applied to an existing file being overwritten.
+func writeToFile(filePath string, r io.Reader, mode os.FileMode) (int64, error) {
 	out, err := os.Create(filePath)
 	if err != nil {
 		return 0, fmt.Errorf("create file: %w", err)
 	}
 	defer closeFile(out)
 
+	if mode != 0 {
+		if err := out.Chmod(mode); err != nil {
+			return 0, fmt.Errorf("chmod file: %w", err)
+		}
+	}
+
 	size, err := io.Copy(out, r)
 	if err != nil {
 		return 0, fmt.Errorf("write file: %w", err)
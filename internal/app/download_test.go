@@ -0,0 +1,113 @@
+package app
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaDownloaderExpiredURL(t *testing.T) {
+	tests := []struct {
+		name        string
+		url         string
+		status      int
+		wantExpired bool
+	}{
+		{name: "generated media forbidden", url: "https://aigc-files.bigmodel.cn/api/cogview/abc.png", status: http.StatusForbidden, wantExpired: true},
+		{name: "generated media not found", url: "https://cdn.z.ai/media/abc.mp4", status: http.StatusNotFound, wantExpired: true},
+		{name: "other host", url: "https://example.com/abc.png", status: http.StatusNotFound},
+		{name: "server error", url: "https://cdn.z.ai/media/abc.mp4", status: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doer := new(MockHTTPDoer)
+			doer.On("Do", mock.Anything).Return(&http.Response{
+				StatusCode: tt.status,
+				Body:       io.NopCloser(strings.NewReader("")),
+			}, nil)
+
+			result := NewMediaDownloader(doer).Download(tt.url, filepath.Join(t.TempDir(), "out"))
+			assert.Error(t, result.Error)
+			assert.Equal(t, tt.wantExpired, errors.Is(result.Error, ErrMediaExpired))
+		})
+	}
+}
+
+func TestMediaDownloaderFileMode(t *testing.T) {
+	doer := new(MockHTTPDoer)
+	doer.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("png bytes")),
+	}, nil)
+
+	path := filepath.Join(t.TempDir(), "out.png")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	downloader := NewMediaDownloader(doer)
+	downloader.FileMode = 0600
+	result := downloader.Download("https://cdn.z.ai/a.png", path)
+	require.NoError(t, result.Error)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+}
+
+func TestMediaDownloaderCopyTo(t *testing.T) {
+	doer := new(MockHTTPDoer)
+	doer.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader("png bytes")),
+	}, nil).Once()
+	doer.On("Do", mock.Anything).Return(&http.Response{
+		StatusCode: http.StatusGone,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}, nil)
+
+	var buf strings.Builder
+	size, err := NewMediaDownloader(doer).CopyTo(&buf, "https://cdn.z.ai/a.png")
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), size)
+	assert.Equal(t, "png bytes", buf.String())
+
+	buf.Reset()
+	_, err = NewMediaDownloader(doer).CopyTo(&buf, "https://cdn.z.ai/a.png")
+	assert.ErrorIs(t, err, ErrMediaExpired)
+	assert.Empty(t, buf.String())
+}
+
+func TestParseFileMode(t *testing.T) {
+	for input, want := range map[string]os.FileMode{"0600": 0600, "644": 0644, " 0640 ": 0640} {
+		mode, err := ParseFileMode(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, mode, input)
+	}
+	for _, bad := range []string{"", "rw-r--r--", "0999", "01777", "-1"} {
+		_, err := ParseFileMode(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestHistoryEntryMediaExpired(t *testing.T) {
+	entry := NewImageHistoryEntry("fox", ImageData{URL: "https://cdn.z.ai/fox.png"}, "glm-image")
+	assert.NotNil(t, entry.ExpiresAt)
+	assert.False(t, entry.MediaExpired(time.Now()))
+	assert.True(t, entry.MediaExpired(time.Now().Add(MediaURLLifetime+time.Hour)))
+
+	assert.False(t, HistoryEntry{}.MediaExpired(time.Now()), "entries without expiry never expire")
+
+	video := NewVideoHistoryEntry("waves", VideoResult{URL: "https://cdn.z.ai/waves.mp4"}, "cogvideox-3")
+	assert.Equal(t, "video", video.Type)
+	assert.Equal(t, "https://cdn.z.ai/waves.mp4", video.VideoURL)
+	assert.True(t, video.MediaExpired(time.Now().Add(MediaURLLifetime+time.Hour)))
+}
@@ -2,11 +2,19 @@ package app
 
 import (
 	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -31,19 +39,44 @@ type HistoryEntry struct {
 	TokenUsage Usage       `json:"token_usage,omitempty"`
 
 	// Image generation fields
-	ImageURL    string `json:"image_url,omitempty"`
-	ImageSize   string `json:"image_size,omitempty"`
-	ImageFormat string `json:"image_format,omitempty"`
-	Type        string `json:"type"` // "chat", "image", or "web"
+	ImageURL    string     `json:"image_url,omitempty"`
+	ImageSize   string     `json:"image_size,omitempty"`
+	ImageFormat string     `json:"image_format,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"` // When ImageURL stops being served
+	Type        string     `json:"type"`                 // One of HistoryTypes
 
 	// Web reader fields
 	WebSources []string `json:"web_sources,omitempty"`
+
+	// Vision fields
+	ImageSources []string `json:"image_sources,omitempty"` // Analyzed image paths or URLs
+
+	// Video generation fields
+	VideoURL string `json:"video_url,omitempty"`
 }
 
+// HistoryTypes lists the entry types commands record. Entries without a type are chat.
+var HistoryTypes = []string{"chat", "audio", "image", "video", "vision", "web", "web_search"}
+
+// historyShardLayout names daily shard files (YYYY-MM-DD.jsonl).
+const historyShardLayout = "2006-01-02"
+
 // FileHistoryStore implements HistoryStore with JSONL file storage.
+// In sharded mode, path is a directory holding one file per day.
 type FileHistoryStore struct {
-	path string
-	mu   sync.RWMutex
+	path    string
+	sharded bool
+	mu      sync.RWMutex
+
+	// maxEntryChars caps the stored prompt and text response (0 = unlimited)
+	maxEntryChars int
+
+	// Encryption (see SetEncryption); the cipher is derived on first use
+	encrypt   bool
+	key       HistoryKeyFunc
+	cipherMu  sync.Mutex
+	cipher    *historyCipher
+	cipherErr error
 }
 
 // NewFileHistoryStore creates a history store at the given path.
@@ -60,16 +93,76 @@ func NewFileHistoryStore(path string) *FileHistoryStore {
 	return &FileHistoryStore{path: path}
 }
 
+// NewShardedHistoryStore creates a history store that writes daily files into dir.
+// If dir is empty, uses ~/.config/zai/history.
+func NewShardedHistoryStore(dir string) *FileHistoryStore {
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			dir = "history"
+		} else {
+			dir = filepath.Join(home, ".config", "zai", "history")
+		}
+	}
+	return &FileHistoryStore{path: dir, sharded: true}
+}
+
+// SetMaxEntryChars caps the prompt and text response Save stores, in runes;
+// longer ones are cut with a marker. Structured responses are left whole so
+// they stay valid JSON. 0 means unlimited.
+func (h *FileHistoryStore) SetMaxEntryChars(n int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.maxEntryChars = n
+}
+
+// filePath returns the file an entry with the given timestamp belongs in.
+func (h *FileHistoryStore) filePath(t time.Time) string {
+	if !h.sharded {
+		return h.path
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return filepath.Join(h.path, t.Local().Format(historyShardLayout)+".jsonl")
+}
+
+// shardPaths returns daily shard files, newest first.
+func (h *FileHistoryStore) shardPaths() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(h.path, "*.jsonl"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list history shards: %w", err)
+	}
+	var shards []string
+	for _, m := range matches {
+		if _, err := time.Parse(historyShardLayout, strings.TrimSuffix(filepath.Base(m), ".jsonl")); err == nil {
+			shards = append(shards, m)
+		}
+	}
+	// Date names sort lexically
+	sort.Sort(sort.Reverse(sort.StringSlice(shards)))
+	return shards, nil
+}
+
 // Save appends an entry to the history file.
 func (h *FileHistoryStore) Save(entry HistoryEntry) error {
 	h.mu.Lock()
 	defer h.mu.Unlock()
+	path := h.filePath(entry.Timestamp)
+
 	// Ensure directory exists
-	dir := filepath.Dir(h.path)
+	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return fmt.Errorf("failed to create history directory: %w", err)
 	}
 
+	if h.maxEntryChars > 0 {
+		entry.Prompt = truncateRunes(entry.Prompt, h.maxEntryChars)
+		if text, ok := entry.Response.(string); ok {
+			entry.Response = truncateRunes(text, h.maxEntryChars)
+		}
+	}
+
 	// Handle response conversion for compatibility
 	if _, ok := entry.Response.(string); !ok {
 		// Response is complex type, convert to JSON string for storage
@@ -85,13 +178,18 @@ func (h *FileHistoryStore) Save(entry HistoryEntry) error {
 		return fmt.Errorf("failed to marshal history entry: %w", err)
 	}
 
-	file, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	line, err := h.encodeLine(data)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 	if err != nil {
 		return fmt.Errorf("failed to open history file: %w", err)
 	}
 	defer closeFile(file)
 
-	if _, err := file.WriteString(string(data) + "\n"); err != nil {
+	if _, err := file.WriteString(line + "\n"); err != nil {
 		return fmt.Errorf("failed to write history entry: %w", err)
 	}
 
@@ -99,10 +197,112 @@ func (h *FileHistoryStore) Save(entry HistoryEntry) error {
 }
 
 // GetRecent returns the most recent history entries.
+// Sharded stores read daily files newest-first until limit is reached.
 func (h *FileHistoryStore) GetRecent(limit int) ([]HistoryEntry, error) {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
-	file, err := os.Open(h.path)
+
+	if !h.sharded {
+		entries, err := h.readFile(h.path)
+		if err != nil {
+			return nil, err
+		}
+		return lastEntries(entries, limit), nil
+	}
+
+	shards, err := h.shardPaths()
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []HistoryEntry{}
+	for _, shard := range shards {
+		shardEntries, err := h.readFile(shard)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(shardEntries, entries...)
+		if limit > 0 && len(entries) >= limit {
+			break
+		}
+	}
+	return lastEntries(entries, limit), nil
+}
+
+// Search returns entries whose prompt or response contains term, ignoring case.
+// Matches are oldest first; limit keeps the most recent ones (0 for all).
+func (h *FileHistoryStore) Search(term string, limit int) ([]HistoryEntry, error) {
+	return h.SearchRegexp(regexp.MustCompile("(?i)"+regexp.QuoteMeta(term)), limit)
+}
+
+// SearchRegexp is Search with a regular expression instead of a substring.
+func (h *FileHistoryStore) SearchRegexp(re *regexp.Regexp, limit int) ([]HistoryEntry, error) {
+	entries, err := h.GetRecent(0)
+	if err != nil {
+		return nil, err
+	}
+	var matches []HistoryEntry
+	for _, entry := range entries {
+		if re.MatchString(entry.Prompt) || re.MatchString(entry.ResponseText()) {
+			matches = append(matches, entry)
+		}
+	}
+	return lastEntries(matches, limit), nil
+}
+
+// FormatHistoryMarkdown renders entries as a Markdown document: a heading per
+// exchange, then the prompt and response in fenced blocks. Fences are longer
+// than any backtick run in the text, so code in prompts can't end them early.
+func FormatHistoryMarkdown(entries []HistoryEntry, exported time.Time) string {
+	var sb strings.Builder
+	sb.WriteString("# zai history\n\n")
+	sb.WriteString(fmt.Sprintf("_Exported %s, %d entries_\n", exported.Format("2006-01-02 15:04"), len(entries)))
+	for i, entry := range entries {
+		title := strings.Join(strings.Fields(entry.Prompt), " ")
+		if runes := []rune(title); len(runes) > 60 {
+			title = string(runes[:57]) + "..."
+		}
+		entryType := entry.Type
+		if entryType == "" {
+			entryType = "chat"
+		}
+		sb.WriteString(fmt.Sprintf("\n## %d. %s\n\n", i+1, EscapeMarkdown(title)))
+		sb.WriteString(fmt.Sprintf("_%s · %s · %s_\n\n", entry.Timestamp.Format("2006-01-02 15:04"), entryType, EscapeMarkdown(entry.Model)))
+		sb.WriteString("**Prompt**\n\n" + codeFence(entry.Prompt) + "\n")
+		sb.WriteString("**Response**\n\n" + codeFence(entry.ResponseText()))
+	}
+	return sb.String()
+}
+
+// markdownSpecial matches characters with inline meaning in Markdown.
+var markdownSpecial = regexp.MustCompile("[\\\\`*_\\[\\]|<>]")
+
+// EscapeMarkdown backslash-escapes s for use as inline Markdown text,
+// including | so it is safe inside table cells.
+func EscapeMarkdown(s string) string {
+	return markdownSpecial.ReplaceAllString(s, "\\$0")
+}
+
+// codeFence wraps s in a code fence one backtick longer than its longest backtick run.
+func codeFence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + "\n" + strings.TrimRight(s, "\n") + "\n" + fence + "\n"
+}
+
+// readFile parses a JSONL history file, skipping malformed lines.
+// A missing file yields no entries. A missing or wrong key is an error, so it
+// is never silent; single lines the key can't open are skipped with a warning.
+func (h *FileHistoryStore) readFile(path string) ([]HistoryEntry, error) {
+	file, err := os.Open(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return []HistoryEntry{}, nil
@@ -112,7 +312,9 @@ func (h *FileHistoryStore) GetRecent(limit int) ([]HistoryEntry, error) {
 	defer closeFile(file)
 
 	var entries []HistoryEntry
+	var undecryptable int
 	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxStoredLineSize)
 
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
@@ -120,8 +322,16 @@ func (h *FileHistoryStore) GetRecent(limit int) ([]HistoryEntry, error) {
 			continue
 		}
 
+		data, err := h.decodeLine(line)
+		if errors.Is(err, errUndecryptableLine) {
+			undecryptable++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
 		var entry HistoryEntry
-		if err := json.Unmarshal([]byte(line), &entry); err == nil {
+		if err := json.Unmarshal(data, &entry); err == nil {
 			entries = append(entries, entry)
 		}
 	}
@@ -129,16 +339,135 @@ func (h *FileHistoryStore) GetRecent(limit int) ([]HistoryEntry, error) {
 	if err := scanner.Err(); err != nil {
 		return nil, fmt.Errorf("error reading history file: %w", err)
 	}
+	if undecryptable > 0 {
+		slog.Warn("skipped history entries that could not be decrypted", "file", path, "count", undecryptable)
+	}
 
-	// Return most recent entries
-	if limit > 0 && len(entries) > limit {
-		entries = entries[len(entries)-limit:]
+	return entries, nil
+}
+
+// maxHistoryLineSize is the longest JSONL line ParseHistoryJSONL accepts.
+const maxHistoryLineSize = 10 * 1024 * 1024
+
+// maxStoredLineSize is the longest line readFile accepts: an imported line
+// of maxHistoryLineSize after sealing (nonce, tag, and base64 overhead).
+var maxStoredLineSize = len(encryptedLinePrefix) + base64.StdEncoding.EncodedLen(maxHistoryLineSize+64)
+
+// ParseHistoryJSONL strictly parses JSONL history, skipping blank lines.
+// Unlike reading the store, an unparseable line is an error naming the line.
+func ParseHistoryJSONL(r io.Reader) ([]HistoryEntry, error) {
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxHistoryLineSize)
+
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: invalid history entry: %w", lineNum, err)
+		}
+		if entry.Timestamp.IsZero() {
+			return nil, fmt.Errorf("line %d: history entry has no timestamp", lineNum)
+		}
+		entries = append(entries, entry)
 	}
 
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading history: %w", err)
+	}
 	return entries, nil
 }
 
-// Path returns the history file path.
+// historyEntryKey identifies an entry for de-duplication (timestamp + prompt hash).
+func historyEntryKey(entry HistoryEntry) string {
+	sum := sha256.Sum256([]byte(entry.Prompt))
+	return entry.Timestamp.UTC().Format(time.RFC3339Nano) + "|" + hex.EncodeToString(sum[:])
+}
+
+// Import appends entries not already in the store, oldest first.
+// Duplicates (same timestamp and prompt) are skipped, including repeats within entries.
+func (h *FileHistoryStore) Import(entries []HistoryEntry) (imported, skipped int, err error) {
+	existing, err := h.GetRecent(0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	seen := make(map[string]bool, len(existing)+len(entries))
+	for _, entry := range existing {
+		seen[historyEntryKey(entry)] = true
+	}
+
+	sorted := make([]HistoryEntry, len(entries))
+	copy(sorted, entries)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	for _, entry := range sorted {
+		key := historyEntryKey(entry)
+		if seen[key] {
+			skipped++
+			continue
+		}
+		if err := h.Save(entry); err != nil {
+			return imported, skipped, err
+		}
+		seen[key] = true
+		imported++
+	}
+	return imported, skipped, nil
+}
+
+// lastEntries returns the final limit entries (all if limit <= 0).
+func lastEntries(entries []HistoryEntry, limit int) []HistoryEntry {
+	if limit > 0 && len(entries) > limit {
+		return entries[len(entries)-limit:]
+	}
+	return entries
+}
+
+// historyTimeLayouts are the absolute formats accepted by ParseHistoryTime.
+var historyTimeLayouts = []string{time.RFC3339, "2006-01-02T15:04", "2006-01-02 15:04", time.DateOnly}
+
+// ParseHistoryTime parses a --since/--until bound: RFC3339, YYYY-MM-DD
+// (local midnight), YYYY-MM-DD HH:MM, or a relative age before now such as
+// 7d, 2w, 24h, or 90m.
+func ParseHistoryTime(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range historyTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	if n := len(value); n > 1 {
+		unit := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}[value[n-1]]
+		if count, err := strconv.Atoi(value[:n-1]); err == nil && unit > 0 && count >= 0 {
+			return now.Add(-time.Duration(count) * unit), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use YYYY-MM-DD, RFC3339, or an age like 7d, 24h)", value)
+}
+
+// InTimeRange reports whether the entry falls in [since, until).
+// A zero bound is open.
+func (e HistoryEntry) InTimeRange(since, until time.Time) bool {
+	if !since.IsZero() && e.Timestamp.Before(since) {
+		return false
+	}
+	return until.IsZero() || e.Timestamp.Before(until)
+}
+
+// Path returns the history file path (the shard directory in sharded mode).
 func (h *FileHistoryStore) Path() string {
 	h.mu.RLock()
 	defer h.mu.RUnlock()
@@ -147,18 +476,39 @@ func (h *FileHistoryStore) Path() string {
 
 // NewImageHistoryEntry creates a history entry for image generation.
 func NewImageHistoryEntry(prompt string, imageData ImageData, model string) HistoryEntry {
+	now := time.Now()
+	expires := now.Add(MediaURLLifetime)
 	return HistoryEntry{
-		Timestamp:   time.Now(),
+		Timestamp:   now,
 		Prompt:      prompt,
 		Response:    fmt.Sprintf("Generated image: %s", imageData.URL),
 		Model:       model,
 		ImageURL:    imageData.URL,
 		ImageSize:   fmt.Sprintf("%dx%d", imageData.Width, imageData.Height),
 		ImageFormat: imageData.Format,
+		ExpiresAt:   &expires,
 		Type:        "image",
 	}
 }
 
+// ResponseText returns the response as text; structured responses are indented JSON.
+func (e HistoryEntry) ResponseText() string {
+	if respStr, ok := e.Response.(string); ok {
+		return respStr
+	}
+	data, err := json.MarshalIndent(e.Response, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", e.Response)
+	}
+	return string(data)
+}
+
+// MediaExpired reports whether the entry's media URL has passed its expiry.
+// Entries without an expiry (older entries, non-media) are never expired.
+func (e HistoryEntry) MediaExpired(now time.Time) bool {
+	return e.ExpiresAt != nil && now.After(*e.ExpiresAt)
+}
+
 // NewChatHistoryEntry creates a history entry for chat (sets type to "chat").
 func NewChatHistoryEntry(timestamp time.Time, prompt, response, model string, usage Usage) HistoryEntry {
 	return HistoryEntry{
@@ -204,6 +554,33 @@ func NewSearchHistoryEntry(timestamp time.Time, query string, resp *WebSearchRes
 	}
 }
 
+// NewVideoHistoryEntry creates a history entry for video generation.
+func NewVideoHistoryEntry(prompt string, video VideoResult, model string) HistoryEntry {
+	now := time.Now()
+	expires := now.Add(MediaURLLifetime)
+	return HistoryEntry{
+		Timestamp: now,
+		Prompt:    prompt,
+		Response:  fmt.Sprintf("Generated video: %s", video.URL),
+		Model:     model,
+		VideoURL:  video.URL,
+		ExpiresAt: &expires,
+		Type:      "video",
+	}
+}
+
+// NewVisionHistoryEntry creates a history entry for image analysis.
+func NewVisionHistoryEntry(timestamp time.Time, prompt, analysis, model string, sources []string) HistoryEntry {
+	return HistoryEntry{
+		Timestamp:    timestamp,
+		Prompt:       prompt,
+		Response:     analysis,
+		Model:        model,
+		Type:         "vision",
+		ImageSources: sources,
+	}
+}
+
 // NewAudioHistoryEntry creates a history entry for audio transcription.
 func NewAudioHistoryEntry(text string, model string) HistoryEntry {
 	return HistoryEntry{
@@ -214,3 +591,96 @@ func NewAudioHistoryEntry(text string, model string) HistoryEntry {
 		Type:      "audio",
 	}
 }
+
+// HistoryFollower tracks a read position in the history file for tail -f style monitoring.
+// Detects truncation and rotation (file replaced) and restarts from the beginning.
+// For sharded stores it moves to the new day's file at midnight.
+type HistoryFollower struct {
+	store  *FileHistoryStore
+	path   string
+	offset int64
+	info   os.FileInfo
+}
+
+// Follow returns a follower for the history file.
+// If fromEnd is true, only entries appended after this call are reported.
+func (h *FileHistoryStore) Follow(fromEnd bool) *HistoryFollower {
+	f := &HistoryFollower{store: h, path: h.filePath(time.Now())}
+	if info, err := os.Stat(f.path); err == nil {
+		f.info = info
+		if fromEnd {
+			f.offset = info.Size()
+		}
+	}
+	return f
+}
+
+// Poll returns complete entries appended since the last call.
+// A missing file is not an error; it yields no entries until the file appears.
+func (f *HistoryFollower) Poll() ([]HistoryEntry, error) {
+	if path := f.store.filePath(time.Now()); path != f.path {
+		f.path = path
+		f.info = nil
+		f.offset = 0
+	}
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			f.info = nil
+			f.offset = 0
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to stat history file: %w", err)
+	}
+
+	// Rotated (different file) or truncated: start over
+	if (f.info != nil && !os.SameFile(f.info, info)) || info.Size() < f.offset {
+		f.offset = 0
+	}
+	f.info = info
+
+	if info.Size() == f.offset {
+		return nil, nil
+	}
+
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer closeFile(file)
+
+	if _, err := file.Seek(f.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek history file: %w", err)
+	}
+
+	var entries []HistoryEntry
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// Partial line without newline: leave it for the next poll
+			break
+		}
+		f.offset += int64(len(line))
+
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		data, err := f.store.decodeLine(line)
+		if errors.Is(err, errUndecryptableLine) {
+			slog.Warn("skipped a history entry that could not be decrypted", "file", f.path)
+			continue
+		}
+		if err != nil {
+			return entries, err
+		}
+		var entry HistoryEntry
+		if err := json.Unmarshal(data, &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,326 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHistoryFollower tests tail -f style polling of the history file.
+func TestHistoryFollower(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	store := NewFileHistoryStore(path)
+
+	require.NoError(t, store.Save(NewChatHistoryEntry(time.Now(), "old", "r", "glm-4.7", Usage{})))
+
+	t.Run("from end skips existing entries", func(t *testing.T) {
+		follower := store.Follow(true)
+		entries, err := follower.Poll()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		require.NoError(t, store.Save(NewChatHistoryEntry(time.Now(), "new", "r", "glm-4.7", Usage{})))
+		entries, err = follower.Poll()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "new", entries[0].Prompt)
+
+		entries, err = follower.Poll()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("partial line is deferred", func(t *testing.T) {
+		follower := store.Follow(true)
+		file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+		require.NoError(t, err)
+		_, err = file.WriteString(`{"prompt":"partial"`)
+		require.NoError(t, err)
+
+		entries, err := follower.Poll()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		_, err = file.WriteString(`,"type":"chat"}` + "\n")
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		entries, err = follower.Poll()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "partial", entries[0].Prompt)
+	})
+
+	t.Run("truncation restarts from beginning", func(t *testing.T) {
+		follower := store.Follow(true)
+		require.NoError(t, os.Truncate(path, 0))
+		require.NoError(t, store.Save(NewChatHistoryEntry(time.Now(), "rotated", "r", "glm-4.7", Usage{})))
+
+		entries, err := follower.Poll()
+		require.NoError(t, err)
+		require.Len(t, entries, 1)
+		assert.Equal(t, "rotated", entries[0].Prompt)
+	})
+
+	t.Run("missing file yields nothing", func(t *testing.T) {
+		missing := NewFileHistoryStore(filepath.Join(t.TempDir(), "none.jsonl"))
+		entries, err := missing.Follow(false).Poll()
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+}
+
+// TestShardedHistoryStore tests daily shard writes and newest-first reads.
+func TestShardedHistoryStore(t *testing.T) {
+	dir := t.TempDir()
+	store := NewShardedHistoryStore(dir)
+
+	day1 := time.Date(2024, 1, 14, 12, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+	require.NoError(t, store.Save(NewChatHistoryEntry(day1, "a", "r", "glm-4.7", Usage{})))
+	require.NoError(t, store.Save(NewChatHistoryEntry(day1, "b", "r", "glm-4.7", Usage{})))
+	require.NoError(t, store.Save(NewChatHistoryEntry(day2, "c", "r", "glm-4.7", Usage{})))
+	// Unrelated files in the directory are ignored
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.jsonl"), []byte(`{"prompt":"x"}`+"\n"), 0600))
+
+	assert.FileExists(t, filepath.Join(dir, "2024-01-14.jsonl"))
+	assert.FileExists(t, filepath.Join(dir, "2024-01-15.jsonl"))
+
+	entries, err := store.GetRecent(2)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "b", entries[0].Prompt)
+	assert.Equal(t, "c", entries[1].Prompt)
+
+	entries, err = store.GetRecent(0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	empty, err := NewShardedHistoryStore(filepath.Join(dir, "missing")).GetRecent(10)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+// TestHistoryImport tests merging entries with de-duplication.
+func TestHistoryImport(t *testing.T) {
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Save(NewChatHistoryEntry(base, "shared", "r", "glm-4.7", Usage{})))
+
+	incoming := []HistoryEntry{
+		NewChatHistoryEntry(base.Add(2*time.Hour), "later", "r", "glm-4.7", Usage{}),
+		NewChatHistoryEntry(base, "shared", "r", "glm-4.7", Usage{}),
+		NewChatHistoryEntry(base.Add(time.Hour), "earlier", "r", "glm-4.7", Usage{}),
+		NewChatHistoryEntry(base.Add(time.Hour), "earlier", "r", "glm-4.7", Usage{}),
+	}
+
+	imported, skipped, err := store.Import(incoming)
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.Equal(t, 2, skipped)
+
+	entries, err := store.GetRecent(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "earlier", entries[1].Prompt)
+	assert.Equal(t, "later", entries[2].Prompt)
+
+	// An entry past the default 64 KB scanner limit stays readable once imported
+	long := strings.Repeat("x", 200*1024)
+	_, _, err = store.Import([]HistoryEntry{NewChatHistoryEntry(base.Add(3*time.Hour), "long", long, "glm-4.7", Usage{})})
+	require.NoError(t, err)
+	entries, err = store.GetRecent(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 4)
+	assert.Equal(t, long, entries[3].ResponseText())
+}
+
+// TestHistorySearch tests case-insensitive and regexp matching over prompts and responses.
+func TestHistorySearch(t *testing.T) {
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+
+	require.NoError(t, store.Save(NewChatHistoryEntry(base, "Explain Kubernetes pods", "A pod is...", "glm-4.7", Usage{})))
+	require.NoError(t, store.Save(NewChatHistoryEntry(base.Add(time.Hour), "sort a list", "use sorted()", "glm-4.7", Usage{})))
+	require.NoError(t, store.Save(NewChatHistoryEntry(base.Add(2*time.Hour), "deploy it", "Run kubectl on the kubernetes cluster", "glm-4.7", Usage{})))
+
+	entries, err := store.Search("KUBERNETES", 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "Explain Kubernetes pods", entries[0].Prompt)
+	assert.Equal(t, "deploy it", entries[1].Prompt)
+
+	entries, err = store.Search("kubernetes", 1)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "deploy it", entries[0].Prompt)
+
+	// Regexp metacharacters in a plain term are literal
+	entries, err = store.Search("sorted()", 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	entries, err = store.SearchRegexp(regexp.MustCompile(`^sort|kubectl`), 0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+// TestHistoryEncryption tests that sealed entries round-trip and need the passphrase to read.
+func TestHistoryEncryption(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	passphrase := func(key string) HistoryKeyFunc {
+		return func() (string, error) { return key, nil }
+	}
+
+	store := NewFileHistoryStore(path)
+	require.NoError(t, store.Save(NewChatHistoryEntry(time.Now(), "plain prompt", "ok", "glm-4.7", Usage{})))
+	store.SetEncryption(true, passphrase("s3cret"))
+	require.NoError(t, store.Save(NewChatHistoryEntry(time.Now(), "my api key is abc123", "noted", "glm-4.7", Usage{})))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "abc123")
+	assert.Contains(t, string(data), encryptedLinePrefix)
+
+	entries, err := store.Search("abc123", 0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Plain and sealed lines are both readable with the key, even with encryption off
+	reader := NewFileHistoryStore(path)
+	reader.SetEncryption(false, passphrase("s3cret"))
+	entries, err = reader.GetRecent(0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = NewFileHistoryStore(path).GetRecent(0)
+	assert.ErrorIs(t, err, ErrHistoryKeyMissing)
+
+	// A mistyped key is refused before it can seal anything
+	wrong := NewFileHistoryStore(path)
+	wrong.SetEncryption(true, passphrase("guess"))
+	_, err = wrong.GetRecent(0)
+	assert.ErrorIs(t, err, ErrHistoryKeyMismatch)
+	assert.ErrorIs(t, wrong.Save(NewChatHistoryEntry(time.Now(), "lost", "x", "glm-4.7", Usage{})), ErrHistoryKeyMismatch)
+
+	// Lines sealed under another key (written before the check existed) are skipped
+	salt, err := os.ReadFile(path + historySaltSuffix)
+	require.NoError(t, err)
+	other, err := newHistoryCipher("guess", salt[:historySaltSize])
+	require.NoError(t, err)
+	sealed, err := other.seal([]byte(`{"prompt":"lost"}`))
+	require.NoError(t, err)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteString(sealed + "\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = reader.GetRecent(0)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+// TestHistoryMaxEntryChars tests that long prompts and text responses are cut when stored.
+func TestHistoryMaxEntryChars(t *testing.T) {
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	store.SetMaxEntryChars(5)
+
+	entry := NewChatHistoryEntry(time.Now(), "héllo world", "a long answer", "glm-4.7", Usage{})
+	require.NoError(t, store.Save(entry))
+	require.NoError(t, store.Save(NewWebHistoryEntry("id", "read it", &WebReaderResponse{ID: "id"}, nil)))
+	assert.Equal(t, "héllo world", entry.Prompt, "caller's entry is untouched")
+
+	entries, err := store.GetRecent(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "héllo\n... [truncated]", entries[0].Prompt)
+	assert.Equal(t, "a lon\n... [truncated]", entries[0].Response)
+	assert.Contains(t, entries[1].Response, `"url":""}`, "structured responses stay whole")
+}
+
+// TestFormatHistoryMarkdown tests that backticks and pipes can't break the document.
+func TestFormatHistoryMarkdown(t *testing.T) {
+	base := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)
+	entries := []HistoryEntry{
+		NewChatHistoryEntry(base, "what does `a | b` do?", "It pipes:\n```sh\nls | wc -l\n```", "glm-4.7", Usage{}),
+	}
+
+	md := FormatHistoryMarkdown(entries, base)
+	assert.Contains(t, md, "_Exported 2024-01-15 09:00, 1 entries_")
+	assert.Contains(t, md, "## 1. what does \\`a \\| b\\` do?\n")
+	assert.Contains(t, md, "_2024-01-15 09:00 · chat · glm-4.7_")
+	// The response contains a ``` fence, so it is wrapped in a longer one
+	assert.Contains(t, md, "**Response**\n\n````\nIt pipes:\n```sh\nls | wc -l\n```\n````\n")
+	assert.Contains(t, md, "**Prompt**\n\n```\nwhat does `a | b` do?\n```\n")
+}
+
+// TestParseHistoryJSONL tests strict parsing of import files.
+func TestParseHistoryJSONL(t *testing.T) {
+	entries, err := ParseHistoryJSONL(strings.NewReader(`{"timestamp":"2024-01-15T09:00:00Z","prompt":"a"}` + "\n\n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	_, err = ParseHistoryJSONL(strings.NewReader(`{"timestamp":"2024-01-15T09:00:00Z","prompt":"a"}` + "\nnot json\n"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "line 2")
+
+	_, err = ParseHistoryJSONL(strings.NewReader(`{"prompt":"no time"}`))
+	require.Error(t, err)
+}
+
+func TestVisionHistoryEntryRoundTrip(t *testing.T) {
+	store := NewFileHistoryStore(filepath.Join(t.TempDir(), "history.jsonl"))
+	entry := NewVisionHistoryEntry(time.Now(), "describe", "a red fox", DefaultVisionModel, []string{"fox.jpg"})
+	require.NoError(t, store.Save(entry))
+
+	entries, err := store.GetRecent(0)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "vision", entries[0].Type)
+	assert.Equal(t, "a red fox", entries[0].Response)
+	assert.Equal(t, []string{"fox.jpg"}, entries[0].ImageSources)
+}
+
+func TestParseHistoryTime(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	got, err := ParseHistoryTime("2024-01-01", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), got)
+
+	got, err = ParseHistoryTime("2024-01-15T09:30:00Z", now)
+	require.NoError(t, err)
+	assert.True(t, got.Equal(time.Date(2024, 1, 15, 9, 30, 0, 0, time.UTC)))
+
+	for value, age := range map[string]time.Duration{
+		"7d":  7 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"24h": 24 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		got, err = ParseHistoryTime(value, now)
+		require.NoError(t, err, value)
+		assert.Equal(t, now.Add(-age), got, value)
+	}
+
+	for _, bad := range []string{"", "yesterday", "-3d", "2024-13-01"} {
+		_, err = ParseHistoryTime(bad, now)
+		assert.Error(t, err, bad)
+	}
+
+	entry := HistoryEntry{Timestamp: time.Date(2024, 1, 20, 0, 0, 0, 0, time.UTC)}
+	jan := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	assert.True(t, entry.InTimeRange(jan, feb))
+	assert.True(t, entry.InTimeRange(time.Time{}, time.Time{}))
+	assert.False(t, entry.InTimeRange(feb, time.Time{}))
+	assert.False(t, entry.InTimeRange(time.Time{}, jan))
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HistoryKeyEnv names the environment variable holding the history passphrase.
+const HistoryKeyEnv = "ZAI_HISTORY_KEY"
+
+// encryptedLinePrefix marks a history line sealed with the store passphrase.
+// Plain JSON lines start with '{', so encrypted and plain lines can share a file.
+const encryptedLinePrefix = "enc:v1:"
+
+const (
+	historySaltSize   = 16
+	historyCheckSize  = sha256.Size // Key check stored after the salt
+	historyKDFRounds  = 600_000
+	historyKeySize    = 32 // AES-256
+	historySaltSuffix = ".salt"
+)
+
+// ErrHistoryKeyMissing is returned when encrypted history is read or written without a passphrase.
+var ErrHistoryKeyMissing = errors.New("history is encrypted: set " + HistoryKeyEnv + " to the history passphrase")
+
+// ErrHistoryKeyMismatch is returned when the passphrase differs from the one
+// history was first encrypted with, before anything is written with it.
+var ErrHistoryKeyMismatch = errors.New("wrong history passphrase: " + HistoryKeyEnv + " does not match the key history is encrypted with")
+
+// errUndecryptableLine marks a sealed line the store key can't open. Readers
+// skip such lines rather than lose the rest of the history.
+var errUndecryptableLine = errors.New("cannot decrypt history entry")
+
+// HistoryKeyFunc supplies the history passphrase. It is called at most once,
+// the first time an encrypted line is read or written.
+type HistoryKeyFunc func() (string, error)
+
+// historyCipher seals history lines with AES-256-GCM. The key is derived from
+// the passphrase with PBKDF2-SHA256 and a random salt kept beside the history.
+type historyCipher struct {
+	aead  cipher.AEAD
+	check []byte // HMAC of a fixed label under the key, to recognize the passphrase
+}
+
+func newHistoryCipher(passphrase string, salt []byte) (*historyCipher, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, historyKDFRounds, historyKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive history key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history cipher: %w", err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("zai history key check")) //nolint:errcheck // hash writes never fail
+	return &historyCipher{aead: aead, check: mac.Sum(nil)}, nil
+}
+
+// seal encrypts a JSON line into its stored form.
+func (c *historyCipher) seal(plain []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plain, nil)
+	return encryptedLinePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a stored line produced by seal.
+func (c *historyCipher) open(line string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, encryptedLinePrefix))
+	if err != nil || len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("%w: corrupt entry", errUndecryptableLine)
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plain, err := c.aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: sealed with a different key", errUndecryptableLine)
+	}
+	return plain, nil
+}
+
+// SetEncryption configures history encryption. When encrypt is true, Save
+// writes sealed lines. Reading sealed lines only needs key, so history stays
+// readable after encryption is switched off. A nil key means none is available.
+func (h *FileHistoryStore) SetEncryption(encrypt bool, key HistoryKeyFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.encrypt = encrypt
+	h.key = key
+	h.cipher = nil
+	h.cipherErr = nil
+}
+
+// saltPath returns the file holding the key derivation salt.
+func (h *FileHistoryStore) saltPath() string {
+	if h.sharded {
+		return filepath.Join(h.path, "history"+historySaltSuffix)
+	}
+	return h.path + historySaltSuffix
+}
+
+// lineCipher returns the store cipher, deriving it on first use.
+// The salt is created when missing. Callers hold h.mu.
+func (h *FileHistoryStore) lineCipher() (*historyCipher, error) {
+	h.cipherMu.Lock()
+	defer h.cipherMu.Unlock()
+	if h.cipher != nil || h.cipherErr != nil {
+		return h.cipher, h.cipherErr
+	}
+	h.cipher, h.cipherErr = h.loadCipher()
+	return h.cipher, h.cipherErr
+}
+
+func (h *FileHistoryStore) loadCipher() (*historyCipher, error) {
+	if h.key == nil {
+		return nil, ErrHistoryKeyMissing
+	}
+	passphrase, err := h.key()
+	if err != nil {
+		return nil, err
+	}
+	if passphrase == "" {
+		return nil, ErrHistoryKeyMissing
+	}
+
+	// The salt file holds the salt and, after it, a check of the key derived
+	// from it. Files from before the check existed get one on first use.
+	stored, err := os.ReadFile(h.saltPath())
+	if os.IsNotExist(err) {
+		stored = make([]byte, historySaltSize)
+		if _, err := rand.Read(stored); err != nil {
+			return nil, fmt.Errorf("failed to generate history salt: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(h.saltPath()), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create history directory: %w", err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read history salt: %w", err)
+	}
+	if len(stored) < historySaltSize {
+		return nil, fmt.Errorf("history salt file %s is corrupt", h.saltPath())
+	}
+
+	c, err := newHistoryCipher(passphrase, stored[:historySaltSize])
+	if err != nil {
+		return nil, err
+	}
+	if check := stored[historySaltSize:]; len(check) > 0 {
+		if !hmac.Equal(check, c.check) {
+			return nil, ErrHistoryKeyMismatch
+		}
+		return c, nil
+	}
+	stored = append(stored[:historySaltSize:historySaltSize], c.check...)
+	if err := WriteFileAtomic(h.saltPath(), stored, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write history salt: %w", err)
+	}
+	return c, nil
+}
+
+// encodeLine returns the stored form of a marshaled entry.
+func (h *FileHistoryStore) encodeLine(data []byte) (string, error) {
+	if !h.encrypt {
+		return string(data), nil
+	}
+	c, err := h.lineCipher()
+	if err != nil {
+		return "", err
+	}
+	return c.seal(data)
+}
+
+// decodeLine returns the JSON for a stored line, decrypting sealed lines.
+// A sealed line the key can't open yields an error matching errUndecryptableLine.
+func (h *FileHistoryStore) decodeLine(line string) ([]byte, error) {
+	if !strings.HasPrefix(line, encryptedLinePrefix) {
+		return []byte(line), nil
+	}
+	c, err := h.lineCipher()
+	if err != nil {
+		return nil, err
+	}
+	return c.open(line)
+}
@@ -0,0 +1,130 @@
+package app
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// MaxHotwords is the most hotwords the transcription API accepts.
+const MaxHotwords = 100
+
+// hotwordStopwords are common capitalized words that are not domain terms.
+var hotwordStopwords = map[string]bool{
+	"the": true, "this": true, "that": true, "these": true, "those": true, "there": true,
+	"then": true, "than": true, "they": true, "their": true, "when": true, "where": true,
+	"what": true, "which": true, "while": true, "who": true, "why": true, "how": true,
+	"and": true, "but": true, "for": true, "not": true, "with": true, "from": true,
+	"into": true, "about": true, "after": true, "before": true, "also": true, "are": true,
+	"was": true, "were": true, "will": true, "would": true, "should": true, "could": true,
+	"can": true, "have": true, "has": true, "had": true, "you": true, "your": true,
+	"our": true, "its": true, "all": true, "any": true, "some": true, "each": true,
+	"other": true, "because": true, "however": true, "therefore": true, "between": true,
+	"through": true, "during": true, "without": true, "within": true, "here": true,
+	"yes": true, "okay": true, "just": true, "like": true, "well": true, "now": true,
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true, "friday": true,
+	"saturday": true, "sunday": true, "january": true, "february": true, "march": true,
+	"april": true, "june": true, "july": true, "august": true, "september": true,
+	"october": true, "november": true, "december": true,
+}
+
+// hotwordMinRepeats is how often a plain lowercase word must appear to count
+// as domain vocabulary; rarer ones are usually ordinary words.
+const hotwordMinRepeats = 3
+
+// ExtractHotwords picks likely domain terms from text for transcription hints:
+// capitalized words, acronyms, mixed-case or alphanumeric tokens (k8s, gRPC),
+// and long lowercase words that repeat. Results are ordered by frequency,
+// de-duplicated case-insensitively, and capped at limit.
+func ExtractHotwords(text string, limit int) []string {
+	type candidate struct {
+		word  string
+		count int
+		first int
+		term  bool // distinctive on its own, no repeats needed
+	}
+	seen := make(map[string]*candidate)
+
+	tokens := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '_' && r != '.' && r != '\''
+	})
+	for i, tok := range tokens {
+		tok = strings.Trim(tok, "-_.'")
+		tok = strings.TrimSuffix(tok, "'s")
+		key := strings.ToLower(tok)
+		if len([]rune(tok)) < 3 || hotwordStopwords[key] || isNumeric(tok) {
+			continue
+		}
+		if c, ok := seen[key]; ok {
+			c.count++
+			c.term = c.term || isDistinctiveTerm(tok)
+			continue
+		}
+		seen[key] = &candidate{word: tok, count: 1, first: i, term: isDistinctiveTerm(tok)}
+	}
+
+	var picked []*candidate
+	for _, c := range seen {
+		if c.term || (c.count >= hotwordMinRepeats && len(c.word) >= 6) {
+			picked = append(picked, c)
+		}
+	}
+	sort.Slice(picked, func(i, j int) bool {
+		if picked[i].count != picked[j].count {
+			return picked[i].count > picked[j].count
+		}
+		return picked[i].first < picked[j].first
+	})
+
+	words := make([]string, 0, min(len(picked), limit))
+	for _, c := range picked {
+		if len(words) == limit {
+			break
+		}
+		words = append(words, c.word)
+	}
+	return words
+}
+
+// isDistinctiveTerm reports whether a token looks like a name, acronym, or identifier.
+func isDistinctiveTerm(tok string) bool {
+	var upper, lower, digit bool
+	for _, r := range tok {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return upper || (digit && lower) || strings.ContainsAny(tok, "_.")
+}
+
+// isNumeric reports whether tok has no letters.
+func isNumeric(tok string) bool {
+	return strings.IndexFunc(tok, unicode.IsLetter) < 0
+}
+
+// MergeHotwords combines hotword lists in order, dropping case-insensitive
+// duplicates and blanks, and caps the result at MaxHotwords.
+func MergeHotwords(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	var merged []string
+	for _, list := range lists {
+		for _, w := range list {
+			w = strings.TrimSpace(w)
+			key := strings.ToLower(w)
+			if w == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, w)
+			if len(merged) == MaxHotwords {
+				return merged
+			}
+		}
+	}
+	return merged
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtractHotwords(t *testing.T) {
+	text := `The Kubernetes cluster runs on GKE. We deploy with Helm and talk gRPC.
+Kubernetes schedules pods; the scheduler retries. The scheduler is slow.
+When the scheduler stalls, k8s restarts it. Meeting on Monday with Priya's team.
+Version 1.29 shipped. the and but 2024`
+
+	words := ExtractHotwords(text, 20)
+
+	assert.Equal(t, []string{"scheduler", "Kubernetes"}, words[:2], "most frequent terms first")
+	for _, want := range []string{"GKE", "Helm", "gRPC", "k8s", "Priya", "scheduler"} {
+		assert.Contains(t, words, want)
+	}
+	for _, unwanted := range []string{"The", "When", "Monday", "1.29", "2024", "pods", "slow"} {
+		assert.NotContains(t, words, unwanted)
+	}
+
+	assert.Len(t, ExtractHotwords(text, 2), 2)
+	assert.Empty(t, ExtractHotwords("", 10))
+}
+
+func TestMergeHotwords(t *testing.T) {
+	merged := MergeHotwords([]string{"Docker", " kubectl "}, []string{"docker", "Helm", ""})
+	assert.Equal(t, []string{"Docker", "kubectl", "Helm"}, merged)
+
+	many := make([]string, 150)
+	for i := range many {
+		many[i] = fmt.Sprintf("term%d", i)
+	}
+	assert.Len(t, MergeHotwords(many), MaxHotwords)
+}
@@ -0,0 +1,223 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register GIF decoder
+	"image/jpeg"
+	_ "image/png" // register PNG decoder
+	"math"
+	"os"
+)
+
+// MaxReferenceImageBytes is the largest local reference image that is uploaded.
+const MaxReferenceImageBytes = 5 * 1024 * 1024
+
+// CheckImageFileSize returns an error if the file at path is larger than
+// maxBytes. Only the size is inspected, so an oversized file is never read.
+func CheckImageFileSize(path string, maxBytes int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Size() > maxBytes {
+		return fmt.Errorf("%s is %.1f MB; the limit is %.1f MB",
+			path, float64(info.Size())/(1024*1024), float64(maxBytes)/(1024*1024))
+	}
+	return nil
+}
+
+// shrinkJPEGQuality is the JPEG quality used when re-encoding a downscaled image.
+const shrinkJPEGQuality = 85
+
+// ShrinkImage downscales a JPEG, PNG, or GIF until its JPEG encoding fits in
+// maxBytes. Returns the new bytes and their MIME type (always image/jpeg).
+func ShrinkImage(data []byte, maxBytes int64) ([]byte, string, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot decode image for resizing: %w", err)
+	}
+
+	size := int64(len(data))
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	for {
+		// Pixel count scales with the square of the side, so shrink by the root of the
+		// size ratio, with headroom because JPEG size isn't exactly proportional.
+		scale := math.Sqrt(float64(maxBytes)/float64(size)) * 0.9
+		if scale >= 1 {
+			scale = 0.9
+		}
+		width, height = int(float64(width)*scale), int(float64(height)*scale)
+		if width < 16 || height < 16 {
+			return nil, "", fmt.Errorf("image cannot be shrunk below %d bytes", maxBytes)
+		}
+
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, downscale(src, width, height), &jpeg.Options{Quality: shrinkJPEGQuality}); err != nil {
+			return nil, "", fmt.Errorf("failed to encode resized image: %w", err)
+		}
+		if int64(buf.Len()) <= maxBytes {
+			return buf.Bytes(), "image/jpeg", nil
+		}
+		size = int64(buf.Len())
+	}
+}
+
+// downscale resizes src to width x height by averaging each destination
+// pixel's source area (box filter). Transparent areas are flattened onto white.
+func downscale(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	flat := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, bounds.Min, draw.Over)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, max((y+1)*sh/height, y*sh/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, max((x+1)*sw/width, x*sw/width+1)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				row := flat.Pix[sy*flat.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+3]
+					r, g, b = r+uint64(p[0]), g+uint64(p[1]), b+uint64(p[2])
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: uint8(r / n), G: uint8(g / n), B: uint8(b / n), A: 255}) //nolint:gosec // G115: averages of uint8 values fit in uint8
+		}
+	}
+	return dst
+}
+
+// gridGap is the padding in pixels between and around contact-sheet cells.
+const gridGap = 8
+
+// ComposeGrid lays images out in a near-square contact sheet on a white
+// background. Every cell is sized to the largest image, and smaller images
+// are centered in their cell.
+func ComposeGrid(images []image.Image) *image.RGBA {
+	if len(images) == 0 {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	var cellW, cellH int
+	for _, img := range images {
+		cellW = max(cellW, img.Bounds().Dx())
+		cellH = max(cellH, img.Bounds().Dy())
+	}
+	cols := int(math.Ceil(math.Sqrt(float64(len(images)))))
+	rows := (len(images) + cols - 1) / cols
+
+	sheet := image.NewRGBA(image.Rect(0, 0, cols*(cellW+gridGap)+gridGap, rows*(cellH+gridGap)+gridGap))
+	draw.Draw(sheet, sheet.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	for i, img := range images {
+		b := img.Bounds()
+		x := gridGap + (i%cols)*(cellW+gridGap) + (cellW-b.Dx())/2
+		y := gridGap + (i/cols)*(cellH+gridGap) + (cellH-b.Dy())/2
+		draw.Draw(sheet, image.Rect(x, y, x+b.Dx(), y+b.Dy()), img, b.Min, draw.Over)
+	}
+	return sheet
+}
+
+// lanczosLobes is the support of the Lanczos kernel (Lanczos-3).
+const lanczosLobes = 3
+
+// lanczos is the Lanczos-3 windowed sinc kernel.
+func lanczos(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x == 0:
+		return 1
+	case x >= lanczosLobes:
+		return 0
+	}
+	px := math.Pi * x
+	return lanczosLobes * math.Sin(px) * math.Sin(px/lanczosLobes) / (px * px)
+}
+
+// contribution is one source pixel's weight in a resampled pixel.
+type contribution struct {
+	index  int
+	weight float64
+}
+
+// lanczosWeights returns, for each of dst output positions along an axis,
+// the source positions it draws from and their normalized weights.
+// Positions past the edge are clamped to the border pixel.
+func lanczosWeights(src, dst int) [][]contribution {
+	scale := float64(src) / float64(dst)
+	weights := make([][]contribution, dst)
+	for i := range weights {
+		center := (float64(i)+0.5)*scale - 0.5
+		start := int(math.Floor(center)) - lanczosLobes + 1
+		var sum float64
+		for j := start; j < start+2*lanczosLobes; j++ {
+			w := lanczos(center - float64(j))
+			weights[i] = append(weights[i], contribution{index: min(max(j, 0), src-1), weight: w})
+			sum += w
+		}
+		for k := range weights[i] {
+			weights[i][k].weight /= sum
+		}
+	}
+	return weights
+}
+
+// ResizeLanczos resamples src to width x height with a Lanczos-3 filter,
+// rows first and then columns. Suited to enlarging; downscale is cheaper for shrinking.
+func ResizeLanczos(src image.Image, width, height int) *image.RGBA {
+	bounds := src.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	rgba := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(rgba, rgba.Bounds(), src, bounds.Min, draw.Src)
+
+	// Resize each row into a width x sh buffer
+	columns := lanczosWeights(sw, width)
+	tmp := make([]float64, width*sh*4)
+	for y := 0; y < sh; y++ {
+		for x, contribs := range columns {
+			d := tmp[(y*width+x)*4:]
+			for _, ct := range contribs {
+				s := rgba.Pix[y*rgba.Stride+ct.index*4:]
+				for c := 0; c < 4; c++ {
+					d[c] += ct.weight * float64(s[c])
+				}
+			}
+		}
+	}
+
+	// Then each column into the destination
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y, contribs := range lanczosWeights(sh, height) {
+		for x := 0; x < width; x++ {
+			var px [4]float64
+			for _, ct := range contribs {
+				s := tmp[(ct.index*width+x)*4:]
+				for c := range px {
+					px[c] += ct.weight * s[c]
+				}
+			}
+			// Premultiplied color can't exceed alpha; ringing at edges can push it over
+			alpha := clampByte(px[3])
+			d := dst.Pix[y*dst.Stride+x*4:]
+			for c := 0; c < 3; c++ {
+				d[c] = min(clampByte(px[c]), alpha)
+			}
+			d[3] = alpha
+		}
+	}
+	return dst
+}
+
+// clampByte rounds v to the nearest uint8, clamping the overshoot Lanczos produces at sharp edges.
+func clampByte(v float64) uint8 {
+	return uint8(min(max(math.Round(v), 0), 255))
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShrinkImage(t *testing.T) {
+	// Noise compresses poorly, so the PNG is large
+	rng := rand.New(rand.NewSource(1)) //nolint:gosec // G404: deterministic test data
+	img := image.NewRGBA(image.Rect(0, 0, 400, 300))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(rng.Intn(256)) //nolint:gosec // G115: value is below 256
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	original := buf.Bytes()
+
+	limit := int64(len(original) / 10)
+	shrunk, mimeType, err := ShrinkImage(original, limit)
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", mimeType)
+	assert.LessOrEqual(t, int64(len(shrunk)), limit)
+
+	decoded, err := jpeg.Decode(bytes.NewReader(shrunk))
+	require.NoError(t, err)
+	assert.Less(t, decoded.Bounds().Dx(), 400)
+	ratio := float64(decoded.Bounds().Dx()) / float64(decoded.Bounds().Dy())
+	assert.InDelta(t, 4.0/3.0, ratio, 0.05)
+
+	_, _, err = ShrinkImage([]byte("not an image"), limit)
+	assert.Error(t, err)
+
+	_, _, err = ShrinkImage(original, 10)
+	assert.Error(t, err)
+}
+
+func TestDownscaleAveragesPixels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.RGBA{R: 200, A: 255})
+	img.Set(1, 0, color.RGBA{R: 100, A: 255})
+	img.Set(0, 1, color.RGBA{R: 0, A: 255})
+	img.Set(1, 1, color.RGBA{R: 100, A: 255})
+
+	out := downscale(img, 1, 1)
+	assert.Equal(t, color.RGBA{R: 100, A: 255}, out.RGBAAt(0, 0))
+}
+
+func TestResizeLanczos(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{R: 40, G: 120, B: 200, A: 255}), image.Point{}, draw.Src)
+
+	out := ResizeLanczos(img, 8, 6)
+	assert.Equal(t, image.Rect(0, 0, 8, 6), out.Bounds())
+	// A flat image stays flat: the weights are normalized
+	assert.Equal(t, color.RGBA{R: 40, G: 120, B: 200, A: 255}, out.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 40, G: 120, B: 200, A: 255}, out.RGBAAt(7, 5))
+
+	// A hard edge stays ordered and in range despite the kernel's ringing
+	edge := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	edge.Set(0, 0, color.RGBA{A: 255})
+	edge.Set(1, 0, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	wide := ResizeLanczos(edge, 8, 1)
+	assert.Less(t, wide.RGBAAt(1, 0).R, wide.RGBAAt(6, 0).R)
+}
+
+func TestComposeGrid(t *testing.T) {
+	solid := func(w, h int, c color.RGBA) image.Image {
+		img := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+		return img
+	}
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+
+	// Three images make a 2x2 grid; cells take the largest size (40x20)
+	sheet := ComposeGrid([]image.Image{solid(40, 20, red), solid(20, 10, blue), solid(40, 20, red)})
+	assert.Equal(t, 2*(40+gridGap)+gridGap, sheet.Bounds().Dx())
+	assert.Equal(t, 2*(20+gridGap)+gridGap, sheet.Bounds().Dy())
+
+	assert.Equal(t, red, sheet.RGBAAt(gridGap, gridGap))
+	// The smaller image is centered in the second cell, with white padding around it
+	cellX := gridGap + 40 + gridGap
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, sheet.RGBAAt(cellX, gridGap))
+	assert.Equal(t, blue, sheet.RGBAAt(cellX+20, gridGap+10))
+
+	assert.True(t, ComposeGrid(nil).Bounds().Empty())
+}
+
+func TestCheckImageFileSize(t *testing.T) {
+	dir := t.TempDir()
+
+	// Sparse and not an image: rejected on size alone, before any decoding
+	big := filepath.Join(dir, "big.png")
+	require.NoError(t, os.WriteFile(big, nil, 0600))
+	require.NoError(t, os.Truncate(big, MaxReferenceImageBytes+1))
+	err := CheckImageFileSize(big, MaxReferenceImageBytes)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "limit is 5.0 MB")
+
+	small := filepath.Join(dir, "small.png")
+	require.NoError(t, os.WriteFile(small, []byte("tiny"), 0600))
+	assert.NoError(t, CheckImageFileSize(small, MaxReferenceImageBytes))
+
+	assert.ErrorIs(t, CheckImageFileSize(filepath.Join(dir, "missing.png"), MaxReferenceImageBytes), os.ErrNotExist)
+}
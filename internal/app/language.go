@@ -0,0 +1,31 @@
+package app
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxLanguageSample is how many characters of transcript are sent for language detection.
+const maxLanguageSample = 1000
+
+// LanguageDetectionPrompt asks a chat model for the ISO 639-1 code of text.
+func LanguageDetectionPrompt(text string) string {
+	text = strings.TrimSpace(text)
+	if runes := []rune(text); len(runes) > maxLanguageSample {
+		text = string(runes[:maxLanguageSample])
+	}
+	return "Identify the language of the following transcript. Reply with only its two-letter ISO 639-1 code (for example en, zh, ja).\n\n" + text
+}
+
+// languageCodePattern matches a bare ISO 639-1 code in a model reply.
+var languageCodePattern = regexp.MustCompile(`(?i)^[^a-z]*([a-z]{2})[^a-z]*$`)
+
+// ParseLanguageCode extracts a lowercase ISO 639-1 code from a reply such as
+// "zh", "`ja`", or "EN." Returns false for anything else.
+func ParseLanguageCode(reply string) (string, bool) {
+	m := languageCodePattern.FindStringSubmatch(strings.TrimSpace(reply))
+	if m == nil {
+		return "", false
+	}
+	return strings.ToLower(m[1]), true
+}
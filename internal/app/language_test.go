@@ -0,0 +1,26 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLanguageCode(t *testing.T) {
+	for reply, want := range map[string]string{"zh": "zh", " `ja` ": "ja", "EN.": "en", "\"fr\"\n": "fr"} {
+		code, ok := ParseLanguageCode(reply)
+		assert.True(t, ok, reply)
+		assert.Equal(t, want, code, reply)
+	}
+	for _, reply := range []string{"", "English", "the language is en", "eng"} {
+		_, ok := ParseLanguageCode(reply)
+		assert.False(t, ok, reply)
+	}
+}
+
+func TestLanguageDetectionPromptTruncates(t *testing.T) {
+	prompt := LanguageDetectionPrompt(strings.Repeat("a", 5000))
+	assert.Less(t, len(prompt), 1500)
+	assert.Contains(t, prompt, "ISO 639-1")
+}
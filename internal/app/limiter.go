@@ -0,0 +1,87 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// AdaptiveLimiter caps concurrent requests with a limit that adapts AIMD-style:
+// it grows by one after a full window of successes and halves on throttling.
+type AdaptiveLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	min       int
+	max       int
+	active    int
+	successes int
+	changed   chan struct{} // Closed and replaced whenever a slot may have opened
+}
+
+// NewAdaptiveLimiter returns a limiter starting at minLimit concurrent slots,
+// never going below minLimit or above maxLimit.
+func NewAdaptiveLimiter(minLimit, maxLimit int) *AdaptiveLimiter {
+	minLimit = max(minLimit, 1)
+	return &AdaptiveLimiter{
+		limit:   minLimit,
+		min:     minLimit,
+		max:     max(maxLimit, minLimit),
+		changed: make(chan struct{}),
+	}
+}
+
+// Acquire blocks until a slot is free or ctx is done.
+func (l *AdaptiveLimiter) Acquire(ctx context.Context) error {
+	for {
+		l.mu.Lock()
+		if l.active < l.limit {
+			l.active++
+			l.mu.Unlock()
+			return nil
+		}
+		wait := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-wait:
+		}
+	}
+}
+
+// Release frees a slot and adjusts the limit: halved when throttled,
+// raised by one once limit consecutive requests have succeeded.
+func (l *AdaptiveLimiter) Release(throttled bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.active--
+	if throttled {
+		l.limit = max(l.min, l.limit/2)
+		l.successes = 0
+	} else {
+		l.successes++
+		if l.successes >= l.limit && l.limit < l.max {
+			l.limit++
+			l.successes = 0
+		}
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// Limit returns the current concurrency limit.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.limit
+}
+
+// IsThrottleError reports whether err is an API rate-limit (429) or overload (503) response.
+func IsThrottleError(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) &&
+		(apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode == http.StatusServiceUnavailable)
+}
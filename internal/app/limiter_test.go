@@ -0,0 +1,62 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdaptiveLimiterAIMD(t *testing.T) {
+	l := NewAdaptiveLimiter(2, 6)
+	assert.Equal(t, 2, l.Limit())
+
+	// A full window of successes raises the limit by one
+	for range 2 {
+		require.NoError(t, l.Acquire(context.Background()))
+		l.Release(false)
+	}
+	assert.Equal(t, 3, l.Limit())
+	for range 3 {
+		require.NoError(t, l.Acquire(context.Background()))
+		l.Release(false)
+	}
+	assert.Equal(t, 4, l.Limit())
+
+	// Throttling halves it, but not below the minimum
+	require.NoError(t, l.Acquire(context.Background()))
+	l.Release(true)
+	assert.Equal(t, 2, l.Limit())
+	require.NoError(t, l.Acquire(context.Background()))
+	l.Release(true)
+	assert.Equal(t, 2, l.Limit())
+}
+
+func TestAdaptiveLimiterBlocksAtLimit(t *testing.T) {
+	l := NewAdaptiveLimiter(1, 1)
+	require.NoError(t, l.Acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, l.Acquire(ctx), context.DeadlineExceeded)
+
+	acquired := make(chan error, 1)
+	go func() { acquired <- l.Acquire(context.Background()) }()
+	l.Release(false)
+	select {
+	case err := <-acquired:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("waiter was not woken by Release")
+	}
+}
+
+func TestIsThrottleError(t *testing.T) {
+	assert.True(t, IsThrottleError(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 429})))
+	assert.True(t, IsThrottleError(&APIError{StatusCode: 503}))
+	assert.False(t, IsThrottleError(&APIError{StatusCode: 500}))
+	assert.False(t, IsThrottleError(fmt.Errorf("network down")))
+}
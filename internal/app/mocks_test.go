@@ -93,6 +93,14 @@ func (m *MockHistoryStore) GetRecent(limit int) ([]HistoryEntry, error) {
 	return args.Get(0).([]HistoryEntry), args.Error(1)
 }
 
+func (m *MockHistoryStore) Search(term string, limit int) ([]HistoryEntry, error) {
+	args := m.Called(term, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]HistoryEntry), args.Error(1)
+}
+
 func (m *MockHistoryStore) Path() string {
 	args := m.Called()
 	return args.String(0)
@@ -1,8 +1,12 @@
 package app
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os/exec"
+	"path/filepath"
+	"strings"
 )
 
 // OpenWith opens a file or URL with the system's default handler.
@@ -31,3 +35,140 @@ func buildOpenCommand(target string) (*exec.Cmd, error) {
 	}
 	return nil, fmt.Errorf("no platform opener available (need: open, xdg-open, or start)")
 }
+
+// SplitCommandLine splits a command string into arguments without invoking a
+// shell. Single quotes are literal, double quotes allow \" and \\ escapes,
+// and a backslash outside quotes escapes the next character.
+func SplitCommandLine(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			// Inside double quotes only \" and \\ are escapes, as in a shell
+			if quote == '"' && r != '"' && r != '\\' {
+				cur.WriteRune('\\')
+			}
+			cur.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\\' && (quote == 0 || quote == '"'):
+			escaped = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape in command: %s", line)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
+
+// ResolvePipeCommand splits command and resolves its program on PATH, so a
+// missing tool can be reported before any work is done. args[0] is the full path.
+func ResolvePipeCommand(command string) ([]string, error) {
+	return resolveCommand("pipe", command)
+}
+
+// ResolveNotifyCommand is ResolvePipeCommand for the notify.command setting.
+func ResolveNotifyCommand(command string) ([]string, error) {
+	return resolveCommand("notify", command)
+}
+
+// resolveCommand splits command and resolves its program on PATH; kind names
+// the setting in error messages.
+func resolveCommand(kind, command string) ([]string, error) {
+	args, err := SplitCommandLine(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, fmt.Errorf("empty %s command", kind)
+	}
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return nil, fmt.Errorf("%s command not found: %s", kind, args[0])
+	}
+	args[0] = path
+	return args, nil
+}
+
+// PipeThrough runs command (split with SplitCommandLine, no shell) with input
+// on its stdin, streaming its output to stdout and stderr.
+func PipeThrough(command string, input []byte, stdout, stderr io.Writer) error {
+	args, err := ResolvePipeCommand(command)
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // G204: user-chosen command, run without a shell
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("pipe command %s failed: %w", filepath.Base(args[0]), err)
+	}
+	return nil
+}
+
+// Notify reports that a long operation finished with status ("success" or
+// "failure"). With no command it rings the terminal bell on bell. Otherwise
+// the command runs without a shell: {status} and {message} in its arguments
+// are replaced, or both are appended when neither placeholder is used.
+func Notify(command, status, message string, bell io.Writer) error {
+	if command == "" {
+		_, err := io.WriteString(bell, "\a")
+		return err
+	}
+
+	args, err := ResolveNotifyCommand(command)
+	if err != nil {
+		return err
+	}
+	placeholders := false
+	replacer := strings.NewReplacer("{status}", status, "{message}", message)
+	for i, arg := range args[1:] {
+		if strings.Contains(arg, "{status}") || strings.Contains(arg, "{message}") {
+			args[i+1] = replacer.Replace(arg)
+			placeholders = true
+		}
+	}
+	if !placeholders {
+		args = append(args, status, message)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...) //nolint:gosec // G204: user-chosen command, run without a shell
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command %s failed: %w: %s", filepath.Base(args[0]), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package app
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := map[string][]string{
+		`glow -`:                    {"glow", "-"},
+		`  bat  -l md `:             {"bat", "-l", "md"},
+		`sed 's/a b/c/'`:            {"sed", "s/a b/c/"},
+		`printf "%s \"x\"\n" 'y'`:   {"printf", `%s "x"\n`, "y"},
+		`tool my\ file ""`:          {"tool", "my file", ""},
+		`/opt/My\ Tools/fmt --wrap`: {"/opt/My Tools/fmt", "--wrap"},
+	}
+	for line, want := range tests {
+		got, err := SplitCommandLine(line)
+		require.NoError(t, err, line)
+		assert.Equal(t, want, got, line)
+	}
+
+	for _, bad := range []string{`glow "unterminated`, `sed 'x`, `trailing\`} {
+		_, err := SplitCommandLine(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestPipeThrough(t *testing.T) {
+	err := PipeThrough("zai-no-such-command-xyz", []byte("hi"), &bytes.Buffer{}, &bytes.Buffer{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not found")
+
+	if _, err := exec.LookPath("cat"); err != nil {
+		t.Skip("cat not available")
+	}
+	var out bytes.Buffer
+	require.NoError(t, PipeThrough("cat", []byte("# Title\n"), &out, &bytes.Buffer{}))
+	assert.Equal(t, "# Title\n", out.String())
+}
+
+func TestNotify(t *testing.T) {
+	var bell bytes.Buffer
+	require.NoError(t, Notify("", "success", "done", &bell))
+	assert.Equal(t, "\a", bell.String())
+
+	err := Notify("zai-no-such-command-xyz", "failure", "boom", &bell)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "notify command not found")
+
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	out := filepath.Join(t.TempDir(), "notify.txt")
+	require.NoError(t, Notify(`sh -c 'echo "$1|$2" > "$0"' `+out, "success", "video saved", &bell))
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "success|video saved\n", string(data))
+
+	require.NoError(t, Notify(`sh -c 'echo "$1" > "$0"' `+out+` "zai {status}: {message}"`, "failure", "boom", &bell))
+	data, err = os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "zai failure: boom\n", string(data))
+}
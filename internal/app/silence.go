@@ -0,0 +1,104 @@
+package app
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Silence is a pause detected by ffmpeg's silencedetect filter.
+type Silence struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+var (
+	silenceStartRegex   = regexp.MustCompile(`silence_start:\s*(-?[\d.]+)`)
+	silenceEndRegex     = regexp.MustCompile(`silence_end:\s*(-?[\d.]+)`)
+	ffmpegDurationRegex = regexp.MustCompile(`Duration:\s*(\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+)
+
+// ParseSilenceDetect extracts silences from ffmpeg silencedetect log output.
+// A silence_start without a matching silence_end (silence running to the end
+// of the file) is dropped.
+func ParseSilenceDetect(log string) []Silence {
+	var silences []Silence
+	var start time.Duration
+	open := false
+	for _, line := range strings.Split(log, "\n") {
+		if m := silenceStartRegex.FindStringSubmatch(line); m != nil {
+			if d, ok := parseSeconds(m[1]); ok {
+				start, open = max(0, d), true
+			}
+			continue
+		}
+		if m := silenceEndRegex.FindStringSubmatch(line); m != nil && open {
+			if end, ok := parseSeconds(m[1]); ok && end > start {
+				silences = append(silences, Silence{Start: start, End: end})
+			}
+			open = false
+		}
+	}
+	return silences
+}
+
+// ParseFFmpegDuration extracts the input duration ("Duration: 00:01:23.45")
+// from ffmpeg log output.
+func ParseFFmpegDuration(log string) (time.Duration, bool) {
+	m := ffmpegDurationRegex.FindStringSubmatch(log)
+	if m == nil {
+		return 0, false
+	}
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, ok := parseSeconds(m[3])
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute + seconds, true
+}
+
+// SilenceSplitPoints picks segment boundaries at the midpoints of silences,
+// keeping each segment as long as possible without exceeding maxSegment.
+// Speech that runs past maxSegment without a pause is cut at maxSegment.
+// total bounds the final segment; pass 0 when the duration is unknown.
+// The returned points exclude 0 and total.
+func SilenceSplitPoints(silences []Silence, total, maxSegment time.Duration) []time.Duration {
+	if maxSegment <= 0 {
+		return nil
+	}
+
+	var points []time.Duration
+	var start, candidate time.Duration
+	advance := func(until time.Duration) {
+		for until-start > maxSegment {
+			if candidate > start {
+				start = candidate
+			} else {
+				start += maxSegment
+			}
+			points = append(points, start)
+		}
+	}
+
+	for _, s := range silences {
+		mid := s.Start + (s.End-s.Start)/2
+		if mid <= start || (total > 0 && mid >= total) {
+			continue
+		}
+		advance(mid)
+		candidate = mid
+	}
+	advance(total)
+	return points
+}
+
+// parseSeconds parses a decimal number of seconds.
+func parseSeconds(s string) (time.Duration, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(f * float64(time.Second)), true
+}
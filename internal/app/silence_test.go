@@ -0,0 +1,51 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const silenceDetectLog = `Input #0, wav, from 'talk.wav':
+  Duration: 00:01:05.50, bitrate: 256 kb/s
+[silencedetect @ 0x7f8] silence_start: 9.5
+[silencedetect @ 0x7f8] silence_end: 10.5 | silence_duration: 1
+[silencedetect @ 0x7f8] silence_start: 22
+[silencedetect @ 0x7f8] silence_end: 23 | silence_duration: 1
+[silencedetect @ 0x7f8] silence_start: 30.2
+[silencedetect @ 0x7f8] silence_end: 30.8 | silence_duration: 0.6
+[silencedetect @ 0x7f8] silence_start: 64.9
+size=N/A time=00:01:05.50 bitrate=N/A speed= 900x`
+
+func TestParseSilenceDetect(t *testing.T) {
+	silences := ParseSilenceDetect(silenceDetectLog)
+	require.Len(t, silences, 3, "trailing silence without an end is dropped")
+	assert.Equal(t, Silence{Start: 9500 * time.Millisecond, End: 10500 * time.Millisecond}, silences[0])
+	assert.Equal(t, 30800*time.Millisecond, silences[2].End)
+
+	total, ok := ParseFFmpegDuration(silenceDetectLog)
+	require.True(t, ok)
+	assert.Equal(t, 65500*time.Millisecond, total)
+
+	_, ok = ParseFFmpegDuration("no duration here")
+	assert.False(t, ok)
+}
+
+func TestSilenceSplitPoints(t *testing.T) {
+	silences := ParseSilenceDetect(silenceDetectLog)
+	points := SilenceSplitPoints(silences, 65500*time.Millisecond, 25*time.Second)
+
+	// Latest pause before 25s is at 22.5s; the next at 30.5s fits; the rest has
+	// no pause within 25s, so it is cut at the limit.
+	assert.Equal(t, []time.Duration{22500 * time.Millisecond, 30500 * time.Millisecond, 55500 * time.Millisecond}, points)
+
+	prev := time.Duration(0)
+	for _, p := range append(points, 65500*time.Millisecond) {
+		assert.LessOrEqual(t, p-prev, 25*time.Second)
+		prev = p
+	}
+
+	assert.Empty(t, SilenceSplitPoints(silences[:1], 20*time.Second, 25*time.Second), "short audio needs no split")
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WebSnapshot is the last fetched content of a page, kept for change detection.
+type WebSnapshot struct {
+	URL       string    `json:"url"`
+	Format    string    `json:"format"`
+	Content   string    `json:"content"`
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// WebSnapshotStore keeps one snapshot file per URL and format.
+type WebSnapshotStore struct {
+	dir string
+}
+
+// NewWebSnapshotStore creates a snapshot store rooted at dir.
+func NewWebSnapshotStore(dir string) *WebSnapshotStore {
+	return &WebSnapshotStore{dir: dir}
+}
+
+// Load returns the stored snapshot for url and format, if any.
+func (s *WebSnapshotStore) Load(url, format string) (*WebSnapshot, bool, error) {
+	data, err := os.ReadFile(s.path(url, format))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("read snapshot: %w", err)
+	}
+
+	var snapshot WebSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, false, fmt.Errorf("parse snapshot: %w", err)
+	}
+	return &snapshot, true, nil
+}
+
+// Save replaces the stored snapshot for the snapshot's URL and format.
+func (s *WebSnapshotStore) Save(snapshot WebSnapshot) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("create snapshot directory: %w", err)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("marshal snapshot: %w", err)
+	}
+	if err := os.WriteFile(s.path(snapshot.URL, snapshot.Format), data, 0600); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return nil
+}
+
+// path names the snapshot file by a hash of the URL and format.
+func (s *WebSnapshotStore) path(url, format string) string {
+	hash := sha256.Sum256([]byte(url + "\x00" + format))
+	return filepath.Join(s.dir, hex.EncodeToString(hash[:])+".json")
+}
@@ -0,0 +1,64 @@
+package app
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ChatStreamChunk is one server-sent event of a streaming chat completion.
+type ChatStreamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// parseSSEStream reads "data:" events from an OpenAI-style SSE stream,
+// calling onDelta with each content fragment. Stops at "data: [DONE]" or EOF.
+// Returns the full concatenated content and the final usage, if reported.
+func parseSSEStream(r io.Reader, onDelta func(string)) (string, Usage, error) {
+	var content strings.Builder
+	var usage Usage
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok {
+			continue // Comments, event names, and blank separators
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ChatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return content.String(), usage, fmt.Errorf("invalid stream event: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onDelta != nil {
+				onDelta(choice.Delta.Content)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return content.String(), usage, fmt.Errorf("error reading stream: %w", err)
+	}
+
+	return content.String(), usage, nil
+}
@@ -0,0 +1,149 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseSSEStream tests parsing OpenAI-style server-sent events.
+func TestParseSSEStream(t *testing.T) {
+	stream := strings.Join([]string{
+		`: keep-alive`,
+		`data: {"choices":[{"delta":{"content":"Hello"}}]}`,
+		``,
+		`data: {"choices":[{"delta":{"content":", world"}}]}`,
+		`data: {"choices":[{"delta":{}}],"usage":{"total_tokens":12}}`,
+		`data: [DONE]`,
+		`data: {"choices":[{"delta":{"content":"ignored"}}]}`,
+	}, "\n")
+
+	var deltas []string
+	content, usage, err := parseSSEStream(strings.NewReader(stream), func(d string) { deltas = append(deltas, d) })
+	require.NoError(t, err)
+	assert.Equal(t, "Hello, world", content)
+	assert.Equal(t, []string{"Hello", ", world"}, deltas)
+	assert.Equal(t, 12, usage.TotalTokens)
+
+	_, _, err = parseSSEStream(strings.NewReader("data: {not json"), nil)
+	assert.Error(t, err)
+}
+
+// TestClientVisionStream tests streamed and buffered-fallback vision responses.
+func TestClientVisionStream(t *testing.T) {
+	streaming := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if streaming {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"a \"}}]}\n\n")  //nolint:errcheck // test mock
+			fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"cat\"}}]}\n\n") //nolint:errcheck // test mock
+			fmt.Fprint(w, "data: [DONE]\n\n")                                            //nolint:errcheck // test mock
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices":[{"message":{"content":"a dog"}}]}`) //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), nil, nil)
+
+	var got strings.Builder
+	content, err := client.VisionStream(context.Background(), "describe", "data:image/png;base64,AAAA", VisionOptions{}, func(d string) { got.WriteString(d) })
+	require.NoError(t, err)
+	assert.Equal(t, "a cat", content)
+	assert.Equal(t, "a cat", got.String())
+
+	streaming = false
+	got.Reset()
+	content, err = client.VisionStream(context.Background(), "describe", "data:image/png;base64,AAAA", VisionOptions{}, func(d string) { got.WriteString(d) })
+	require.NoError(t, err)
+	assert.Equal(t, "a dog", content)
+	assert.Equal(t, "a dog", got.String())
+}
+
+// TestClientChatStream tests that streamed chat sends stream=true and saves the assembled response.
+func TestClientChatStream(t *testing.T) {
+	var stream bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		stream = req.Stream
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n")                               //nolint:errcheck // test mock
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}],\"usage\":{\"total_tokens\":7}}\n\n") //nolint:errcheck // test mock
+		fmt.Fprint(w, "data: [DONE]\n\n")                                                                          //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	history := &MockHistoryStore{}
+	history.On("Save", mock.MatchedBy(func(e HistoryEntry) bool {
+		return e.Prompt == "hi" && e.Response == "Hello" && e.TokenUsage.TotalTokens == 7
+	})).Return(nil)
+	client := NewClient(ClientConfig{APIKey: "test-api-key", BaseURL: server.URL}, DiscardLogger(), history, nil)
+
+	var deltas []string
+	content, err := client.ChatStream(context.Background(), "hi", ChatOptions{}, func(d string) { deltas = append(deltas, d) })
+	require.NoError(t, err)
+	assert.True(t, stream)
+	assert.Equal(t, "Hello", content)
+	assert.Equal(t, []string{"Hel", "lo"}, deltas)
+	history.AssertExpectations(t)
+}
+
+// TestClientChatStreamRetry tests that a stream is retried until output starts, but not after.
+func TestClientChatStreamRetry(t *testing.T) {
+	calls := 0
+	breakMidStream := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 && !breakMidStream {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\n") //nolint:errcheck // test mock
+		if breakMidStream {
+			fmt.Fprint(w, "data: {broken\n\n") //nolint:errcheck // test mock
+			return
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n") //nolint:errcheck // test mock
+		fmt.Fprint(w, "data: [DONE]\n\n")                                           //nolint:errcheck // test mock
+	}))
+	defer server.Close()
+
+	client := NewClient(ClientConfig{
+		APIKey:  "test-api-key",
+		BaseURL: server.URL,
+		RetryConfig: RetryConfig{
+			MaxAttempts:    3,
+			InitialBackoff: time.Millisecond,
+			MaxBackoff:     time.Millisecond,
+			TimeoutBackoff: true,
+		},
+	}, DiscardLogger(), nil, nil)
+
+	var got strings.Builder
+	content, err := client.ChatStream(context.Background(), "hi", ChatOptions{}, func(d string) { got.WriteString(d) })
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls, "503 is retried")
+	assert.Equal(t, "Hello", content)
+	assert.Equal(t, "Hello", got.String())
+
+	// Output already shown: a broken stream fails instead of repeating text
+	calls, breakMidStream = 0, true
+	got.Reset()
+	content, err = client.ChatStream(context.Background(), "hi", ChatOptions{}, func(d string) { got.WriteString(d) })
+	require.Error(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "Hel", content)
+	assert.Equal(t, "Hel", got.String())
+}
@@ -0,0 +1,264 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Subtitle cue defaults: two lines of 42 characters is the common broadcast convention.
+const (
+	DefaultCueLineChars = 42
+	DefaultMaxCueChars  = 2 * DefaultCueLineChars
+)
+
+// SubtitleCue is a single timed caption.
+type SubtitleCue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// SplitCue splits a cue into cues of at most maxChars characters.
+// Breaks on word boundaries, preferring sentence ends once a cue is half full,
+// and distributes the original time span proportionally to text length.
+// A single word longer than maxChars becomes its own cue.
+func SplitCue(cue SubtitleCue, maxChars int) []SubtitleCue {
+	text := strings.Join(strings.Fields(cue.Text), " ")
+	if maxChars <= 0 || utf8.RuneCountInString(text) <= maxChars {
+		cue.Text = text
+		return []SubtitleCue{cue}
+	}
+
+	segments := splitCueText(text, maxChars)
+
+	totalRunes := 0
+	for _, seg := range segments {
+		totalRunes += utf8.RuneCountInString(seg)
+	}
+
+	span := cue.End - cue.Start
+	cues := make([]SubtitleCue, 0, len(segments))
+	start := cue.Start
+	consumed := 0
+	for i, seg := range segments {
+		consumed += utf8.RuneCountInString(seg)
+		end := cue.Start + time.Duration(float64(span)*float64(consumed)/float64(totalRunes))
+		if i == len(segments)-1 {
+			end = cue.End
+		}
+		cues = append(cues, SubtitleCue{Start: start, End: end, Text: seg})
+		start = end
+	}
+	return cues
+}
+
+// splitCueText greedily packs words into segments of at most maxChars.
+func splitCueText(text string, maxChars int) []string {
+	var segments []string
+	var cur strings.Builder
+	curLen := 0
+
+	flush := func() {
+		if curLen > 0 {
+			segments = append(segments, cur.String())
+			cur.Reset()
+			curLen = 0
+		}
+	}
+
+	for _, word := range strings.Fields(text) {
+		wordLen := utf8.RuneCountInString(word)
+		if curLen > 0 && curLen+1+wordLen > maxChars {
+			flush()
+		}
+		if curLen > 0 {
+			cur.WriteByte(' ')
+			curLen++
+		}
+		cur.WriteString(word)
+		curLen += wordLen
+
+		// Prefer ending a cue at a sentence boundary once it is reasonably full
+		if curLen >= maxChars/2 && strings.ContainsAny(word[len(word)-1:], ".?!") {
+			flush()
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// WrapCueText breaks cue text into lines of at most lineChars on word boundaries.
+func WrapCueText(text string, lineChars int) string {
+	if lineChars <= 0 || utf8.RuneCountInString(text) <= lineChars {
+		return text
+	}
+
+	var lines []string
+	var line strings.Builder
+	lineLen := 0
+	for _, word := range strings.Fields(text) {
+		wordLen := utf8.RuneCountInString(word)
+		if lineLen > 0 && lineLen+1+wordLen > lineChars {
+			lines = append(lines, line.String())
+			line.Reset()
+			lineLen = 0
+		}
+		if lineLen > 0 {
+			line.WriteByte(' ')
+			lineLen++
+		}
+		line.WriteString(word)
+		lineLen += wordLen
+	}
+	if lineLen > 0 {
+		lines = append(lines, line.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ChunkCues builds cues for transcripts of consecutive fixed-length chunks:
+// chunk i spans [i*chunkDuration, (i+1)*chunkDuration), except the last,
+// which ends at lastEnd when that is positive. Texts longer than maxChars are
+// split into readable cues; empty chunks produce none.
+func ChunkCues(texts []string, chunkDuration, lastEnd time.Duration, maxChars int) []SubtitleCue {
+	starts := make([]time.Duration, len(texts))
+	for i := range starts {
+		starts[i] = time.Duration(i) * chunkDuration
+	}
+	if n := len(texts); n > 0 && lastEnd <= starts[n-1] {
+		lastEnd = time.Duration(n) * chunkDuration
+	}
+	return SpanCues(texts, starts, lastEnd, maxChars)
+}
+
+// SpanCues builds cues for transcripts of consecutive segments: text i spans
+// [starts[i], starts[i+1]) and the last ends at end. When end is not after the
+// last start, the last segment's length is estimated from its text. Texts
+// longer than maxChars are split into several cues.
+func SpanCues(texts []string, starts []time.Duration, end time.Duration, maxChars int) []SubtitleCue {
+	var cues []SubtitleCue
+	for i, text := range texts {
+		if strings.TrimSpace(text) == "" || i >= len(starts) {
+			continue
+		}
+		cue := SubtitleCue{Start: starts[i], End: end, Text: text}
+		if i+1 < len(starts) {
+			cue.End = starts[i+1]
+		}
+		if cue.End <= cue.Start {
+			cue.End = cue.Start + EstimateSpeechDuration(text)
+		}
+		cues = append(cues, SplitCue(cue, maxChars)...)
+	}
+	return cues
+}
+
+// LowConfidenceMarker prefixes transcript segments scored below --min-confidence.
+const LowConfidenceMarker = "[?]"
+
+// IsLowConfidence reports whether seg was scored below minConfidence.
+// Unscored segments are never low confidence.
+func IsLowConfidence(seg TranscriptionSegment, minConfidence float64) bool {
+	return seg.Confidence != nil && *seg.Confidence < minConfidence
+}
+
+// LowConfidenceSegments returns the segments scored below minConfidence.
+// scored is false when no segment carries a confidence score at all.
+func LowConfidenceSegments(segments []TranscriptionSegment, minConfidence float64) (low []TranscriptionSegment, scored bool) {
+	low = []TranscriptionSegment{}
+	for _, seg := range segments {
+		if seg.Confidence == nil {
+			continue
+		}
+		scored = true
+		if IsLowConfidence(seg, minConfidence) {
+			low = append(low, seg)
+		}
+	}
+	return low, scored
+}
+
+// AnnotateLowConfidence joins segment texts into a transcript, marking
+// segments scored below minConfidence with LowConfidenceMarker.
+func AnnotateLowConfidence(segments []TranscriptionSegment, minConfidence float64) string {
+	parts := make([]string, 0, len(segments))
+	for _, seg := range segments {
+		text := strings.TrimSpace(seg.Text)
+		if text == "" {
+			continue
+		}
+		if IsLowConfidence(seg, minConfidence) {
+			text = LowConfidenceMarker + " " + text
+		}
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, " ")
+}
+
+// SegmentCues builds cues from timed segments, marking segments scored below
+// minConfidence (pass 0 to mark none). Segments longer than maxChars are split
+// into readable cues.
+func SegmentCues(segments []TranscriptionSegment, minConfidence float64, maxChars int) []SubtitleCue {
+	var cues []SubtitleCue
+	for _, seg := range segments {
+		if strings.TrimSpace(seg.Text) == "" {
+			continue
+		}
+		cue := SubtitleCue{
+			Start: time.Duration(seg.Start * float64(time.Second)),
+			End:   time.Duration(seg.End * float64(time.Second)),
+			Text:  seg.Text,
+		}
+		if IsLowConfidence(seg, minConfidence) {
+			cue.Text = LowConfidenceMarker + " " + cue.Text
+		}
+		cues = append(cues, SplitCue(cue, maxChars)...)
+	}
+	return cues
+}
+
+// speechWordsPerSecond approximates conversational speech (150 words a minute).
+const speechWordsPerSecond = 2.5
+
+// EstimateSpeechDuration guesses how long text takes to say, for transcripts
+// without timing. Never less than a second.
+func EstimateSpeechDuration(text string) time.Duration {
+	words := len(strings.Fields(text))
+	return max(time.Second, time.Duration(float64(words)/speechWordsPerSecond*float64(time.Second)))
+}
+
+// FormatSRT renders cues as SubRip subtitles, numbered from 1.
+func FormatSRT(cues []SubtitleCue) string {
+	var sb strings.Builder
+	for i, cue := range cues {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1,
+			subtitleTimestamp(cue.Start, ','), subtitleTimestamp(cue.End, ','),
+			WrapCueText(cue.Text, DefaultCueLineChars))
+	}
+	return sb.String()
+}
+
+// FormatVTT renders cues as WebVTT subtitles.
+func FormatVTT(cues []SubtitleCue) string {
+	var sb strings.Builder
+	sb.WriteString("WEBVTT\n\n")
+	for i, cue := range cues {
+		fmt.Fprintf(&sb, "%d\n%s --> %s\n%s\n\n", i+1,
+			subtitleTimestamp(cue.Start, '.'), subtitleTimestamp(cue.End, '.'),
+			WrapCueText(cue.Text, DefaultCueLineChars))
+	}
+	return sb.String()
+}
+
+// subtitleTimestamp formats d as HH:MM:SS followed by sep and milliseconds
+// (',' for SRT, '.' for WebVTT).
+func subtitleTimestamp(d time.Duration, sep byte) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%c%03d", ms/3_600_000, ms/60_000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSplitCue tests splitting long cues on word and sentence boundaries.
+func TestSplitCue(t *testing.T) {
+	t.Run("short cue is unchanged", func(t *testing.T) {
+		cue := SubtitleCue{Start: 0, End: 5 * time.Second, Text: "Hello  world"}
+		cues := SplitCue(cue, DefaultMaxCueChars)
+		require.Len(t, cues, 1)
+		assert.Equal(t, "Hello world", cues[0].Text)
+		assert.Equal(t, 5*time.Second, cues[0].End)
+	})
+
+	t.Run("long cue respects limit and covers span", func(t *testing.T) {
+		text := strings.Repeat("the quick brown fox jumps over the lazy dog ", 6)
+		cue := SubtitleCue{Start: 10 * time.Second, End: 40 * time.Second, Text: text}
+		cues := SplitCue(cue, 40)
+
+		require.Greater(t, len(cues), 1)
+		assert.Equal(t, 10*time.Second, cues[0].Start)
+		assert.Equal(t, 40*time.Second, cues[len(cues)-1].End)
+		for i, c := range cues {
+			assert.LessOrEqual(t, utf8.RuneCountInString(c.Text), 40)
+			assert.LessOrEqual(t, c.Start, c.End)
+			if i > 0 {
+				assert.Equal(t, cues[i-1].End, c.Start)
+			}
+		}
+		assert.Equal(t, strings.Join(strings.Fields(text), " "), joinCueTexts(cues))
+	})
+
+	t.Run("prefers sentence boundaries", func(t *testing.T) {
+		cue := SubtitleCue{End: 10 * time.Second, Text: "This is the first sentence. And here is the second one that follows."}
+		cues := SplitCue(cue, 50)
+		require.Len(t, cues, 2)
+		assert.Equal(t, "This is the first sentence.", cues[0].Text)
+	})
+
+	t.Run("overlong word gets its own cue", func(t *testing.T) {
+		cue := SubtitleCue{End: time.Second, Text: "a " + strings.Repeat("x", 30) + " b"}
+		cues := SplitCue(cue, 10)
+		require.Len(t, cues, 3)
+		assert.Equal(t, strings.Repeat("x", 30), cues[1].Text)
+	})
+}
+
+// TestWrapCueText tests line wrapping inside a cue.
+func TestWrapCueText(t *testing.T) {
+	assert.Equal(t, "short", WrapCueText("short", 42))
+	assert.Equal(t, "one two\nthree", WrapCueText("one two three", 8))
+}
+
+func joinCueTexts(cues []SubtitleCue) string {
+	texts := make([]string, len(cues))
+	for i, c := range cues {
+		texts[i] = c.Text
+	}
+	return strings.Join(texts, " ")
+}
+
+// TestFormatSRTRoundTrip tests that generated SRT parses back into the same cues.
+func TestFormatSRTRoundTrip(t *testing.T) {
+	texts := []string{
+		"First chunk of the lecture.",
+		"",
+		strings.Repeat("a much longer second chunk that needs splitting ", 4),
+		"Final words.",
+	}
+	cues := ChunkCues(texts, 25*time.Second, 82*time.Second+500*time.Millisecond, DefaultMaxCueChars)
+	require.Greater(t, len(cues), 3)
+	assert.Equal(t, 50*time.Second, cues[1].Start, "empty chunk still advances time")
+
+	parsed := parseSRT(t, FormatSRT(cues))
+	require.Len(t, parsed, len(cues))
+	for i := range cues {
+		assert.Equal(t, cues[i].Start.Truncate(time.Millisecond), parsed[i].Start)
+		assert.Equal(t, cues[i].End.Truncate(time.Millisecond), parsed[i].End)
+		assert.Equal(t, cues[i].Text, parsed[i].Text)
+	}
+	assert.Equal(t, 82*time.Second+500*time.Millisecond, parsed[len(parsed)-1].End)
+
+	// A smaller limit yields more, shorter cues over the same span
+	short := ChunkCues(texts, 25*time.Second, 82*time.Second+500*time.Millisecond, 20)
+	assert.Greater(t, len(short), len(cues))
+	for _, cue := range short {
+		assert.LessOrEqual(t, utf8.RuneCountInString(cue.Text), 20)
+	}
+
+	vtt := FormatVTT(cues[:1])
+	assert.Equal(t, "WEBVTT\n\n1\n00:00:00.000 --> 00:00:25.000\nFirst chunk of the lecture.\n\n", vtt)
+}
+
+func TestLowConfidenceSegments(t *testing.T) {
+	score := func(f float64) *float64 { return &f }
+	segments := []TranscriptionSegment{
+		{Start: 0, End: 2, Text: "Clear opening.", Confidence: score(0.95)},
+		{Start: 2, End: 4.5, Text: "mumbled words", Confidence: score(0.4)},
+		{Start: 4.5, End: 6, Text: "Unscored tail."},
+	}
+
+	low, scored := LowConfidenceSegments(segments, 0.6)
+	require.True(t, scored)
+	require.Len(t, low, 1)
+	assert.Equal(t, "mumbled words", low[0].Text)
+	assert.Equal(t, "Clear opening. [?] mumbled words Unscored tail.", AnnotateLowConfidence(segments, 0.6))
+
+	cues := SegmentCues(segments, 0.6, DefaultMaxCueChars)
+	require.Len(t, cues, 3)
+	assert.Equal(t, 2*time.Second, cues[1].Start)
+	assert.Equal(t, 4500*time.Millisecond, cues[1].End)
+	assert.Equal(t, "[?] mumbled words", cues[1].Text)
+
+	low, scored = LowConfidenceSegments(segments[2:], 0.6)
+	assert.False(t, scored, "segments without scores")
+	assert.Empty(t, low)
+}
+
+// parseSRT parses SubRip text, checking cue numbering and timestamp syntax.
+func parseSRT(t *testing.T, srt string) []SubtitleCue {
+	t.Helper()
+	var cues []SubtitleCue
+	for i, block := range strings.Split(strings.TrimSpace(srt), "\n\n") {
+		lines := strings.Split(block, "\n")
+		require.GreaterOrEqual(t, len(lines), 3, "cue %d", i+1)
+		assert.Equal(t, strconv.Itoa(i+1), lines[0])
+
+		start, end, ok := strings.Cut(lines[1], " --> ")
+		require.True(t, ok, lines[1])
+		cues = append(cues, SubtitleCue{
+			Start: parseSRTTimestamp(t, start),
+			End:   parseSRTTimestamp(t, end),
+			Text:  strings.Join(lines[2:], " "),
+		})
+	}
+	return cues
+}
+
+func parseSRTTimestamp(t *testing.T, s string) time.Duration {
+	t.Helper()
+	var h, m, sec, ms int
+	_, err := fmt.Sscanf(s, "%02d:%02d:%02d,%03d", &h, &m, &sec, &ms)
+	require.NoError(t, err, s)
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec)*time.Second + time.Duration(ms)*time.Millisecond
+}
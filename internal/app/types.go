@@ -1,7 +1,10 @@
 package app
 
 import (
+	"cmp"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"time"
 )
 
@@ -10,20 +13,41 @@ import (
 type APIError struct {
 	StatusCode int
 	Body       string
+	RequestID  string // From the X-Request-Id header or the body's request_id, when present
 }
 
 func (e *APIError) Error() string {
 	return fmt.Sprintf("API error: %d - %s", e.StatusCode, e.Body)
 }
 
+// newAPIError builds an APIError from a non-OK response and its body.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(body), RequestID: resp.Header.Get("X-Request-Id")}
+	if apiErr.RequestID == "" {
+		var payload struct {
+			RequestID string          `json:"request_id"`
+			Error     json.RawMessage `json:"error"` // An object with request_id, or a plain message
+		}
+		var inner struct {
+			RequestID string `json:"request_id"`
+		}
+		if json.Unmarshal(body, &payload) == nil {
+			_ = json.Unmarshal(payload.Error, &inner)
+			apiErr.RequestID = cmp.Or(payload.RequestID, inner.RequestID)
+		}
+	}
+	return apiErr
+}
+
 // ChatRequest represents the API request payload.
 type ChatRequest struct {
 	Model       string    `json:"model"`
 	Messages    []Message `json:"messages"`
-	Stream      bool      `json:"stream"` // Reserved for future streaming API support
-	Temperature float64   `json:"temperature,omitempty"`
+	Stream      bool      `json:"stream"` // Server-sent events (see ChatStream)
+	Temperature float64   `json:"temperature"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	TopP        float64   `json:"top_p,omitempty"`
+	Seed        *int      `json:"seed,omitempty"`
 	Thinking    *Thinking `json:"thinking,omitempty"`
 }
 
@@ -58,9 +82,28 @@ type Choice struct {
 
 // Usage represents token usage statistics.
 type Usage struct {
-	PromptTokens     int `json:"prompt_tokens"`
-	CompletionTokens int `json:"completion_tokens"`
-	TotalTokens      int `json:"total_tokens"`
+	PromptTokens        int                  `json:"prompt_tokens"`
+	CompletionTokens    int                  `json:"completion_tokens"`
+	TotalTokens         int                  `json:"total_tokens"`
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// Cost estimates the dollar cost of the usage from per-1000-token prices.
+func (u Usage) Cost(per1KPrompt, per1KCompletion float64) float64 {
+	return float64(u.PromptTokens)/1000*per1KPrompt + float64(u.CompletionTokens)/1000*per1KCompletion
+}
+
+// PromptTokensDetails breaks down prompt token usage.
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"` // Prompt tokens served from the prefix cache
+}
+
+// CachedTokens returns how many prompt tokens were served from cache.
+func (u Usage) CachedTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
 }
 
 // ModelsResponse represents the /models API response.
@@ -79,11 +122,12 @@ type Model struct {
 
 // ImageGenerationRequest represents the image generation API request.
 type ImageGenerationRequest struct {
-	Model   string `json:"model"` // "glm-image"
-	Prompt  string `json:"prompt"`
-	Quality string `json:"quality,omitempty"` // "hd" or "standard"
-	Size    string `json:"size,omitempty"`    // "1024x1024"
-	UserID  string `json:"user_id,omitempty"` // Optional
+	Model    string `json:"model"` // "glm-image"
+	Prompt   string `json:"prompt"`
+	Quality  string `json:"quality,omitempty"`   // "hd" or "standard"
+	Size     string `json:"size,omitempty"`      // "1024x1024"
+	UserID   string `json:"user_id,omitempty"`   // Optional
+	ImageURL string `json:"image_url,omitempty"` // Reference image (URL or base64 data URI)
 }
 
 // ImageResponse represents the image generation API response.
@@ -105,6 +149,20 @@ type ImageData struct {
 	Format        string `json:"format"`
 }
 
+// ImageUpscaleRequest represents an image upscaling API request.
+type ImageUpscaleRequest struct {
+	Image string `json:"image"` // Base64 data URI
+	Scale int    `json:"scale"`
+}
+
+// UpscaleResult is an enlarged image and how it was produced.
+type UpscaleResult struct {
+	Data   []byte // Encoded image; PNG when resized locally
+	Method string // UpscaleMethodAPI or UpscaleMethodLocal
+	Width  int
+	Height int
+}
+
 // ImageModel represents an image generation model.
 type ImageModel struct {
 	ID          string `json:"id"`
@@ -120,6 +178,8 @@ type ImageOptions struct {
 	Size    string // "widthxheight" format
 	UserID  string // Optional user ID for analytics
 	Model   string // Override default model
+
+	ReferenceImage string // Style reference: URL or base64 data URI
 }
 
 // WebReaderRequest represents a web reader API request.
@@ -170,13 +230,28 @@ type ChatOptions struct {
 	Temperature *float64 // Override default temperature
 	MaxTokens   *int     // Override default max tokens
 	TopP        *float64 // Override default top_p
+	Seed        *int     // Sampling seed for reproducible output (honored only if the API supports it)
 	Thinking    *bool    // Enable thinking mode
 	WebEnabled  *bool    // Enable web content fetching
 	WebTimeout  *int     // Web fetch timeout in seconds
+	WebFormat   string   // Return format for auto-fetched URLs: markdown (default) or text
+	StripURLs   bool     // Remove URLs from the prompt before sending (nothing is fetched)
+
+	WebCache *WebContentCache // Reuse pages already fetched this session (nil = always fetch)
+
+	Instructions string // Shared instructions prepended to the prompt, ahead of file contents
+
+	CompressFile  bool   // Embed a summary of FilePath instead of its raw contents
+	CompressModel string // Model used for the summary (default: DefaultCompressModel)
+
+	RetryOnEmpty bool // Retry when the API returns a response with empty content
+	CachePrefix  bool // Put the file context in the system message so repeated requests share a cacheable prefix
+
+	OnUsage func(Usage) // Called with the token usage of each successful response (optional)
 
 	// Legacy fields for backward compatibility
 	FilePath     string    // Optional file to include in context
-	Context      []Message // Previous messages for context
+	Context      []Message // Previous messages, sent after the system message and before the prompt
 	Think        bool      // Enable thinking/reasoning mode (legacy)
 	SystemPrompt string    // Custom system prompt
 }
@@ -242,13 +317,15 @@ type RetryConfig struct {
 	MaxAttempts    int           // Maximum number of retry attempts (default: 3)
 	InitialBackoff time.Duration // Initial backoff duration (default: 1s)
 	MaxBackoff     time.Duration // Maximum backoff duration (default: 30s)
+	MaxElapsed     time.Duration // Total time budget for retries (default: 0, unbounded)
+	TimeoutBackoff bool          // Lengthen the request timeout on each retry (1x, 1.5x, 2x, ...)
 }
 
 // VisionRequest represents a vision/image analysis API request.
 type VisionRequest struct {
 	Model       string          `json:"model"`
 	Messages    []VisionMessage `json:"messages"`
-	Stream      bool            `json:"stream"` // Reserved for future streaming API support
+	Stream      bool            `json:"stream"` // Server-sent events (see VisionStream)
 	Temperature float64         `json:"temperature,omitempty"`
 	MaxTokens   int             `json:"max_tokens,omitempty"`
 	TopP        float64         `json:"top_p,omitempty"`
@@ -269,15 +346,17 @@ type ContentPart struct {
 
 // ImageURLContent contains image URL or base64 data.
 type ImageURLContent struct {
-	URL string `json:"url"`
+	URL    string `json:"url"`
+	Detail string `json:"detail,omitempty"` // "low", "high", or "auto"
 }
 
 // VisionOptions configures vision/analysis requests.
 type VisionOptions struct {
-	Model       string   // Override default model (e.g., "glm-4.6v")
+	Model       string   // Override default model (DefaultVisionModel)
 	Temperature *float64 // Override default temperature
 	MaxTokens   *int     // Override default max tokens
 	TopP        *float64 // Override default top_p
+	Detail      string   // Image resolution: "low", "high", or "auto" (empty omits it)
 }
 
 // TranscriptionResponse represents the audio transcription API response.
@@ -287,6 +366,17 @@ type TranscriptionResponse struct {
 	RequestID string `json:"request_id,omitempty"`
 	Model     string `json:"model"`
 	Text      string `json:"text"`
+	// Segments is the timed breakdown of Text, when the model returns one.
+	Segments []TranscriptionSegment `json:"segments,omitempty"`
+}
+
+// TranscriptionSegment is a timed span of a transcript, in seconds.
+// Confidence is nil when the model doesn't score segments.
+type TranscriptionSegment struct {
+	Start      float64  `json:"start"`
+	End        float64  `json:"end"`
+	Text       string   `json:"text"`
+	Confidence *float64 `json:"confidence,omitempty"`
 }
 
 // TranscriptionOptions configures audio transcription requests.
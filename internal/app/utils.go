@@ -1,10 +1,20 @@
 package app
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 // urlRegex matches HTTP/HTTPS URLs
@@ -40,6 +50,14 @@ func ExtractURLs(text string) []string {
 	return urls
 }
 
+// StripURLs removes every URL from text, so it is neither fetched nor sent.
+func StripURLs(text string) string {
+	return urlRegex.ReplaceAllStringFunc(text, func(match string) string {
+		// Keep trailing punctuation, as ExtractURLs does not treat it as part of the URL
+		return match[len(strings.TrimRight(match, ".,!?;:)]}")):]
+	})
+}
+
 // normalizeURL ensures URL has proper scheme and is valid.
 func normalizeURL(raw string) string {
 	// Trim trailing punctuation
@@ -241,20 +259,30 @@ func DetectSearchIntent(text string) (hasIntent bool, query string) {
 // FormatSearchForContext formats search results as XML context for prompt augmentation.
 // This is used by the --search flag to prepend search results to prompts.
 func FormatSearchForContext(results []SearchResult) string {
+	return FormatSearchForContextWithBudget(results, 0)
+}
+
+// FormatSearchForContextWithBudget is FormatSearchForContext with a cap on the
+// estimated tokens (~4 bytes each) of the context. Results are added in rank
+// order until the next one would exceed maxTokens; the top result is always kept.
+// A maxTokens of 0 means no cap.
+func FormatSearchForContextWithBudget(results []SearchResult, maxTokens int) string {
 	if len(results) == 0 {
 		return ""
 	}
 
+	const header, footer = "<web_search_results>\n", "</web_search_results>"
 	var sb strings.Builder
-	sb.WriteString("<web_search_results>\n")
+	sb.WriteString(header)
 
 	resultTemplate := `<result>
 <title>%s</title>
 <url>%s</url>
 `
 
-	for _, result := range results {
-		sb.WriteString(fmt.Sprintf(resultTemplate, result.Title, result.Link))
+	for i, result := range results {
+		var rb strings.Builder
+		rb.WriteString(fmt.Sprintf(resultTemplate, result.Title, result.Link))
 
 		if result.Content != "" {
 			// Truncate very long content to keep context manageable
@@ -262,20 +290,479 @@ func FormatSearchForContext(results []SearchResult) string {
 			if len(content) > 1000 {
 				content = content[:1000] + "..."
 			}
-			sb.WriteString("<content>")
-			sb.WriteString(content)
-			sb.WriteString("</content>\n")
+			rb.WriteString("<content>")
+			rb.WriteString(content)
+			rb.WriteString("</content>\n")
 		}
 
 		if result.PublishDate != "" {
-			sb.WriteString("<date>")
-			sb.WriteString(result.PublishDate)
-			sb.WriteString("</date>\n")
+			rb.WriteString("<date>")
+			rb.WriteString(result.PublishDate)
+			rb.WriteString("</date>\n")
 		}
 
-		sb.WriteString("</result>\n")
+		rb.WriteString("</result>\n")
+
+		if maxTokens > 0 && i > 0 && (sb.Len()+rb.Len()+len(footer))/4 > maxTokens {
+			break
+		}
+		sb.WriteString(rb.String())
 	}
 
-	sb.WriteString("</web_search_results>")
+	sb.WriteString(footer)
 	return sb.String()
 }
+
+// windowsReservedNames are device names Windows refuses as file names, with or without extension.
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// SanitizeFilename makes name safe as a single path element on common filesystems.
+// Illegal characters become hyphens, whitespace runs collapse to one hyphen, and the
+// result is cut to maxLen runes (if maxLen > 0). Windows reserved names get a "_" prefix.
+func SanitizeFilename(name string, maxLen int) string {
+	var b strings.Builder
+	lastHyphen := false
+	for _, r := range name {
+		if r < 0x20 || r == 0x7f || unicode.IsSpace(r) || strings.ContainsRune(`<>:"/\|?*`, r) {
+			r = '-'
+		}
+		if r == '-' {
+			if lastHyphen {
+				continue
+			}
+			lastHyphen = true
+		} else {
+			lastHyphen = false
+		}
+		b.WriteRune(r)
+	}
+
+	result := strings.Trim(b.String(), "-. ")
+	if runes := []rune(result); maxLen > 0 && len(runes) > maxLen {
+		result = strings.TrimRight(string(runes[:maxLen]), "-. ")
+	}
+	if result == "" {
+		return "untitled"
+	}
+
+	stem, _, _ := strings.Cut(result, ".")
+	if windowsReservedNames[strings.ToUpper(stem)] {
+		result = "_" + result
+	}
+	return result
+}
+
+// fencedBlock matches ``` code fences, capturing the body.
+var fencedBlock = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n(.*?)```")
+
+// ExtractTables pulls tables out of a model response, dropping surrounding prose.
+// Fenced code blocks are preferred; otherwise runs of "|" lines are taken as
+// Markdown tables. For csv, an unfenced response is returned whole.
+func ExtractTables(response, format string) []string {
+	var tables []string
+	for _, m := range fencedBlock.FindAllStringSubmatch(response, -1) {
+		if body := strings.TrimSpace(m[1]); body != "" {
+			tables = append(tables, body)
+		}
+	}
+	if len(tables) > 0 {
+		return tables
+	}
+
+	if format == "csv" {
+		if body := strings.TrimSpace(response); body != "" {
+			return []string{body}
+		}
+		return nil
+	}
+
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			tables = append(tables, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(response, "\n") {
+		if trimmed := strings.TrimSpace(line); strings.HasPrefix(trimmed, "|") {
+			current = append(current, trimmed)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return tables
+}
+
+// WriteFileAtomic writes data to a temp file next to path and renames it into
+// place, creating parent directories. Readers never see a partial file.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) //nolint:errcheck // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close() //nolint:errcheck,gosec // already failing
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close() //nolint:errcheck,gosec // already failing
+		return fmt.Errorf("chmod %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("rename into %s: %w", path, err)
+	}
+	return nil
+}
+
+// positionalPlaceholder matches {{, }}, and {N} placeholders.
+var positionalPlaceholder = regexp.MustCompile(`\{\{|\}\}|\{(\d+)\}`)
+
+// HasPositionalPlaceholders reports whether template contains a {N} placeholder.
+func HasPositionalPlaceholders(template string) bool {
+	for _, m := range positionalPlaceholder.FindAllStringSubmatch(template, -1) {
+		if m[1] != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandPositional replaces {1}, {2}, ... with values (1-based).
+// {{ and }} produce literal braces. A placeholder without a value is an error.
+func ExpandPositional(template string, values []string) (string, error) {
+	var missing []string
+	out := positionalPlaceholder.ReplaceAllStringFunc(template, func(m string) string {
+		switch m {
+		case "{{":
+			return "{"
+		case "}}":
+			return "}"
+		}
+		n, err := strconv.Atoi(m[1 : len(m)-1])
+		if err != nil || n < 1 || n > len(values) {
+			missing = append(missing, m)
+			return m
+		}
+		return values[n-1]
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("no value for %s (got %d values)", strings.Join(missing, ", "), len(values))
+	}
+	return out, nil
+}
+
+// ParseMessages decodes a JSON array of {role, content} messages, such as a
+// transcript saved with --export-format json. Roles must be system, user,
+// or assistant, and content must be non-empty.
+func ParseMessages(data []byte) ([]Message, error) {
+	var messages []Message
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return nil, fmt.Errorf("expected a JSON array of {\"role\", \"content\"} messages: %w", err)
+	}
+	for i, msg := range messages {
+		switch msg.Role {
+		case "system", "user", "assistant":
+		default:
+			return nil, fmt.Errorf("message %d: invalid role %q (must be system, user, or assistant)", i+1, msg.Role)
+		}
+		if strings.TrimSpace(msg.Content) == "" {
+			return nil, fmt.Errorf("message %d: empty content", i+1)
+		}
+	}
+	return messages, nil
+}
+
+// ParseContextMessage parses a "role:content" message such as "user:I prefer Python".
+func ParseContextMessage(value string) (Message, error) {
+	role, content, ok := strings.Cut(value, ":")
+	if !ok {
+		return Message{}, fmt.Errorf("expected role:content, got %q", value)
+	}
+	role = strings.ToLower(strings.TrimSpace(role))
+	switch role {
+	case "system", "user", "assistant":
+	default:
+		return Message{}, fmt.Errorf("invalid role %q (must be system, user, or assistant)", role)
+	}
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return Message{}, fmt.Errorf("empty content for role %q", role)
+	}
+	return Message{Role: role, Content: content}, nil
+}
+
+// listMarker matches a leading bullet or number ("- ", "* ", "1. ", "2) ").
+var listMarker = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// ParseQueryList extracts one query per line from a model's reply, dropping
+// list markers, surrounding quotes, blanks, preamble lines ending in ":",
+// and case-insensitive duplicates.
+// At most limit queries are returned.
+func ParseQueryList(text string, limit int) []string {
+	seen := make(map[string]bool)
+	var queries []string
+	for _, line := range strings.Split(text, "\n") {
+		q := listMarker.ReplaceAllString(line, "")
+		q = strings.TrimSpace(strings.Trim(strings.TrimSpace(q), `"'`+"`"))
+		key := strings.ToLower(q)
+		if q == "" || strings.HasSuffix(q, ":") || seen[key] {
+			continue
+		}
+		if len(queries) == limit {
+			break
+		}
+		seen[key] = true
+		queries = append(queries, q)
+	}
+	return queries
+}
+
+// MergeSearchResults concatenates result lists in order, keeping the first
+// result for each link. Links differing only in scheme, host case, trailing
+// slash, or fragment count as the same page.
+func MergeSearchResults(lists ...[]SearchResult) []SearchResult {
+	seen := make(map[string]bool)
+	var merged []SearchResult
+	for _, list := range lists {
+		for _, result := range list {
+			key := searchLinkKey(result.Link)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, result)
+		}
+	}
+	return merged
+}
+
+// searchLinkKey normalizes a result link for de-duplication.
+func searchLinkKey(link string) string {
+	parsed, err := url.Parse(strings.TrimSpace(link))
+	if err != nil || parsed.Host == "" {
+		return link
+	}
+	return strings.ToLower(parsed.Host) + strings.TrimSuffix(parsed.EscapedPath(), "/") + "?" + parsed.RawQuery
+}
+
+// publishDateLayouts are the date formats seen in search result publish dates.
+var publishDateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02",
+	"2006.01.02",
+	"2006年01月02日",
+	"2006年1月2日",
+	time.RFC1123Z,
+	time.RFC1123,
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 Jan 2006",
+	"2 January 2006",
+}
+
+// relativeDateRegex matches relative dates such as "3 days ago".
+var relativeDateRegex = regexp.MustCompile(`^(\d+)\s*(minute|hour|day|week|month|year)s?\s+ago$`)
+
+// ParsePublishDate parses a search result publish date in any of the common
+// absolute formats, or a relative one ("2 hours ago") measured from now.
+func ParsePublishDate(s string, now time.Time) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range publishDateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+
+	m := relativeDateRegex.FindStringSubmatch(strings.ToLower(s))
+	if m == nil {
+		return time.Time{}, false
+	}
+	n, _ := strconv.Atoi(m[1])
+	switch m[2] {
+	case "minute":
+		return now.Add(-time.Duration(n) * time.Minute), true
+	case "hour":
+		return now.Add(-time.Duration(n) * time.Hour), true
+	case "day":
+		return now.AddDate(0, 0, -n), true
+	case "week":
+		return now.AddDate(0, 0, -7*n), true
+	case "month":
+		return now.AddDate(0, -n, 0), true
+	default:
+		return now.AddDate(-n, 0, 0), true
+	}
+}
+
+// SortByPublishDate returns the dated results newest first and how many
+// results were dropped for having no parseable publish date.
+func SortByPublishDate(results []SearchResult, now time.Time) (sorted []SearchResult, undated int) {
+	dates := make(map[int]time.Time, len(results))
+	var keep []int
+	for i, result := range results {
+		date, ok := ParsePublishDate(result.PublishDate, now)
+		if !ok {
+			undated++
+			continue
+		}
+		dates[i] = date
+		keep = append(keep, i)
+	}
+
+	sort.SliceStable(keep, func(a, b int) bool {
+		return dates[keep[a]].After(dates[keep[b]])
+	})
+	sorted = make([]SearchResult, 0, len(keep))
+	for _, i := range keep {
+		sorted = append(sorted, results[i])
+	}
+	return sorted, undated
+}
+
+// segmentIndexRegex captures the trailing segment number of a file name (chunk-012.wav).
+var segmentIndexRegex = regexp.MustCompile(`(\d+)\.[^.]*$`)
+
+// SortSegmentPaths orders segment files such as ffmpeg's chunk-%03d.wav by
+// their numeric index rather than lexically, so chunk-1000 follows chunk-999
+// regardless of how the directory listing was ordered. Paths without an index
+// sort after indexed ones, by name.
+func SortSegmentPaths(paths []string) []string {
+	index := func(path string) (int, bool) {
+		m := segmentIndexRegex.FindStringSubmatch(filepath.Base(path))
+		if m == nil {
+			return 0, false
+		}
+		n, err := strconv.Atoi(m[1])
+		return n, err == nil
+	}
+
+	sorted := slices.Clone(paths)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, aok := index(sorted[i])
+		b, bok := index(sorted[j])
+		switch {
+		case aok && bok && a != b:
+			return a < b
+		case aok != bok:
+			return aok
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// textSniffLen is how much of the input IsProbablyText inspects.
+const textSniffLen = 8192
+
+// IsProbablyText reports whether data looks like text rather than a binary
+// file: no NUL bytes and valid UTF-8 in the first 8KB.
+func IsProbablyText(data []byte) bool {
+	sample := data
+	if len(sample) > textSniffLen {
+		sample = sample[:textSniffLen]
+		// Don't count a multi-byte rune cut at the boundary as invalid
+		for i := 0; i < utf8.UTFMax-1 && len(sample) > 0 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	return bytes.IndexByte(sample, 0) < 0 && utf8.Valid(sample)
+}
+
+// Heading is a Markdown heading and the headings nested under it.
+type Heading struct {
+	Level    int        `json:"level"`
+	Title    string     `json:"title"`
+	Offset   int        `json:"offset"` // Characters (runes) before the heading line
+	Children []*Heading `json:"children,omitempty"`
+}
+
+var (
+	atxHeadingRegex   = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	setextUnderline   = regexp.MustCompile(`^ {0,3}(=+|-+)[ \t]*$`)
+	markdownLinkRegex = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+)
+
+// ParseMarkdownHeadings returns the headings of a Markdown document as a tree,
+// each nested under the closest preceding heading of a lower level. ATX (#)
+// and setext (=== / ---) headings are recognized; fenced code is skipped and
+// links in titles are reduced to their text.
+func ParseMarkdownHeadings(markdown string) []*Heading {
+	var roots, stack []*Heading
+	add := func(level int, title string, offset int) {
+		title = strings.TrimSpace(markdownLinkRegex.ReplaceAllString(title, "$1"))
+		if title == "" {
+			return
+		}
+		h := &Heading{Level: level, Title: title, Offset: offset}
+		for len(stack) > 0 && stack[len(stack)-1].Level >= level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			roots = append(roots, h)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, h)
+		}
+		stack = append(stack, h)
+	}
+
+	offset := 0
+	inFence := false
+	prev, prevOffset := "", 0 // Paragraph line that a setext underline would turn into a heading
+	for _, line := range strings.Split(markdown, "\n") {
+		lineOffset := offset
+		offset += utf8.RuneCountInString(line) + 1
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			prev = ""
+			continue
+		}
+		if inFence {
+			continue
+		}
+
+		if m := atxHeadingRegex.FindStringSubmatch(line); m != nil {
+			add(len(m[1]), m[2], lineOffset)
+			prev = ""
+			continue
+		}
+		if m := setextUnderline.FindStringSubmatch(line); m != nil && prev != "" {
+			level := 1
+			if m[1][0] == '-' {
+				level = 2
+			}
+			add(level, prev, prevOffset)
+			prev = ""
+			continue
+		}
+		prev, prevOffset = trimmed, lineOffset
+	}
+	return roots
+}
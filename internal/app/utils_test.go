@@ -1,9 +1,16 @@
 package app
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TestExtractURLs tests the ExtractURLs function with table-driven tests.
@@ -73,6 +80,12 @@ func TestExtractURLs(t *testing.T) {
 	}
 }
 
+func TestStripURLs(t *testing.T) {
+	input := "error fetching https://api.example.com/v1?x=1 (see www.example.org)\nretrying"
+	assert.Equal(t, "error fetching  (see )\nretrying", StripURLs(input))
+	assert.Empty(t, ExtractURLs(StripURLs(input)))
+}
+
 // TestNormalizeURL tests the normalizeURL function with table-driven tests.
 func TestNormalizeURL(t *testing.T) {
 	tests := []struct {
@@ -171,6 +184,27 @@ func TestFormatSearchForContext(t *testing.T) {
 	assert.Contains(t, result, "</web_search_results>")
 }
 
+func TestFormatSearchForContextWithBudget(t *testing.T) {
+	results := []SearchResult{
+		{Title: "First", Link: "https://example.com/1", Content: strings.Repeat("a", 400)},
+		{Title: "Second", Link: "https://example.com/2", Content: strings.Repeat("b", 400)},
+		{Title: "Third", Link: "https://example.com/3", Content: strings.Repeat("c", 400)},
+	}
+
+	assert.Equal(t, FormatSearchForContext(results), FormatSearchForContextWithBudget(results, 0))
+
+	// Each result is ~120 tokens, so a 300 token budget fits the top two
+	result := FormatSearchForContextWithBudget(results, 300)
+	assert.Contains(t, result, "Second")
+	assert.NotContains(t, result, "Third")
+	assert.True(t, strings.HasSuffix(result, "</web_search_results>"))
+
+	// The top result is kept even when it alone exceeds the budget
+	result = FormatSearchForContextWithBudget(results, 10)
+	assert.Contains(t, result, "First")
+	assert.NotContains(t, result, "Second")
+}
+
 // TestDefaultChatOptions tests the DefaultChatOptions function.
 func TestDefaultChatOptions(t *testing.T) {
 	opts := DefaultChatOptions()
@@ -180,3 +214,271 @@ func TestDefaultChatOptions(t *testing.T) {
 	assert.Equal(t, 8192, *opts.MaxTokens)
 	assert.Equal(t, 0.9, *opts.TopP)
 }
+
+// TestSanitizeFilename tests making arbitrary text safe as a file name.
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		maxLen   int
+		expected string
+	}{
+		{name: "plain", input: "sunset", expected: "sunset"},
+		{name: "whitespace collapses", input: "a cat  wearing\tsunglasses", expected: "a-cat-wearing-sunglasses"},
+		{name: "illegal characters", input: `what/is:this?<"x">|*\y`, expected: "what-is-this-x-y"},
+		{name: "control characters", input: "line\x00one\x1ftwo", expected: "line-one-two"},
+		{name: "trailing dots and spaces", input: "  name. . ", expected: "name"},
+		{name: "truncated", input: "one two three four", maxLen: 9, expected: "one-two-t"},
+		{name: "truncation trims hyphen", input: "one two three", maxLen: 8, expected: "one-two"},
+		{name: "empty", input: "///", expected: "untitled"},
+		{name: "reserved name", input: "CON", expected: "_CON"},
+		{name: "reserved name lowercase", input: "nul", expected: "_nul"},
+		{name: "reserved name with extension", input: "com1.txt", expected: "_com1.txt"},
+		{name: "not reserved", input: "console", expected: "console"},
+		{name: "unicode kept", input: "café ☕", expected: "café-☕"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SanitizeFilename(tt.input, tt.maxLen))
+		})
+	}
+}
+
+// TestExtractTables tests keeping only tables from a vision response.
+func TestExtractTables(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		format   string
+		expected []string
+	}{
+		{
+			name:     "markdown without fences",
+			response: "Here is the table:\n\n| a | b |\n|---|---|\n| 1 | 2 |\n\nAnd another:\n| x |\n|---|\n| y |\nDone.",
+			format:   "markdown",
+			expected: []string{"| a | b |\n|---|---|\n| 1 | 2 |", "| x |\n|---|\n| y |"},
+		},
+		{
+			name:     "fenced csv blocks",
+			response: "Table 1:\n```csv\nname,qty\napple,3\n```\nTable 2:\n```\nk,v\n```",
+			format:   "csv",
+			expected: []string{"name,qty\napple,3", "k,v"},
+		},
+		{
+			name:     "unfenced csv",
+			response: "\nname,qty\napple,3\n",
+			format:   "csv",
+			expected: []string{"name,qty\napple,3"},
+		},
+		{
+			name:     "no tables",
+			response: "I could not find a table.",
+			format:   "markdown",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, ExtractTables(tt.response, tt.format))
+		})
+	}
+}
+
+// TestWriteFileAtomic tests writing a file via temp file and rename.
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "nested", "out.json")
+
+	require.NoError(t, WriteFileAtomic(path, []byte("first"), 0600))
+	require.NoError(t, WriteFileAtomic(path, []byte("second"), 0600))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(data))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temp files should not be left behind")
+}
+
+// TestExpandPositional tests {N} substitution for inline prompt templates.
+func TestExpandPositional(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		values   []string
+		expected string
+		wantErr  bool
+	}{
+		{name: "two values", template: "translate {1} to {2}", values: []string{"hello", "French"}, expected: "translate hello to French"},
+		{name: "repeated and reordered", template: "{2}: {1} {1}", values: []string{"a", "b"}, expected: "b: a a"},
+		{name: "escaped braces", template: "as JSON {{\"text\": {1}}}", values: []string{"x"}, expected: "as JSON {\"text\": x}"},
+		{name: "values are not re-expanded", template: "{1}", values: []string{"{2}"}, expected: "{2}"},
+		{name: "missing value", template: "{1} and {3}", values: []string{"a"}, wantErr: true},
+		{name: "zero index", template: "{0}", values: []string{"a"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExpandPositional(tt.template, tt.values)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+
+	assert.True(t, HasPositionalPlaceholders("say {1}"))
+	assert.False(t, HasPositionalPlaceholders("literal {{1}} and {name}"))
+}
+
+func TestParseMessages(t *testing.T) {
+	messages, err := ParseMessages([]byte(`[
+  {"role": "system", "content": "You are terse."},
+  {"role": "user", "content": "hi"},
+  {"role": "assistant", "content": "hello"}
+]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Message{
+		{Role: "system", Content: "You are terse."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hello"},
+	}, messages)
+
+	for name, input := range map[string]string{
+		"not an array": `{"role": "user", "content": "hi"}`,
+		"bad role":     `[{"role": "bot", "content": "hi"}]`,
+		"empty":        `[{"role": "user", "content": " "}]`,
+		"invalid json": `[{"role": "user"`,
+	} {
+		_, err := ParseMessages([]byte(input))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestParseContextMessage(t *testing.T) {
+	msg, err := ParseContextMessage("User: I prefer Python: it's readable")
+	require.NoError(t, err)
+	assert.Equal(t, Message{Role: "user", Content: "I prefer Python: it's readable"}, msg)
+
+	for _, bad := range []string{"no role here", "bot:hi", "assistant:  "} {
+		_, err := ParseContextMessage(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestParseQueryList(t *testing.T) {
+	reply := "Here are some queries:\n1. golang generics tutorial\n- \"go type parameters\"\n* Golang Generics Tutorial\n\n2) go 1.18 generics performance\n"
+	assert.Equal(t, []string{
+		"golang generics tutorial",
+		"go type parameters",
+		"go 1.18 generics performance",
+	}, ParseQueryList(reply, 10))
+	assert.Len(t, ParseQueryList(reply, 2), 2)
+}
+
+func TestMergeSearchResults(t *testing.T) {
+	first := []SearchResult{{Title: "A", Link: "https://example.com/a"}, {Title: "B", Link: "https://example.com/b?x=1"}}
+	second := []SearchResult{
+		{Title: "A again", Link: "http://EXAMPLE.com/a/#intro"},
+		{Title: "B other query", Link: "https://example.com/b?x=2"},
+		{Title: "C", Link: "https://other.org/c"},
+	}
+
+	merged := MergeSearchResults(first, second)
+	titles := make([]string, len(merged))
+	for i, r := range merged {
+		titles[i] = r.Title
+	}
+	assert.Equal(t, []string{"A", "B", "B other query", "C"}, titles)
+}
+
+func TestSortByPublishDate(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	results := []SearchResult{
+		{Title: "old", PublishDate: "2024-05-01"},
+		{Title: "undated"},
+		{Title: "recent", PublishDate: "2 days ago"},
+		{Title: "garbled", PublishDate: "sometime"},
+		{Title: "cn", PublishDate: "2024年06月09日"},
+		{Title: "rfc", PublishDate: "2024-06-09T18:30:00Z"},
+		{Title: "prose", PublishDate: "May 20, 2024"},
+	}
+
+	sorted, undated := SortByPublishDate(results, now)
+	titles := make([]string, len(sorted))
+	for i, r := range sorted {
+		titles[i] = r.Title
+	}
+	assert.Equal(t, []string{"rfc", "cn", "recent", "prose", "old"}, titles)
+	assert.Equal(t, 2, undated)
+}
+
+// TestSortSegmentPaths tests that chunks are ordered by index, not by listing order.
+func TestSortSegmentPaths(t *testing.T) {
+	var want []string
+	for i := range 13 {
+		want = append(want, fmt.Sprintf("/tmp/zai-chunk-42-%d.wav", i))
+	}
+
+	// A lexical listing puts chunk 10 before chunk 2
+	lexical := slices.Clone(want)
+	slices.Sort(lexical)
+	assert.Equal(t, "/tmp/zai-chunk-42-10.wav", lexical[2])
+	assert.Equal(t, want, SortSegmentPaths(lexical))
+
+	shuffled := slices.Clone(want)
+	slices.Reverse(shuffled)
+	assert.Equal(t, want, SortSegmentPaths(shuffled))
+
+	// %03d padding overflows past 999
+	assert.Equal(t, []string{"c-998.wav", "c-999.wav", "c-1000.wav", "notes.wav"},
+		SortSegmentPaths([]string{"c-1000.wav", "notes.wav", "c-999.wav", "c-998.wav"}))
+}
+
+func TestIsProbablyText(t *testing.T) {
+	assert.True(t, IsProbablyText([]byte("plain text\nwith lines\t and tabs")))
+	assert.True(t, IsProbablyText([]byte("unicode: héllo 世界 🎉")))
+	assert.True(t, IsProbablyText(nil))
+
+	assert.False(t, IsProbablyText([]byte("RIFF\x24\x08\x00\x00WAVEfmt ")), "NUL bytes")
+	assert.False(t, IsProbablyText([]byte{0xff, 0xd8, 0xff, 0xe0, 'J', 'F', 'I', 'F'}), "invalid UTF-8")
+
+	// A multi-byte rune split by the sniff limit is still text
+	long := strings.Repeat("a", textSniffLen-1) + "世界"
+	assert.True(t, IsProbablyText([]byte(long)))
+}
+
+func TestParseMarkdownHeadings(t *testing.T) {
+	doc := "Guide\n=====\n\nIntro text.\n\n## Install ##\n\n```sh\n# not a heading\n```\n\n### From [source](https://example.com/src)\n\n## Usage\n\n#### Deep\n\nSetext section\n--------------\n"
+	roots := ParseMarkdownHeadings(doc)
+
+	require.Len(t, roots, 1)
+	guide := roots[0]
+	assert.Equal(t, "Guide", guide.Title)
+	assert.Equal(t, 0, guide.Offset)
+	require.Len(t, guide.Children, 3)
+
+	install := guide.Children[0]
+	assert.Equal(t, "Install", install.Title, "closing hashes are trimmed")
+	assert.Equal(t, strings.Index(doc, "## Install"), install.Offset)
+	require.Len(t, install.Children, 1, "fenced '# not a heading' is skipped")
+	assert.Equal(t, "From source", install.Children[0].Title)
+
+	usage := guide.Children[1]
+	require.Len(t, usage.Children, 1)
+	assert.Equal(t, 4, usage.Children[0].Level, "skipped levels still nest")
+
+	assert.Equal(t, Heading{Level: 2, Title: "Setext section", Offset: strings.Index(doc, "Setext")}, *guide.Children[2])
+
+	// Offsets count characters, not bytes
+	assert.Equal(t, 3, ParseMarkdownHeadings("é\n\n# Next")[0].Offset)
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxWAVFmtSize bounds the "fmt " chunk. The largest standard layout
+// (WAVE_FORMAT_EXTENSIBLE) is 40 bytes; anything far beyond is corrupt.
+const maxWAVFmtSize = 1024
+
+// IsDirectWAV reports whether path is a WAV file no longer than maxDuration,
+// judged from its header alone.
+func IsDirectWAV(path string, maxDuration time.Duration) bool {
+	if strings.ToLower(filepath.Ext(path)) != ".wav" {
+		return false
+	}
+	duration, err := WAVDuration(path)
+	return err == nil && duration <= maxDuration
+}
+
+// WAVDuration reads the RIFF/WAVE header of the file at path and returns the
+// audio duration.
+func WAVDuration(path string) (time.Duration, error) {
+	file, err := os.Open(path) //nolint:gosec // G304: path is the user's chosen audio file
+	if err != nil {
+		return 0, err
+	}
+	defer closeFile(file)
+	return ReadWAVDuration(file)
+}
+
+// ReadWAVDuration reads a RIFF/WAVE header and returns the audio duration.
+// Walks the chunk list to find "fmt " (byte rate) and "data" (payload size).
+func ReadWAVDuration(r io.ReadSeeker) (time.Duration, error) {
+	header := make([]byte, 12)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return 0, fmt.Errorf("read WAV header: %w", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, errors.New("not a RIFF/WAVE file")
+	}
+
+	var byteRate uint32
+	chunk := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, chunk); err != nil {
+			return 0, fmt.Errorf("read WAV chunk: %w", err)
+		}
+		id := string(chunk[0:4])
+		size := binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > maxWAVFmtSize {
+				return 0, fmt.Errorf("invalid WAV fmt chunk size %d", size)
+			}
+			fmtData := make([]byte, 16)
+			if _, err := io.ReadFull(r, fmtData); err != nil {
+				return 0, errors.New("invalid WAV fmt chunk")
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtData[8:12])
+			if _, err := r.Seek(int64(size)-16, io.SeekCurrent); err != nil {
+				return 0, fmt.Errorf("skip WAV fmt extension: %w", err)
+			}
+		case "data":
+			if byteRate == 0 {
+				return 0, errors.New("WAV data chunk before fmt chunk")
+			}
+			return time.Duration(float64(size) / float64(byteRate) * float64(time.Second)), nil
+		default:
+			if _, err := r.Seek(int64(size), io.SeekCurrent); err != nil {
+				return 0, fmt.Errorf("skip WAV chunk: %w", err)
+			}
+		}
+		// Chunks are word-aligned
+		if size%2 == 1 {
+			if _, err := r.Seek(1, io.SeekCurrent); err != nil {
+				return 0, fmt.Errorf("skip WAV padding: %w", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package app
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wavChunk encodes a RIFF chunk with its word-alignment padding.
+func wavChunk(id string, body []byte) []byte {
+	out := append([]byte(id), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...)
+	out = append(out, body...)
+	if len(body)%2 == 1 {
+		out = append(out, 0)
+	}
+	return out
+}
+
+// wavFmt is a 16-byte PCM fmt body: 16 kHz mono 16-bit (32000 bytes/s).
+func wavFmt() []byte {
+	body := binary.LittleEndian.AppendUint16(nil, 1) // PCM
+	body = binary.LittleEndian.AppendUint16(body, 1)
+	body = binary.LittleEndian.AppendUint32(body, 16000)
+	body = binary.LittleEndian.AppendUint32(body, 32000)
+	body = binary.LittleEndian.AppendUint16(body, 2)
+	return binary.LittleEndian.AppendUint16(body, 16)
+}
+
+func wavFile(chunks ...[]byte) []byte {
+	body := []byte("WAVE")
+	for _, c := range chunks {
+		body = append(body, c...)
+	}
+	return append(append([]byte("RIFF"), binary.LittleEndian.AppendUint32(nil, uint32(len(body)))...), body...)
+}
+
+func TestReadWAVDuration(t *testing.T) {
+	data := wavChunk("data", make([]byte, 64000))
+	oversized := append([]byte("fmt "), binary.LittleEndian.AppendUint32(nil, 0xFFFFFFF0)...)
+
+	tests := []struct {
+		name    string
+		file    []byte
+		want    time.Duration
+		wantErr string
+	}{
+		{name: "valid", file: wavFile(wavChunk("fmt ", wavFmt()), data), want: 2 * time.Second},
+		{name: "extra chunk before fmt", file: wavFile(wavChunk("LIST", []byte("odd")), wavChunk("fmt ", wavFmt()), data), want: 2 * time.Second},
+		{name: "extended fmt", file: wavFile(wavChunk("fmt ", append(wavFmt(), 0, 0)), data), want: 2 * time.Second},
+		{name: "truncated header", file: []byte("RIFF\x00\x00"), wantErr: "read WAV header"},
+		{name: "not wave", file: append([]byte("RIFF\x00\x00\x00\x00AVI "), data...), wantErr: "not a RIFF/WAVE"},
+		{name: "oversized fmt", file: wavFile(oversized), wantErr: "invalid WAV fmt chunk size"},
+		{name: "short fmt", file: wavFile(wavChunk("fmt ", wavFmt()[:12]), data), wantErr: "invalid WAV fmt chunk size"},
+		{name: "data before fmt", file: wavFile(data), wantErr: "before fmt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadWAVDuration(bytes.NewReader(tt.file))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestIsDirectWAV(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+	short := wavFile(wavChunk("fmt ", wavFmt()), wavChunk("data", make([]byte, 64000)))
+
+	assert.True(t, IsDirectWAV(write("short.WAV", short), 30*time.Second))
+	assert.False(t, IsDirectWAV(write("short.mp3", short), 30*time.Second), "extension must be .wav")
+	assert.False(t, IsDirectWAV(write("long.wav", short), time.Second))
+	assert.False(t, IsDirectWAV(write("bad.wav", []byte("RIFF")), 30*time.Second))
+	assert.False(t, IsDirectWAV(filepath.Join(dir, "missing.wav"), 30*time.Second))
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
 	"time"
 
 	"github.com/spf13/viper"
@@ -14,6 +16,23 @@ type Config struct {
 	API       APIConfig       `mapstructure:"api"`
 	WebReader WebReaderConfig `mapstructure:"web_reader"`
 	WebSearch WebSearchConfig `mapstructure:"web_search"`
+	History   HistoryConfig   `mapstructure:"history"`
+	UI        UIConfig        `mapstructure:"ui"`
+	Media     MediaConfig     `mapstructure:"media"`
+	Audio     AudioConfig     `mapstructure:"audio"`
+	Vision    VisionConfig    `mapstructure:"vision"`
+	Chat      ChatConfig      `mapstructure:"chat"`
+	Output    OutputConfig    `mapstructure:"output"`
+	Notify    NotifyConfig    `mapstructure:"notify"`
+	Pricing   PricingConfig   `mapstructure:"pricing"`
+
+	// SystemPrompt replaces the built-in "be concise" system prompt; --system overrides it
+	SystemPrompt string `mapstructure:"system_prompt"`
+	// SystemMerge combines system_prompt, --system-file, and --system: replace or append
+	SystemMerge string `mapstructure:"system_merge"`
+
+	// Presets are named bundles of flag or config values applied with --preset
+	Presets map[string]map[string]interface{} `mapstructure:"presets"`
 }
 
 // APIConfig holds API connection settings.
@@ -25,6 +44,10 @@ type APIConfig struct {
 	Model          string               `mapstructure:"model"`
 	ImageModel     string               `mapstructure:"image_model"`
 	VideoModel     string               `mapstructure:"video_model"`
+	Version        string               `mapstructure:"version"`
+	Region         string               `mapstructure:"region"`
+	Headers        map[string]string    `mapstructure:"headers"`      // Extra headers for every request
+	HeadersFile    string               `mapstructure:"headers_file"` // "Name: value" lines layered over Headers
 	RateLimit      RateLimitConfig      `mapstructure:"rate_limit"`
 	Retry          RetryConfig          `mapstructure:"retry"`
 	CircuitBreaker CircuitBreakerConfig `mapstructure:"circuit_breaker"`
@@ -41,6 +64,9 @@ type RetryConfig struct {
 	MaxAttempts    int           `mapstructure:"max_attempts"`
 	InitialBackoff time.Duration `mapstructure:"initial_backoff"`
 	MaxBackoff     time.Duration `mapstructure:"max_backoff"`
+	MaxElapsed     time.Duration `mapstructure:"max_elapsed"`
+	OnEmpty        bool          `mapstructure:"on_empty"`
+	TimeoutBackoff bool          `mapstructure:"timeout_backoff"` // Lengthen the timeout on each retry
 }
 
 // CircuitBreakerConfig holds circuit breaker settings.
@@ -59,6 +85,8 @@ type WebReaderConfig struct {
 	ReturnFormat     string `mapstructure:"return_format"`
 	AutoDetect       bool   `mapstructure:"auto_detect"`
 	MaxContentLength int    `mapstructure:"max_content_length"`
+	ChatFormat       string `mapstructure:"chat_format"`  // Format of URLs auto-fetched into chat prompts
+	SnapshotDir      string `mapstructure:"snapshot_dir"` // Page snapshots for reader --diff
 }
 
 // WebSearchConfig holds web search settings.
@@ -70,6 +98,142 @@ type WebSearchConfig struct {
 	CacheEnabled   bool          `mapstructure:"cache_enabled"`
 	CacheDir       string        `mapstructure:"cache_dir"`
 	CacheTTL       time.Duration `mapstructure:"cache_ttl"`
+	ContextBudget  int           `mapstructure:"context_budget"` // Max estimated tokens of --search context (0 = no cap)
+}
+
+// HistoryConfig holds history storage settings.
+type HistoryConfig struct {
+	Sharded       bool   `mapstructure:"sharded"`
+	Dir           string `mapstructure:"dir"`
+	Encrypt       bool   `mapstructure:"encrypt"`         // Seal new entries with ZAI_HISTORY_KEY
+	MaxEntryChars int    `mapstructure:"max_entry_chars"` // Truncate stored prompt/response (0 = unlimited)
+}
+
+// MediaConfig holds settings for saved image and video files.
+type MediaConfig struct {
+	OutputDir      string `mapstructure:"output_dir"`       // Directory for auto-named files
+	OrganizeByDate bool   `mapstructure:"organize_by_date"` // Nest auto-named files under YYYY/MM/DD
+}
+
+// AudioConfig holds transcription settings.
+type AudioConfig struct {
+	LanguageModels map[string]string `mapstructure:"language_models"` // ISO 639-1 code -> ASR model, used by --detect-language
+}
+
+// OutputConfig holds settings for files zai writes.
+type OutputConfig struct {
+	FileMode string `mapstructure:"file_mode"` // Octal permissions, e.g. "0600"; empty keeps each command's default
+}
+
+// NotifyConfig holds settings for --notify completion notifications.
+type NotifyConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // Notify when a command finishes
+	Command string `mapstructure:"command"` // e.g. notify-send; empty rings the terminal bell
+}
+
+// PricingConfig holds token prices for the --usage cost estimate.
+type PricingConfig struct {
+	CostPer1KPrompt     float64 `mapstructure:"cost_per_1k_prompt"`     // Dollars per 1000 prompt tokens
+	CostPer1KCompletion float64 `mapstructure:"cost_per_1k_completion"` // Dollars per 1000 completion tokens
+}
+
+// VisionConfig holds vision upload settings.
+type VisionConfig struct {
+	MaxImageBytes int64 `mapstructure:"max_image_bytes"` // Largest local image to upload
+}
+
+// ChatConfig holds prompt-building settings shared by one-shot and chat.
+type ChatConfig struct {
+	InstructionsFile string `mapstructure:"instructions_file"` // Prepended to every prompt
+	CompressModel    string `mapstructure:"compress_model"`    // Summarizes -f files with --compress
+}
+
+// UIConfig holds terminal display settings.
+type UIConfig struct {
+	Spinner string `mapstructure:"spinner"` // braille, dots, line, arc, or none
+}
+
+// RegionEndpoints holds the API base URLs for one region.
+type RegionEndpoints struct {
+	BaseURL       string
+	CodingBaseURL string
+}
+
+// Regions maps api.region names to their endpoints.
+var Regions = map[string]RegionEndpoints{
+	"global": {
+		BaseURL:       "https://api.z.ai/api/paas/v4",
+		CodingBaseURL: "https://api.z.ai/api/coding/paas/v4",
+	},
+	"cn": {
+		BaseURL:       "https://open.bigmodel.cn/api/paas/v4",
+		CodingBaseURL: "https://open.bigmodel.cn/api/coding/paas/v4",
+	},
+}
+
+// RegionNames returns the known region names, sorted.
+func RegionNames() []string {
+	names := make([]string, 0, len(Regions))
+	for name := range Regions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// KnownKeys returns every dotted key defined by Config, e.g. "api.retry.max_attempts".
+func KnownKeys() []string {
+	var keys []string
+	collectKeys(reflect.TypeOf(Config{}), "", &keys)
+	sort.Strings(keys)
+	return keys
+}
+
+// collectKeys appends the mapstructure keys of t's fields, recursing into
+// nested config structs. time.Duration and other non-struct types are leaves.
+func collectKeys(t reflect.Type, prefix string, keys *[]string) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		key := prefix + field.Tag.Get("mapstructure")
+		if field.Type.Kind() == reflect.Struct {
+			collectKeys(field.Type, key+".", keys)
+			continue
+		}
+		*keys = append(*keys, key)
+	}
+}
+
+// ClosestKey returns the known key nearest to key by edit distance, for
+// "did you mean" hints. Returns "" when nothing is within two edits.
+func ClosestKey(key string, known []string) string {
+	best, bestDist := "", 3
+	for _, k := range known {
+		if d := editDistance(key, k); d < bestDist {
+			best, bestDist = k, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
 }
 
 // Load unmarshals viper config into struct
@@ -89,6 +253,8 @@ func SetDefaults() {
 	viper.SetDefault("api.model", "glm-4.7")
 	viper.SetDefault("api.image_model", "glm-image")
 	viper.SetDefault("api.video_model", "cogvideox-3")
+	viper.SetDefault("system_prompt", "")
+	viper.SetDefault("system_merge", "replace")
 
 	// Rate limit defaults
 	viper.SetDefault("api.rate_limit.requests_per_second", 10)
@@ -98,6 +264,9 @@ func SetDefaults() {
 	viper.SetDefault("api.retry.max_attempts", 3)
 	viper.SetDefault("api.retry.initial_backoff", "1s")
 	viper.SetDefault("api.retry.max_backoff", "30s")
+	viper.SetDefault("api.retry.max_elapsed", "0s")
+	viper.SetDefault("api.retry.on_empty", false)
+	viper.SetDefault("api.retry.timeout_backoff", false)
 
 	// Circuit breaker defaults
 	viper.SetDefault("api.circuit_breaker.enabled", true)
@@ -112,6 +281,7 @@ func SetDefaults() {
 	viper.SetDefault("web_reader.return_format", "markdown")
 	viper.SetDefault("web_reader.auto_detect", true)
 	viper.SetDefault("web_reader.max_content_length", 50000)
+	viper.SetDefault("web_reader.chat_format", "markdown")
 
 	// Web search defaults
 	home, err := os.UserHomeDir()
@@ -123,6 +293,35 @@ func SetDefaults() {
 	viper.SetDefault("web_search.default_recency", "noLimit")
 	viper.SetDefault("web_search.timeout", 30)
 	viper.SetDefault("web_search.cache_enabled", true)
-	viper.SetDefault("web_search.cache_dir", filepath.Join(home, ".config", "zai", "search_cache"))
-	viper.SetDefault("web_search.cache_ttl", "24h")
+	viper.SetDefault("web_search.cache_dir", filepath.Join(home, ".cache", "zai", "search"))
+	viper.SetDefault("web_search.cache_ttl", "1h")
+	viper.SetDefault("web_search.context_budget", 0)
+	viper.SetDefault("web_reader.snapshot_dir", filepath.Join(home, ".cache", "zai", "web-snapshots"))
+
+	// UI defaults
+	viper.SetDefault("ui.spinner", "braille")
+
+	// Vision defaults
+	viper.SetDefault("vision.max_image_bytes", 5*1024*1024)
+
+	// Media output defaults
+	viper.SetDefault("media.output_dir", "")
+	viper.SetDefault("media.organize_by_date", false)
+
+	// Output file defaults (empty = per-command default permissions)
+	viper.SetDefault("output.file_mode", "")
+
+	// Notification defaults (empty command = terminal bell)
+	viper.SetDefault("notify.enabled", false)
+	viper.SetDefault("notify.command", "")
+
+	// Pricing defaults (0 = no cost estimate)
+	viper.SetDefault("pricing.cost_per_1k_prompt", 0.0)
+	viper.SetDefault("pricing.cost_per_1k_completion", 0.0)
+
+	// History defaults
+	viper.SetDefault("history.sharded", false)
+	viper.SetDefault("history.dir", filepath.Join(home, ".config", "zai", "history"))
+	viper.SetDefault("history.encrypt", false)
+	viper.SetDefault("history.max_entry_chars", 0)
 }